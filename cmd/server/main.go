@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"os"
 
 	"fmt"
@@ -24,10 +25,22 @@ func printServerInfo() {
 func main() {
 	printServerInfo()
 
+	migrate := len(os.Args) > 1 && os.Args[1] == "migrate"
+	if migrate {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	cfg := app.LoadConfig()
 
 	log := logger.NewLogger(os.Stdout, cfg.LogLevel, cfg.ServiceName)
 
+	if migrate {
+		if err := app.Migrate(cfg, log, flag.Args()); err != nil {
+			log.Fatal().Err(err).Send()
+		}
+		return
+	}
+
 	if err := app.Run(cfg, log); err != nil {
 		log.Fatal().Err(err).Send()
 	}
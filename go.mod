@@ -5,13 +5,17 @@ go 1.22.7
 require (
 	github.com/gin-gonic/gin v1.10.0
 	github.com/go-resty/resty/v2 v2.14.0
+	github.com/golang/snappy v0.0.4
 	github.com/jackc/pgx/v5 v5.7.0
 	github.com/mailru/easyjson v0.7.7
 	github.com/rs/zerolog v1.33.0
 	github.com/shirou/gopsutil/v3 v3.24.5
 	github.com/stretchr/testify v1.9.0
 	github.com/testcontainers/testcontainers-go v0.33.0
+	go.etcd.io/bbolt v1.3.11
 	golang.org/x/tools v0.25.0
+	google.golang.org/grpc v1.64.1
+	google.golang.org/protobuf v1.34.1
 	honnef.co/go/tools v0.5.1
 )
 
@@ -92,6 +96,6 @@ require (
 	golang.org/x/sync v0.8.0 // indirect
 	golang.org/x/sys v0.25.0 // indirect
 	golang.org/x/text v0.18.0 // indirect
-	google.golang.org/protobuf v1.34.1 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
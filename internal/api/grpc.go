@@ -0,0 +1,35 @@
+package api
+
+import (
+	"google.golang.org/grpc"
+
+	"github.com/Imomali1/metrics/internal/handlers"
+	"github.com/Imomali1/metrics/internal/pkg/interceptors"
+	"github.com/Imomali1/metrics/internal/pkg/pb"
+)
+
+// NewGRPCServer creates gRPC server serving the same use case as NewRouter,
+// hash validation and decryption are done in the same order as in router.
+func NewGRPCServer(options Options) *grpc.Server {
+	updateMethods := []string{
+		pb.Metrics_UpdateMetrics_FullMethodName,
+		pb.Metrics_UpdateMetricsStream_FullMethodName,
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			interceptors.TrustedSubnet(options.Logger, options.Cfg.TrustedSubnet, updateMethods...),
+			interceptors.ValidateHash(options.Logger, options.Cfg.HashKey),
+			interceptors.RSADecrypt(options.Logger, options.PrivateKey),
+		),
+		grpc.ChainStreamInterceptor(
+			interceptors.TrustedSubnetStream(options.Logger, options.Cfg.TrustedSubnet, updateMethods...),
+			interceptors.ValidateHashStream(options.Logger, options.Cfg.HashKey),
+			interceptors.RSADecryptStream(options.Logger, options.PrivateKey),
+		),
+	)
+
+	pb.RegisterMetricsServer(server, handlers.NewMetricServer(options.Logger, options.UseCase))
+
+	return server
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"net"
 	"net/http"
 	"net/http/pprof"
 
@@ -20,6 +21,8 @@ type Handlers struct {
 
 type Config struct {
 	HashKey string
+	// TrustedSubnet limits clients allowed to update metrics, nil allows everyone.
+	TrustedSubnet *net.IPNet
 }
 
 type Options struct {
@@ -48,13 +51,17 @@ func NewRouter(options Options) *gin.Engine {
 
 	router.GET("/", h.MetricHandler.ListMetrics)
 
+	router.GET("/metrics", h.MetricHandler.PrometheusMetrics)
+
 	router.GET("/ping", h.MetricHandler.PingDB)
 
 	router.GET("/healthz", func(ctx *gin.Context) {
 		ctx.Status(http.StatusOK)
 	})
 
-	updateRoutes := router.Group("/update")
+	trustedSubnet := middlewares.TrustedSubnet(options.Logger, options.Cfg.TrustedSubnet)
+
+	updateRoutes := router.Group("/update", trustedSubnet)
 	{
 		// v1 update handler using URI
 		updateRoutes.POST("/:type/:name/:value", h.MetricHandler.UpdateMetricValue)
@@ -63,11 +70,17 @@ func NewRouter(options Options) *gin.Engine {
 		updateRoutes.POST("/", h.MetricHandler.UpdateMetricValueJSON)
 	}
 
-	updatesRoute := router.Group("/updates")
+	updatesRoute := router.Group("/updates", trustedSubnet)
 	{
 		updatesRoute.POST("/", h.MetricHandler.Updates)
 	}
 
+	// InfluxDB v2 compatible write endpoint, e.g. for Telegraf
+	router.POST("/api/v2/write", trustedSubnet, h.MetricHandler.InfluxWrite)
+
+	// Prometheus remote write receiver
+	router.POST("/api/v1/write", trustedSubnet, h.MetricHandler.RemoteWrite)
+
 	getValueRoutes := router.Group("/value")
 	{
 		// v1 get value handler using URI
@@ -77,6 +90,8 @@ func NewRouter(options Options) *gin.Engine {
 		getValueRoutes.POST("/", h.MetricHandler.GetMetricValueByNameJSON)
 	}
 
+	router.GET("/history/:type/:name", h.MetricHandler.GetMetricHistory)
+
 	router.GET("/debug/pprof/", gin.WrapF(pprof.Index))
 	router.GET("/debug/pprof/cmdline", gin.WrapF(pprof.Cmdline))
 	router.GET("/debug/pprof/profile", gin.WrapF(pprof.Profile))
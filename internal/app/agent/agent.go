@@ -1,36 +1,50 @@
 package agent
 
 import (
-	"errors"
+	"context"
+	"crypto/rsa"
 	"fmt"
 	"net"
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
-	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/go-resty/resty/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
 	"github.com/Imomali1/metrics/internal/pkg/cipher"
+	"github.com/Imomali1/metrics/internal/pkg/interceptors"
 	"github.com/Imomali1/metrics/internal/pkg/logger"
-	"github.com/Imomali1/metrics/internal/pkg/utils"
-	"github.com/go-resty/resty/v2"
+	"github.com/Imomali1/metrics/internal/pkg/pb"
+	"github.com/Imomali1/metrics/internal/pkg/retry"
+	"github.com/Imomali1/metrics/internal/pkg/spool"
 )
 
 type agent struct {
 	cfg        Config
 	log        logger.Logger
-	metrics    *Metrics
 	poller     poller
 	reporter   reporter
 	jobsChan   chan Job
 	shutdownCh chan struct{}
-}
-
-type Metrics struct {
-	mu        sync.RWMutex
-	PollCount int64
-	Arr       []entity.Metrics
+	// spool keeps jobs that could not be sent, nil when disabled.
+	spool *spool.Spool
+	// dropped counts jobs dropped without spooling.
+	dropped atomic.Int64
+	// local keeps metrics pushed by local applications, nil when disabled.
+	local *localMetrics
+	// counters tracks increments of collected counters.
+	counters *counterTracker
+	// retry is policy of sending jobs to server.
+	retry retry.Policy
+	// endpoints selects HTTP server jobs are sent to.
+	endpoints *endpoints
+	// self tracks work of agent, see selfSnapshot.
+	self selfMetrics
 }
 
 func Run(cfg Config, log logger.Logger) error {
@@ -39,40 +53,131 @@ func Run(cfg Config, log logger.Logger) error {
 		return fmt.Errorf("failed to upload public key: %w", err)
 	}
 
-	app := agent{
-		cfg:     cfg,
-		log:     log,
-		metrics: &Metrics{},
+	registry, err := newRegistry(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to register collectors: %w", err)
+	}
+
+	app := &agent{
+		cfg: cfg,
+		log: log,
 		poller: poller{
 			interval: time.Duration(cfg.PollInterval) * time.Second,
+			registry: registry,
 		},
 		reporter: reporter{
-			interval:  time.Duration(cfg.ReportInterval) * time.Second,
-			publicKey: publicKey,
+			interval:   time.Duration(cfg.ReportInterval) * time.Second,
+			publicKey:  publicKey,
+			httpClient: resty.New(),
 		},
 		jobsChan:   make(chan Job),
 		shutdownCh: make(chan struct{}),
+		counters:   newCounterTracker(),
+		retry:      cfg.RetryPolicy(),
 	}
 
-	if err = checkServer(cfg.ServerAddress); err != nil {
-		return fmt.Errorf("failed to check server: %w", err)
+	// ctx is done on shutdown, so that jobs stop waiting for retries
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	app.reporter.transport, err = newTransport(app)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Transport == transportGRPC {
+		// endpoints of HTTP transports detect outbound ip on their own
+		realIP, errIP := outboundIP(cfg.GRPCAddress)
+		if errIP != nil {
+			log.Info().Err(errIP).Msg("cannot detect outbound ip, X-Real-IP will not be sent")
+		}
+
+		var conn *grpc.ClientConn
+		conn, err = newGRPCConn(cfg, publicKey, realIP)
+		if err != nil {
+			return fmt.Errorf("failed to create grpc client: %w", err)
+		}
+		defer conn.Close()
+
+		app.reporter.grpcClient = pb.NewMetricsClient(conn)
+	}
+
+	if cfg.SpoolDir != "" {
+		app.spool, err = spool.New(cfg.SpoolDir,
+			int64(cfg.SpoolMaxSize),
+			time.Duration(cfg.SpoolMaxAge)*time.Second,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to open spool: %w", err)
+		}
+		app.spool.OnEvict(app.evictJob)
+	}
+
+	app.endpoints = newEndpoints(cfg.ServerAddresses,
+		cfg.FailoverThreshold,
+		time.Duration(cfg.HealthCheckInterval)*time.Second,
+		app.reporter.httpClient,
+		log,
+	)
+
+	// servers are checked periodically, so agent starts even if none is up
+	if cfg.Transport != transportGRPC {
+		if err = app.retry.Do(ctx, app.endpoints.check); err != nil {
+			log.Info().Err(err).Msg("server is not available, metrics are reported once it is up")
+		}
+	}
+
+	var localListener net.Listener
+	if cfg.LocalAddress != "" {
+		localListener, err = listenLocal(cfg.LocalAddress)
+		if err != nil {
+			return fmt.Errorf("failed to listen local address: %w", err)
+		}
+		app.local = newLocalMetrics()
+	}
+
+	var selfListener net.Listener
+	if cfg.SelfMetricsAddress != "" {
+		selfListener, err = net.Listen("tcp", cfg.SelfMetricsAddress)
+		if err != nil {
+			return fmt.Errorf("failed to listen self metrics address: %w", err)
+		}
 	}
 
 	log.Info().Msg("agent is up and running...")
 
 	for i := 0; i < cfg.RateLimit; i++ {
-		go app.worker()
+		go app.worker(ctx)
 	}
 
+	// goroutines are added to wg before they start, so that jobs channel
+	// is closed only after every goroutine sending to it has returned
 	var wg sync.WaitGroup
+	spawn := func(fn func(wg *sync.WaitGroup)) {
+		wg.Add(1)
+		go fn(&wg)
+	}
 
-	go app.PollMetricsPeriodically(&wg)
-	go app.ReportMetricsPeriodically(&wg)
+	spawn(app.PollMetricsPeriodically)
+	spawn(app.ReportMetricsPeriodically)
+	if app.spool != nil {
+		spawn(app.DrainSpoolPeriodically)
+	}
+	if cfg.Transport != transportGRPC {
+		spawn(app.CheckEndpointsPeriodically)
+	}
+	if localListener != nil {
+		spawn(func(wg *sync.WaitGroup) { app.ServeLocalMetrics(wg, localListener) })
+	}
+	if selfListener != nil {
+		spawn(func(wg *sync.WaitGroup) { app.ServeSelfMetrics(wg, selfListener) })
+	}
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGTERM|syscall.SIGINT|syscall.SIGQUIT)
 
 	<-quit
+	cancel()
 	close(app.shutdownCh)
 	wg.Wait()
 	close(app.jobsChan)
@@ -80,19 +185,32 @@ func Run(cfg Config, log logger.Logger) error {
 	return nil
 }
 
-func checkServer(address string) error {
-	client := resty.New()
-	url := fmt.Sprintf("http://%s/healthz", address)
-
-	var err error
-	err = utils.DoWithRetries(func() error {
-		_, err = client.R().Get(url)
-		return err
-	})
+// newGRPCConn creates connection to gRPC server, requests are encrypted
+// first and then signed, so server validates hash before decryption.
+func newGRPCConn(cfg Config, publicKey *rsa.PublicKey, realIP string) (*grpc.ClientConn, error) {
+	return grpc.NewClient(cfg.GRPCAddress,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(
+			interceptors.RealIP(realIP),
+			interceptors.EncryptRequest(publicKey),
+			interceptors.SignRequest(cfg.HashKey),
+		),
+		grpc.WithChainStreamInterceptor(
+			interceptors.RealIPStream(realIP),
+			interceptors.EncryptRequestStream(publicKey),
+			interceptors.SignRequestStream(cfg.HashKey),
+		),
+	)
+}
 
-	var opErr *net.OpError
-	if errors.As(err, &opErr) {
-		return opErr.Err
+// outboundIP returns address of interface used to reach server,
+// dialing UDP does not send any packets.
+func outboundIP(address string) (string, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return "", err
 	}
-	return err
+	defer conn.Close()
+
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
 }
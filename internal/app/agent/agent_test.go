@@ -0,0 +1,16 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_outboundIP(t *testing.T) {
+	ip, err := outboundIP("127.0.0.1:8080")
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", ip)
+
+	_, err = outboundIP("invalid address")
+	require.Error(t, err)
+}
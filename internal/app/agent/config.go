@@ -2,39 +2,120 @@ package agent
 
 import (
 	"flag"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/collector"
+	"github.com/Imomali1/metrics/internal/pkg/retry"
 	"github.com/Imomali1/metrics/internal/pkg/utils"
 )
 
 type Config struct {
-	ServerAddress  string
+	// ServerAddresses lists HTTP servers in order of preference, see endpoints.
+	ServerAddresses []string
+
+	GRPCAddress    string
+	Transport      string
 	PollInterval   int
 	ReportInterval int
 	HashKey        string
 	RateLimit      int
 	PublicKeyPath  string
+	Labels         entity.Labels
+	// EnabledCollectors lists names of polled collectors, empty list enables all.
+	EnabledCollectors  []string
+	DisabledCollectors []string
+	// DiskFilter selects devices and mountpoints, NetFilter selects network interfaces.
+	DiskFilter collector.Filter
+	NetFilter  collector.Filter
+	// LocalAddress is TCP address or unix:// socket path accepting metrics
+	// pushed by local applications, empty disables it.
+	LocalAddress string
+	// SpoolDir keeps reports that could not be sent, empty disables spool.
+	// Directory must not be shared by several agents.
+	SpoolDir     string
+	SpoolMaxSize int
+	SpoolMaxAge  int
+	// RetryMaxAttempts limits attempts of sending job and checking server,
+	// RetryBaseDelay and RetryMaxDelay bound backoff in milliseconds.
+	RetryMaxAttempts int
+	RetryBaseDelay   int
+	RetryMaxDelay    int
+	// HealthCheckInterval is period of checking servers in seconds,
+	// FailoverThreshold is number of consecutive failed reports
+	// after which the next server is used. FanOut mirrors reports
+	// to every available server.
+	HealthCheckInterval int
+	FailoverThreshold   int
+	FanOut              bool
+
+	// SelfMetricsAddress is TCP address exposing metrics of agent
+	// itself on /metrics, empty disables it.
+	SelfMetricsAddress string
 
 	LogLevel    string
 	ServiceName string
 }
 
+// Transports supported by agent for reporting metrics, see transports.
+// Jobs are sent either over transportHTTP or transportGRPC.
+const (
+	transportURI   = "uri"
+	transportJSON  = "json"
+	transportBatch = "batch"
+	transportGRPC  = "grpc"
+	// transportHTTP is kept as alias of transportBatch.
+	transportHTTP = "http"
+)
+
 const (
 	defaultServerAddress  = "localhost:8080"
+	defaultGRPCAddress    = "localhost:3200"
+	defaultTransport      = transportBatch
 	defaultPollInterval   = 2
 	defaultReportInterval = 10
+	defaultSpoolMaxSize   = 10 * 1024 * 1024
+	defaultSpoolMaxAge    = 60 * 60
+	defaultRetryAttempts  = 4
+	defaultRetryBaseDelay = 1000
+	defaultRetryMaxDelay  = 5000
+	defaultHealthInterval = 5
+	defaultFailover       = 3
 	defaultLogLevel       = "info"
 	defaultServiceName    = "metrics_agent"
 )
 
 func LoadConfig() (cfg Config) {
-	serverAddress := flag.String("a", "", "отвечает за адрес эндпоинта HTTP-сервера")
+	serverAddress := flag.String("a", "", "адреса эндпоинтов HTTP-серверов через запятую в порядке приоритета")
+	grpcAddress := flag.String("g", "", "адрес gRPC-сервера")
+	transport := flag.String("transport", "", "способ отправки метрик на сервер: uri, json, batch или grpc")
 	pollInterval := flag.Int("p", 0, "частота опроса метрик из пакета runtime")
 	reportInterval := flag.Int("r", 0, "частота отправки метрик на сервер")
 	hashKey := flag.String("k", "", "Ключ для подписи данных")
 	rateLimit := flag.Int("l", 1, "количество одновременно исходящих запросов на сервер")
 	publicKeyPath := flag.String("crypto-key", "", "путь до файла с публичным ключом")
+	labels := flag.String("labels", "", "метки, добавляемые ко всем метрикам, в формате host=a,service=b")
+	enabledCollectors := flag.String("collectors", "", "включённые сборщики метрик через запятую, по умолчанию все")
+	disabledCollectors := flag.String("disable-collectors", "", "выключенные сборщики метрик через запятую")
+	diskInclude := flag.String("disk-include", "", "устройства и точки монтирования для сбора метрик дисков через запятую")
+	diskExclude := flag.String("disk-exclude", "", "исключаемые устройства и точки монтирования через запятую")
+	netInclude := flag.String("net-include", "", "сетевые интерфейсы для сбора метрик через запятую")
+	netExclude := flag.String("net-exclude", "", "исключаемые сетевые интерфейсы через запятую")
+	localAddress := flag.String("local-address", "", "адрес или unix:// сокет для приёма метрик локальных приложений")
+	spoolDir := flag.String("spool-dir", "", "директория для неотправленных метрик, по умолчанию не используется")
+	spoolMaxSize := flag.Int("spool-max-size", 0, "максимальный размер директории неотправленных метрик в байтах")
+	spoolMaxAge := flag.Int("spool-max-age", 0, "максимальное время хранения неотправленных метрик в секундах")
+	retryMaxAttempts := flag.Int("retry-attempts", 0, "максимальное количество попыток отправки метрик")
+	retryBaseDelay := flag.Int("retry-base-delay", 0, "начальная задержка между попытками в миллисекундах")
+	retryMaxDelay := flag.Int("retry-max-delay", 0, "максимальная задержка между попытками в миллисекундах")
+	healthInterval := flag.Int("health-interval", 0, "интервал проверки доступности серверов в секундах")
+	failoverThreshold := flag.Int("failover-threshold", 0, "количество неудачных отправок подряд, после которого используется следующий сервер")
+	fanOut := flag.Bool("fan-out", false, "дублировать отправку метрик на все доступные серверы")
+	selfMetricsAddress := flag.String("self-metrics-address", "", "адрес для отдачи метрик самого агента на /metrics, пустое значение отключает его")
 	shortConfigFilePath := flag.String("c", "", "путь до файла конфигурации short")
 	longConfigFilePath := flag.String("config", "", "путь до файла конфигурации long")
 
@@ -60,13 +141,35 @@ func LoadConfig() (cfg Config) {
 		panic(err)
 	}
 
-	cfg.ServerAddress = getEnvString(
+	cfg.ServerAddresses = parseList(getEnvString(
 		"ADDRESS",
 		*serverAddress,
 		fileConf.ServerAddress,
 		defaultServerAddress,
+	))
+
+	if len(cfg.ServerAddresses) == 0 {
+		panic("server address is required")
+	}
+
+	cfg.GRPCAddress = getEnvString(
+		"GRPC_ADDRESS",
+		*grpcAddress,
+		fileConf.GRPCAddress,
+		defaultGRPCAddress,
+	)
+
+	cfg.Transport = getEnvString(
+		"TRANSPORT",
+		*transport,
+		fileConf.Transport,
+		defaultTransport,
 	)
 
+	if _, ok := transports[cfg.Transport]; !ok {
+		panic(fmt.Errorf("unknown transport: %s", cfg.Transport))
+	}
+
 	var filePollInterval *int
 	if fileConf.PollInterval != nil {
 		filePollInterval = utils.Ptr(int(fileConf.PollInterval.Seconds()))
@@ -112,12 +215,199 @@ func LoadConfig() (cfg Config) {
 		"",
 	)
 
+	cfg.Labels = fileConf.Labels
+	if rawLabels := getEnvString("LABELS", *labels, nil, ""); rawLabels != "" {
+		cfg.Labels, err = parseLabels(rawLabels)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if err = cfg.Labels.Validate(); err != nil {
+		panic(err)
+	}
+
+	cfg.EnabledCollectors = getEnvList("COLLECTORS", *enabledCollectors, fileConf.EnabledCollectors)
+	cfg.DisabledCollectors = getEnvList("DISABLED_COLLECTORS", *disabledCollectors, fileConf.DisabledCollectors)
+
+	cfg.DiskFilter = collector.Filter{
+		Include: getEnvList("DISK_INCLUDE", *diskInclude, fileConf.DiskInclude),
+		Exclude: getEnvList("DISK_EXCLUDE", *diskExclude, fileConf.DiskExclude),
+	}
+
+	cfg.NetFilter = collector.Filter{
+		Include: getEnvList("NET_INCLUDE", *netInclude, fileConf.NetInclude),
+		Exclude: getEnvList("NET_EXCLUDE", *netExclude, fileConf.NetExclude),
+	}
+
+	cfg.LocalAddress = getEnvString(
+		"LOCAL_ADDRESS",
+		*localAddress,
+		fileConf.LocalAddress,
+		"",
+	)
+
+	cfg.SpoolDir = getEnvString(
+		"SPOOL_DIR",
+		*spoolDir,
+		fileConf.SpoolDir,
+		"",
+	)
+
+	cfg.SpoolMaxSize = getEnvInt(
+		"SPOOL_MAX_SIZE",
+		*spoolMaxSize,
+		fileConf.SpoolMaxSize,
+		defaultSpoolMaxSize,
+	)
+
+	var fileSpoolMaxAge *int
+	if fileConf.SpoolMaxAge != nil {
+		fileSpoolMaxAge = utils.Ptr(int(fileConf.SpoolMaxAge.Seconds()))
+	}
+
+	cfg.SpoolMaxAge = getEnvInt(
+		"SPOOL_MAX_AGE",
+		*spoolMaxAge,
+		fileSpoolMaxAge,
+		defaultSpoolMaxAge,
+	)
+
+	cfg.RetryMaxAttempts = getEnvInt(
+		"RETRY_MAX_ATTEMPTS",
+		*retryMaxAttempts,
+		fileConf.RetryMaxAttempts,
+		defaultRetryAttempts,
+	)
+
+	var fileRetryBaseDelay *int
+	if fileConf.RetryBaseDelay != nil {
+		fileRetryBaseDelay = utils.Ptr(int(fileConf.RetryBaseDelay.Milliseconds()))
+	}
+
+	cfg.RetryBaseDelay = getEnvInt(
+		"RETRY_BASE_DELAY",
+		*retryBaseDelay,
+		fileRetryBaseDelay,
+		defaultRetryBaseDelay,
+	)
+
+	var fileRetryMaxDelay *int
+	if fileConf.RetryMaxDelay != nil {
+		fileRetryMaxDelay = utils.Ptr(int(fileConf.RetryMaxDelay.Milliseconds()))
+	}
+
+	cfg.RetryMaxDelay = getEnvInt(
+		"RETRY_MAX_DELAY",
+		*retryMaxDelay,
+		fileRetryMaxDelay,
+		defaultRetryMaxDelay,
+	)
+
+	if err = cfg.RetryPolicy().Validate(); err != nil {
+		panic(err)
+	}
+
+	var fileHealthInterval *int
+	if fileConf.HealthCheckInterval != nil {
+		fileHealthInterval = utils.Ptr(int(fileConf.HealthCheckInterval.Seconds()))
+	}
+
+	cfg.HealthCheckInterval = getEnvInt(
+		"HEALTH_CHECK_INTERVAL",
+		*healthInterval,
+		fileHealthInterval,
+		defaultHealthInterval,
+	)
+
+	if cfg.HealthCheckInterval <= 0 {
+		panic("health check interval must be positive")
+	}
+
+	cfg.FailoverThreshold = getEnvInt(
+		"FAILOVER_THRESHOLD",
+		*failoverThreshold,
+		fileConf.FailoverThreshold,
+		defaultFailover,
+	)
+
+	if cfg.FailoverThreshold <= 0 {
+		panic("failover threshold must be positive")
+	}
+
+	cfg.FanOut = getEnvBool(
+		"FAN_OUT",
+		*fanOut,
+		fileConf.FanOut,
+		false,
+	)
+
+	cfg.SelfMetricsAddress = getEnvString(
+		"SELF_METRICS_ADDRESS",
+		*selfMetricsAddress,
+		fileConf.SelfMetricsAddress,
+		"",
+	)
+
 	cfg.LogLevel = defaultLogLevel
 	cfg.ServiceName = defaultServiceName
 
 	return cfg
 }
 
+// RetryPolicy returns policy of sending jobs and checking server.
+func (cfg Config) RetryPolicy() retry.Policy {
+	return retry.Policy{
+		MaxAttempts: cfg.RetryMaxAttempts,
+		BaseDelay:   time.Duration(cfg.RetryBaseDelay) * time.Millisecond,
+		MaxDelay:    time.Duration(cfg.RetryMaxDelay) * time.Millisecond,
+	}
+}
+
+// parseLabels parses labels written as comma separated
+// name=value pairs, e.g. host=a,service=b.
+func parseLabels(s string) (entity.Labels, error) {
+	labels := make(entity.Labels)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("%w: %q is not name=value pair", entity.ErrInvalidLabels, pair)
+		}
+		labels[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	return labels, labels.Validate()
+}
+
+// parseList parses comma separated list, empty items are skipped.
+func parseList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvList returns comma separated list from env or flag,
+// otherwise list from config file.
+func getEnvList(
+	envKey string,
+	flagValue string,
+	fileConfValue []string,
+) []string {
+	if rawList := getEnvString(envKey, flagValue, nil, ""); rawList != "" {
+		return parseList(rawList)
+	}
+	return fileConfValue
+}
+
 func getEnvString(
 	envKey string,
 	flagValue string,
@@ -161,3 +451,25 @@ func getEnvInt(
 
 	return defaultValue
 }
+
+func getEnvBool(
+	envKey string,
+	flagValue bool,
+	fileConfValue *bool,
+	defaultValue bool,
+) bool {
+	envValue, err := strconv.ParseBool(os.Getenv(envKey))
+	if err == nil {
+		return envValue
+	}
+
+	if flagValue {
+		return flagValue
+	}
+
+	if fileConfValue != nil {
+		return *fileConfValue
+	}
+
+	return defaultValue
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/Imomali1/metrics/internal/entity"
 	"github.com/Imomali1/metrics/internal/pkg/utils"
 )
 
@@ -173,3 +174,73 @@ func Test_getEnvString(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    entity.Labels
+		wantErr bool
+	}{
+		{
+			name:  "valid labels",
+			input: "host=a, service=b",
+			want:  entity.Labels{"host": "a", "service": "b"},
+		},
+		{
+			name:  "empty value and trailing comma",
+			input: "host=,",
+			want:  entity.Labels{"host": ""},
+		},
+		{
+			name:    "not a pair",
+			input:   "host",
+			wantErr: true,
+		},
+		{
+			name:    "invalid label name",
+			input:   "1host=a",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLabels(tt.input)
+			if tt.wantErr {
+				require.ErrorIs(t, err, entity.ErrInvalidLabels)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_parseList(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "single item",
+			input: "runtime",
+			want:  []string{"runtime"},
+		},
+		{
+			name:  "items with spaces and empty items",
+			input: " runtime, ,gopsutil,",
+			want:  []string{"runtime", "gopsutil"},
+		},
+		{
+			name:  "empty string",
+			input: "",
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, parseList(tt.input))
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+// counterTracker turns cumulative counters of collectors into increments.
+// Every increment is taken by a single report and stays in flight until
+// server acknowledges the job carrying it. Increment of a dropped job is
+// returned, so that it is taken again, while increment of a spooled job
+// stays in flight, thus retries never count it twice.
+type counterTracker struct {
+	mu sync.Mutex
+	// session identifies tracker of agent process in jobs, increments of
+	// jobs spooled by previous processes are not tracked.
+	session  int64
+	acked    map[string]int64
+	inFlight map[string]int64
+}
+
+func newCounterTracker() *counterTracker {
+	return &counterTracker{
+		session:  time.Now().UnixNano(),
+		acked:    make(map[string]int64),
+		inFlight: make(map[string]int64),
+	}
+}
+
+// Take replaces cumulative values of counters in arr with increments not
+// taken yet, counters without increment are removed. Totals of counters
+// with the same series key are summed. A counter less than already taken
+// value means that its source was restarted, so it is counted from zero.
+func (t *counterTracker) Take(arr []entity.Metrics) []entity.Metrics {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	totals := make(map[string]int64)
+	for _, metric := range arr {
+		if metric.MType == entity.Counter {
+			totals[metric.Key()] += *metric.Delta
+		}
+	}
+
+	taken := arr[:0]
+	for _, metric := range arr {
+		if metric.MType != entity.Counter {
+			taken = append(taken, metric)
+			continue
+		}
+
+		key := metric.Key()
+		total, ok := totals[key]
+		if !ok {
+			// series is already taken
+			continue
+		}
+		delete(totals, key)
+
+		if total < t.acked[key]+t.inFlight[key] {
+			t.acked[key] = 0
+			t.inFlight[key] = 0
+		}
+
+		delta := total - t.acked[key] - t.inFlight[key]
+		if delta == 0 {
+			continue
+		}
+
+		t.inFlight[key] += delta
+		metric.Delta = &delta
+		taken = append(taken, metric)
+	}
+
+	return taken
+}
+
+// Ack marks increments of job as received by server.
+func (t *counterTracker) Ack(job Job) {
+	t.settle(job, true)
+}
+
+// Nack returns increments of job that never reached server.
+func (t *counterTracker) Nack(job Job) {
+	t.settle(job, false)
+}
+
+func (t *counterTracker) settle(job Job, acked bool) {
+	if job.Session != t.session {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, delta := range job.Counters {
+		// increment may be already reset by restarted counter
+		delta = min(delta, t.inFlight[key])
+		t.inFlight[key] -= delta
+		if acked {
+			t.acked[key] += delta
+		}
+	}
+}
+
+// counterDeltas returns increments of counters in arr by series key.
+func counterDeltas(arr ...entity.Metrics) map[string]int64 {
+	var deltas map[string]int64
+	for _, metric := range arr {
+		if metric.MType != entity.Counter {
+			continue
+		}
+		if deltas == nil {
+			deltas = make(map[string]int64)
+		}
+		deltas[metric.Key()] += *metric.Delta
+	}
+	return deltas
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+func pollCount(total int64) []entity.Metrics {
+	return []entity.Metrics{
+		{ID: "PollCount", MType: entity.Counter, Delta: utils.Ptr(total)},
+		{ID: "RandomValue", MType: entity.Gauge, Value: utils.Ptr(1.0)},
+	}
+}
+
+// take returns job carrying increments taken from arr.
+func take(t *testing.T, tracker *counterTracker, arr []entity.Metrics) (Job, int64) {
+	t.Helper()
+
+	taken := tracker.Take(arr)
+	job := Job{Counters: counterDeltas(taken...), Session: tracker.session}
+	return job, job.Counters["PollCount"]
+}
+
+func Test_counterTracker(t *testing.T) {
+	tracker := newCounterTracker()
+
+	job1, delta := take(t, tracker, pollCount(5))
+	require.Equal(t, int64(5), delta)
+
+	// increment of job in flight is not taken again
+	job2, delta := take(t, tracker, pollCount(8))
+	require.Equal(t, int64(3), delta)
+
+	// dropped job returns its increment
+	tracker.Nack(job1)
+	job3, delta := take(t, tracker, pollCount(8))
+	require.Equal(t, int64(5), delta)
+
+	tracker.Ack(job2)
+	tracker.Ack(job3)
+	assert.Equal(t, int64(8), tracker.acked["PollCount"])
+	assert.Zero(t, tracker.inFlight["PollCount"])
+
+	// counter without increment is not reported, gauges are kept
+	taken := tracker.Take(pollCount(8))
+	require.Len(t, taken, 1)
+	assert.Equal(t, entity.Gauge, taken[0].MType)
+
+	// acknowledging the same job twice does not count it twice
+	tracker.Ack(job3)
+	assert.Equal(t, int64(8), tracker.acked["PollCount"])
+
+	// restarted counter is counted from zero
+	_, delta = take(t, tracker, pollCount(2))
+	require.Equal(t, int64(2), delta)
+}
+
+func Test_counterTracker_ForeignSession(t *testing.T) {
+	tracker := newCounterTracker()
+
+	_, delta := take(t, tracker, pollCount(5))
+	require.Equal(t, int64(5), delta)
+
+	// job spooled by previous agent process
+	tracker.Nack(Job{Counters: map[string]int64{"PollCount": 5}, Session: tracker.session - 1})
+
+	_, delta = take(t, tracker, pollCount(5))
+	assert.Zero(t, delta)
+}
+
+func Test_counterTracker_SameKey(t *testing.T) {
+	tracker := newCounterTracker()
+
+	// local counter with the key of collected one is summed with it
+	local := entity.Metrics{ID: "PollCount", MType: entity.Counter, Delta: utils.Ptr[int64](3)}
+	job1, delta := take(t, tracker, append(pollCount(5), local))
+	require.Equal(t, int64(8), delta)
+	tracker.Ack(job1)
+
+	job2, delta := take(t, tracker, append(pollCount(6), local))
+	require.Equal(t, int64(1), delta, "acknowledged increments are not taken again")
+
+	// increments of dropped job are taken again
+	tracker.Nack(job2)
+	local.Delta = utils.Ptr[int64](4)
+	_, delta = take(t, tracker, append(pollCount(6), local))
+	assert.Equal(t, int64(2), delta)
+}
@@ -0,0 +1,186 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+	"github.com/Imomali1/metrics/internal/pkg/retry"
+)
+
+// endpoint is HTTP server metrics are reported to.
+type endpoint struct {
+	address string
+	// realIP is sent in X-Real-IP header to the endpoint, see outboundIP.
+	realIP string
+	// healthy is result of the last health check.
+	healthy atomic.Bool
+	// failures counts consecutive failed reports, endpoint is skipped
+	// once it reaches threshold until it passes health check again.
+	failures atomic.Int64
+}
+
+// endpoints is ordered list of servers. Reports go to the first available
+// endpoint, so agent fails over to the next one while preferred server
+// is down and fails back once it passes health check.
+type endpoints struct {
+	list      []*endpoint
+	threshold int64
+	// interval of health checks is also their timeout.
+	interval time.Duration
+	client   *resty.Client
+	log      logger.Logger
+	// active is the last selected endpoint, switches are logged.
+	active atomic.Pointer[endpoint]
+}
+
+func newEndpoints(
+	addresses []string,
+	threshold int,
+	interval time.Duration,
+	client *resty.Client,
+	log logger.Logger,
+) *endpoints {
+	e := &endpoints{
+		threshold: int64(max(threshold, 1)),
+		interval:  interval,
+		client:    client,
+		log:       log,
+	}
+
+	// endpoints are available until proven otherwise
+	for _, address := range addresses {
+		ep := &endpoint{address: address}
+		ep.healthy.Store(true)
+
+		var err error
+		ep.realIP, err = outboundIP(address)
+		if err != nil {
+			log.Info().Err(err).Msgf("cannot detect outbound ip, X-Real-IP will not be sent to %s", address)
+		}
+
+		e.list = append(e.list, ep)
+	}
+
+	return e
+}
+
+func (e *endpoints) available(ep *endpoint) bool {
+	return ep.healthy.Load() && ep.failures.Load() < e.threshold
+}
+
+// current returns the first available endpoint, the first
+// endpoint is returned when none is available.
+func (e *endpoints) current() *endpoint {
+	ep := e.list[0]
+	for _, candidate := range e.list {
+		if e.available(candidate) {
+			ep = candidate
+			break
+		}
+	}
+
+	if prev := e.active.Swap(ep); prev != nil && prev != ep {
+		e.log.Info().Msgf("switched reporting from server %s to %s", prev.address, ep.address)
+	}
+
+	return ep
+}
+
+// mirrors returns available endpoints except ep, see Config.FanOut.
+func (e *endpoints) mirrors(ep *endpoint) []*endpoint {
+	var list []*endpoint
+	for _, candidate := range e.list {
+		if candidate != ep && e.available(candidate) {
+			list = append(list, candidate)
+		}
+	}
+	return list
+}
+
+// report records result of sending job to ep. Only transient errors are
+// failures, rejected job says nothing about health of server.
+func (e *endpoints) report(ep *endpoint, err error) {
+	switch {
+	case err == nil:
+		ep.failures.Store(0)
+	case retry.IsRetryable(err):
+		if ep.failures.Add(1) == e.threshold {
+			e.log.Info().Err(err).Msgf("server %s is considered down after %d failed reports", ep.address, e.threshold)
+		}
+	}
+}
+
+// check makes health check of every endpoint, error is returned
+// when none of them is available.
+func (e *endpoints) check(ctx context.Context) error {
+	errs := make([]error, len(e.list))
+
+	var wg sync.WaitGroup
+	for i, ep := range e.list {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = e.checkOne(ctx, ep)
+		}()
+	}
+	wg.Wait()
+
+	for _, ep := range e.list {
+		if e.available(ep) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no server is available: %w", errors.Join(errs...))
+}
+
+// checkOne requests /healthz of ep, passed check resets failures.
+func (e *endpoints) checkOne(ctx context.Context, ep *endpoint) error {
+	ctx, cancel := context.WithTimeout(ctx, e.interval)
+	defer cancel()
+
+	resp, err := e.client.R().SetContext(ctx).Get(fmt.Sprintf("http://%s/healthz", ep.address))
+	if err == nil && resp.StatusCode() != http.StatusOK {
+		err = &retry.StatusError{Code: resp.StatusCode()}
+	}
+
+	if err != nil {
+		if ep.healthy.Swap(false) {
+			e.log.Info().Err(err).Msgf("server %s failed health check", ep.address)
+		}
+		return fmt.Errorf("%s: %w", ep.address, err)
+	}
+
+	wasHealthy, failures := ep.healthy.Swap(true), ep.failures.Swap(0)
+	if !wasHealthy || failures >= e.threshold {
+		e.log.Info().Msgf("server %s is healthy again", ep.address)
+	}
+	return nil
+}
+
+// CheckEndpointsPeriodically keeps health of servers up to date,
+// so that agent fails back to preferred server once it recovers.
+func (a *agent) CheckEndpointsPeriodically(wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(a.endpoints.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = a.endpoints.check(context.Background())
+		case <-a.shutdownCh:
+			a.log.Info().Msg("stopped checking servers")
+			return
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/pkg/retry"
+)
+
+// newTestEndpoints adds servers to agent in order of preference.
+func newTestEndpoints(t *testing.T, a *agent, threshold int, servers ...*testServer) {
+	addresses := make([]string, 0, len(servers))
+	for _, ts := range servers {
+		ts.healthy = true
+		server := httptest.NewServer(ts)
+		t.Cleanup(server.Close)
+		addresses = append(addresses, strings.TrimPrefix(server.URL, "http://"))
+	}
+
+	a.endpoints = newEndpoints(addresses, threshold, time.Second, a.reporter.httpClient, a.log)
+}
+
+func TestAgent_process_Failover(t *testing.T) {
+	a, _ := newTestAgent(t, false)
+	a.retry = retry.Policy{MaxAttempts: 3}
+
+	primary, backup := &testServer{}, &testServer{}
+	newTestEndpoints(t, a, 2, primary, backup)
+
+	job := a.httpTarget().job("/updates/", map[string]string{"X-Test": "job"}, []byte("batch"))
+
+	// primary server fails twice in a row, so the last attempt goes to backup
+	primary.status = http.StatusServiceUnavailable
+	a.process(context.Background(), job)
+	assert.Equal(t, 2, primary.requests)
+	assert.Equal(t, []string{"job:batch"}, backup.bodies)
+	assert.Zero(t, a.droppedBatches())
+
+	// failed primary is skipped until it passes health check
+	primary.status = 0
+	a.process(context.Background(), job)
+	assert.Equal(t, 2, primary.requests)
+	assert.Len(t, backup.bodies, 2)
+
+	require.NoError(t, a.endpoints.check(context.Background()))
+	a.process(context.Background(), job)
+	assert.Equal(t, []string{"job:batch"}, primary.bodies, "agent fails back to primary")
+	assert.Len(t, backup.bodies, 2)
+}
+
+func TestAgent_process_RejectedIsNotFailure(t *testing.T) {
+	a, _ := newTestAgent(t, false)
+
+	primary, backup := &testServer{}, &testServer{}
+	newTestEndpoints(t, a, 1, primary, backup)
+
+	primary.status = http.StatusBadRequest
+	a.process(context.Background(), a.httpTarget().job("/updates/", map[string]string{}, []byte("batch")))
+	assert.Equal(t, int64(1), a.droppedBatches())
+	assert.Same(t, a.endpoints.list[0], a.endpoints.current())
+}
+
+func TestEndpoints_check(t *testing.T) {
+	a, _ := newTestAgent(t, false)
+
+	primary, backup := &testServer{}, &testServer{}
+	newTestEndpoints(t, a, 1, primary, backup)
+
+	primary.healthy = false
+	require.NoError(t, a.endpoints.check(context.Background()))
+	assert.Same(t, a.endpoints.list[1], a.endpoints.current())
+
+	backup.healthy = false
+	assert.Error(t, a.endpoints.check(context.Background()))
+	assert.Same(t, a.endpoints.list[0], a.endpoints.current(), "first server is used when none is available")
+
+	primary.healthy = true
+	require.NoError(t, a.endpoints.check(context.Background()))
+	assert.Same(t, a.endpoints.list[0], a.endpoints.current())
+}
+
+func TestAgent_process_FanOut(t *testing.T) {
+	a, _ := newTestAgent(t, false)
+	a.cfg.FanOut = true
+
+	primary, mirror, down := &testServer{}, &testServer{}, &testServer{}
+	newTestEndpoints(t, a, 1, primary, mirror, down)
+	down.healthy = false
+	require.NoError(t, a.endpoints.check(context.Background()))
+
+	// failed mirror does not affect the job
+	mirror.status = http.StatusServiceUnavailable
+	job := a.httpTarget().job("/updates/", map[string]string{"X-Test": "job"}, []byte("batch1"))
+	job.Counters = counterDeltas(a.counters.Take(pollCount(5))...)
+	job.Session = a.counters.session
+	a.process(context.Background(), job)
+	assert.Equal(t, []string{"job:batch1"}, primary.bodies)
+	assert.Equal(t, int64(5), a.counters.acked["PollCount"])
+
+	mirror.status = 0
+	require.NoError(t, a.endpoints.check(context.Background()))
+	a.process(context.Background(), a.httpTarget().job("/updates/", map[string]string{"X-Test": "job"}, []byte("batch2")))
+	assert.Equal(t, []string{"job:batch1", "job:batch2"}, primary.bodies)
+	assert.Equal(t, []string{"job:batch2"}, mirror.bodies)
+	assert.Zero(t, down.requests)
+}
+
+func TestAgent_sendHTTP_RealIP(t *testing.T) {
+	a, _ := newTestAgent(t, false)
+
+	primary, backup := &testServer{}, &testServer{}
+	newTestEndpoints(t, a, 1, primary, backup)
+
+	// test servers listen on loopback
+	for _, ep := range a.endpoints.list {
+		assert.Equal(t, "127.0.0.1", ep.realIP)
+	}
+	a.endpoints.list[1].realIP = "10.0.0.2"
+
+	job := a.httpTarget().job("/updates/", map[string]string{"X-Test": "job"}, []byte("batch"))
+	require.NoError(t, a.sendHTTP(a.endpoints.list[0], job))
+	require.NoError(t, a.sendHTTP(a.endpoints.list[1], job))
+
+	// header is set per endpoint, not carried by job
+	assert.Equal(t, []string{"127.0.0.1"}, primary.realIPs)
+	assert.Equal(t, []string{"10.0.0.2"}, backup.realIPs)
+	assert.NotContains(t, job.Headers, "X-Real-IP")
+}
+
+func TestAgent_send_LegacyURL(t *testing.T) {
+	a, ts := newTestAgent(t, false)
+
+	// spooled by agent reporting to single server
+	data := []byte(`{"transport":"http","url":"http://old-server:8080/updates/?x=1",` +
+		`"headers":{"X-Test":"legacy"},"body":"YmF0Y2g="}`)
+
+	job, err := decodeJob(data)
+	require.NoError(t, err)
+	assert.Equal(t, "/updates/?x=1", job.Path)
+
+	require.NoError(t, a.send(job))
+	assert.Equal(t, []string{"legacy:batch"}, ts.bodies)
+}
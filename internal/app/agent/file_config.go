@@ -5,13 +5,40 @@ import (
 	"fmt"
 	"os"
 	"time"
+
+	"github.com/Imomali1/metrics/internal/entity"
 )
 
 type FileConfig struct {
+	// ServerAddress is comma separated list, see Config.ServerAddresses.
 	ServerAddress  *string        `json:"address"`
+	GRPCAddress    *string        `json:"grpc_address"`
+	Transport      *string        `json:"transport"`
 	PollInterval   *time.Duration `json:"poll_interval"`
 	ReportInterval *time.Duration `json:"report_interval"`
 	PublicKeyPath  *string        `json:"crypto_key"`
+	Labels         entity.Labels  `json:"labels"`
+	// EnabledCollectors lists names of polled collectors, empty list enables all.
+	EnabledCollectors  []string       `json:"collectors"`
+	DisabledCollectors []string       `json:"disabled_collectors"`
+	DiskInclude        []string       `json:"disk_include"`
+	DiskExclude        []string       `json:"disk_exclude"`
+	NetInclude         []string       `json:"net_include"`
+	NetExclude         []string       `json:"net_exclude"`
+	LocalAddress       *string        `json:"local_address"`
+	SpoolDir           *string        `json:"spool_dir"`
+	SpoolMaxSize       *int           `json:"spool_max_size"`
+	SpoolMaxAge        *time.Duration `json:"spool_max_age"`
+
+	RetryMaxAttempts *int           `json:"retry_max_attempts"`
+	RetryBaseDelay   *time.Duration `json:"retry_base_delay"`
+	RetryMaxDelay    *time.Duration `json:"retry_max_delay"`
+
+	HealthCheckInterval *time.Duration `json:"health_check_interval"`
+	FailoverThreshold   *int           `json:"failover_threshold"`
+	FanOut              *bool          `json:"fan_out"`
+
+	SelfMetricsAddress *string `json:"self_metrics_address"`
 }
 
 func LoadFileConfig(configPath string) (FileConfig, error) {
@@ -0,0 +1,257 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mailru/easyjson"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+const unixSocketPrefix = "unix://"
+
+// localMetrics accumulates metrics pushed by local applications between reports.
+type localMetrics struct {
+	mu      sync.Mutex
+	metrics map[string]entity.Metrics
+}
+
+func newLocalMetrics() *localMetrics {
+	return &localMetrics{metrics: make(map[string]entity.Metrics)}
+}
+
+// Push merges batch, counter deltas are summed, histograms and summaries
+// are merged and the last gauge value wins. Invalid batch is rejected
+// as a whole.
+func (m *localMetrics) Push(batch entity.MetricsList) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	histograms := make(map[string]*entity.HistogramValue)
+	for _, metric := range batch {
+		if err := validateLocalMetric(metric); err != nil {
+			return err
+		}
+
+		if metric.MType != entity.Histogram {
+			continue
+		}
+
+		key := metric.MType + ":" + metric.Key()
+		current, ok := histograms[key]
+		if !ok {
+			var old entity.Metrics
+			old, ok = m.metrics[key]
+			current = old.Histogram
+		}
+
+		if !ok {
+			histograms[key] = metric.Histogram.Clone()
+			continue
+		}
+
+		merged, err := current.Merge(metric.Histogram)
+		if err != nil {
+			return fmt.Errorf("%s: %w", metric.Key(), err)
+		}
+		histograms[key] = merged
+	}
+
+	for _, metric := range batch {
+		key := metric.MType + ":" + metric.Key()
+		metric.Labels = metric.Labels.Clone()
+
+		switch metric.MType {
+		case entity.Counter:
+			delta := *metric.Delta
+			if old, ok := m.metrics[key]; ok {
+				delta += *old.Delta
+			}
+			metric.Delta = &delta
+		case entity.Gauge:
+			value := *metric.Value
+			metric.Value = &value
+		case entity.Histogram:
+			metric.Histogram = histograms[key]
+		case entity.Summary:
+			summary := metric.Summary.Clone()
+			if old, ok := m.metrics[key]; ok {
+				summary = old.Summary.Merge(metric.Summary)
+			}
+			metric.Summary = summary
+		}
+
+		m.metrics[key] = metric
+	}
+
+	return nil
+}
+
+// Flush returns metrics pushed so far sorted by series key, histograms
+// and summaries are reset, so that every observation is reported once. Counters keep
+// totals, their increments are taken by counterTracker like increments
+// of collected counters, gauges keep the last value.
+func (m *localMetrics) Flush() []entity.Metrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]string, 0, len(m.metrics))
+	for key := range m.metrics {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	flushed := make([]entity.Metrics, 0, len(keys))
+	for _, key := range keys {
+		metric := m.metrics[key]
+		flushed = append(flushed, metric)
+		if metric.MType == entity.Histogram || metric.MType == entity.Summary {
+			delete(m.metrics, key)
+		}
+	}
+
+	return flushed
+}
+
+func validateLocalMetric(metric entity.Metrics) error {
+	if metric.ID == "" {
+		return errors.New("empty metric name")
+	}
+
+	if strings.HasPrefix(metric.ID, selfMetricPrefix) {
+		return fmt.Errorf("metric name %s uses reserved prefix %s", metric.ID, selfMetricPrefix)
+	}
+
+	switch metric.MType {
+	case entity.Counter:
+		if metric.Delta == nil {
+			return fmt.Errorf("counter %s without delta", metric.Key())
+		}
+		// totals of local counters never decrease, see counterTracker
+		if *metric.Delta < 0 {
+			return fmt.Errorf("counter %s with negative delta", metric.Key())
+		}
+	case entity.Gauge:
+		if metric.Value == nil {
+			return fmt.Errorf("gauge %s without value", metric.Key())
+		}
+	case entity.Histogram:
+		if err := metric.Histogram.Validate(); err != nil {
+			return fmt.Errorf("histogram %s: %w", metric.Key(), err)
+		}
+	case entity.Summary:
+		if err := metric.Summary.Validate(); err != nil {
+			return fmt.Errorf("summary %s: %w", metric.Key(), err)
+		}
+	default:
+		return entity.ErrInvalidMetricType
+	}
+
+	return metric.ValidateSeries()
+}
+
+// localHandler accepts the same JSON as server /update/ and /updates/ handlers.
+func (a *agent) localHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/update/", func(w http.ResponseWriter, r *http.Request) {
+		a.pushLocal(w, r, func(body []byte) (entity.MetricsList, error) {
+			var metric entity.Metrics
+			err := easyjson.Unmarshal(body, &metric)
+			return entity.MetricsList{metric}, err
+		})
+	})
+	mux.HandleFunc("/updates/", func(w http.ResponseWriter, r *http.Request) {
+		a.pushLocal(w, r, func(body []byte) (entity.MetricsList, error) {
+			var batch entity.MetricsList
+			err := easyjson.Unmarshal(body, &batch)
+			return batch, err
+		})
+	})
+	return mux
+}
+
+func (a *agent) pushLocal(
+	w http.ResponseWriter,
+	r *http.Request,
+	unmarshal func(body []byte) (entity.MetricsList, error),
+) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := utils.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		a.log.Info().Err(err).Msg("cannot read local request body")
+		return
+	}
+
+	batch, err := unmarshal(body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		a.log.Info().Err(err).Msg("cannot unmarshal local metrics")
+		return
+	}
+
+	if err = a.local.Push(batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		a.log.Info().Err(err).Msg("invalid local metrics")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// listenLocal listens TCP address or Unix socket path prefixed with unix://,
+// stale socket file of previous run is removed.
+func listenLocal(address string) (net.Listener, error) {
+	path, isUnix := strings.CutPrefix(address, unixSocketPrefix)
+	if !isUnix {
+		return net.Listen("tcp", address)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
+// ServeLocalMetrics accepts metrics pushed by local applications until shutdown.
+func (a *agent) ServeLocalMetrics(wg *sync.WaitGroup, listener net.Listener) {
+	a.serveHTTP(wg, listener, a.localHandler(), "local metrics")
+}
+
+// ServeSelfMetrics exposes metrics of agent itself until shutdown.
+func (a *agent) ServeSelfMetrics(wg *sync.WaitGroup, listener net.Listener) {
+	a.serveHTTP(wg, listener, a.selfMetricsHandler(), "agent metrics")
+}
+
+// serveHTTP serves handler on listener until shutdown, what names it in logs.
+func (a *agent) serveHTTP(wg *sync.WaitGroup, listener net.Listener, handler http.Handler, what string) {
+	defer wg.Done()
+
+	server := &http.Server{Handler: handler}
+
+	go func() {
+		<-a.shutdownCh
+		ctx, cancel := context.WithTimeout(context.Background(), a.reporter.interval)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		a.log.Info().Err(err).Msgf("failed to serve %s", what)
+	}
+
+	a.log.Info().Msgf("stopped serving %s", what)
+}
@@ -0,0 +1,254 @@
+package agent
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+func TestLocalMetrics_PushFlush(t *testing.T) {
+	local := newLocalMetrics()
+
+	err := local.Push(entity.MetricsList{
+		{ID: "requests", MType: entity.Counter, Delta: utils.Ptr[int64](2)},
+		{ID: "temperature", MType: entity.Gauge, Value: utils.Ptr(20.5)},
+	})
+	require.NoError(t, err)
+
+	err = local.Push(entity.MetricsList{
+		{ID: "requests", MType: entity.Counter, Delta: utils.Ptr[int64](3)},
+		{ID: "requests", MType: entity.Counter, Delta: utils.Ptr[int64](1), Labels: entity.Labels{"path": "a"}},
+		{ID: "temperature", MType: entity.Gauge, Value: utils.Ptr(21.5)},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []entity.Metrics{
+		{ID: "requests", MType: entity.Counter, Delta: utils.Ptr[int64](5)},
+		{ID: "requests", MType: entity.Counter, Delta: utils.Ptr[int64](1), Labels: entity.Labels{"path": "a"}},
+		{ID: "temperature", MType: entity.Gauge, Value: utils.Ptr(21.5)},
+	}, local.Flush())
+
+	// counters keep totals, gauges keep the last value
+	require.NoError(t, local.Push(entity.MetricsList{
+		{ID: "requests", MType: entity.Counter, Delta: utils.Ptr[int64](2)},
+	}))
+	require.Equal(t, []entity.Metrics{
+		{ID: "requests", MType: entity.Counter, Delta: utils.Ptr[int64](7)},
+		{ID: "requests", MType: entity.Counter, Delta: utils.Ptr[int64](1), Labels: entity.Labels{"path": "a"}},
+		{ID: "temperature", MType: entity.Gauge, Value: utils.Ptr(21.5)},
+	}, local.Flush())
+}
+
+func TestLocalMetrics_PushHistogram(t *testing.T) {
+	local := newLocalMetrics()
+
+	histogram := func(sum float64, count uint64, counts ...uint64) *entity.HistogramValue {
+		h := entity.NewHistogram([]float64{0.1, 1})
+		for i := range h.Buckets {
+			h.Buckets[i].Count = counts[i]
+		}
+		h.Sum, h.Count = sum, count
+		return h
+	}
+
+	err := local.Push(entity.MetricsList{
+		{ID: "latency", MType: entity.Histogram, Histogram: histogram(0.5, 2, 1, 2)},
+		{ID: "latency", MType: entity.Histogram, Histogram: histogram(2, 1, 0, 0)},
+	})
+	require.NoError(t, err)
+
+	err = local.Push(entity.MetricsList{
+		{ID: "latency", MType: entity.Histogram, Histogram: histogram(0.05, 1, 1, 1)},
+	})
+	require.NoError(t, err)
+
+	// batch with mismatching buckets is rejected as a whole
+	err = local.Push(entity.MetricsList{
+		{ID: "requests", MType: entity.Counter, Delta: utils.Ptr[int64](1)},
+		{ID: "latency", MType: entity.Histogram, Histogram: entity.NewHistogram([]float64{5})},
+	})
+	require.ErrorIs(t, err, entity.ErrHistogramBucketsMismatch)
+
+	require.Equal(t, []entity.Metrics{
+		{ID: "latency", MType: entity.Histogram, Histogram: histogram(2.55, 4, 2, 3)},
+	}, local.Flush())
+
+	// histograms are reported once like counters
+	require.Empty(t, local.Flush())
+}
+
+func TestLocalMetrics_PushSummary(t *testing.T) {
+	local := newLocalMetrics()
+
+	err := local.Push(entity.MetricsList{
+		{ID: "rpc", MType: entity.Summary, Summary: &entity.SummaryValue{
+			Quantiles: []entity.Quantile{{Quantile: 0.5, Value: 0.1}},
+			Sum:       0.5,
+			Count:     3,
+		}},
+	})
+	require.NoError(t, err)
+
+	err = local.Push(entity.MetricsList{
+		{ID: "rpc", MType: entity.Summary, Summary: &entity.SummaryValue{
+			Quantiles: []entity.Quantile{{Quantile: 0.5, Value: 0.3}},
+			Sum:       1.5,
+			Count:     2,
+		}},
+	})
+	require.NoError(t, err)
+
+	// the latest quantiles win, sum and count accumulate
+	require.Equal(t, []entity.Metrics{
+		{ID: "rpc", MType: entity.Summary, Summary: &entity.SummaryValue{
+			Quantiles: []entity.Quantile{{Quantile: 0.5, Value: 0.3}},
+			Sum:       2,
+			Count:     5,
+		}},
+	}, local.Flush())
+
+	require.Empty(t, local.Flush())
+}
+
+func TestLocalMetrics_PushInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		batch entity.MetricsList
+	}{
+		{
+			name:  "empty name",
+			batch: entity.MetricsList{{MType: entity.Gauge, Value: utils.Ptr(1.0)}},
+		},
+		{
+			name:  "invalid type",
+			batch: entity.MetricsList{{ID: "metric", MType: "invalid", Value: utils.Ptr(1.0)}},
+		},
+		{
+			name:  "counter without delta",
+			batch: entity.MetricsList{{ID: "metric", MType: entity.Counter}},
+		},
+		{
+			name:  "counter with negative delta",
+			batch: entity.MetricsList{{ID: "metric", MType: entity.Counter, Delta: utils.Ptr[int64](-1)}},
+		},
+		{
+			name:  "gauge without value",
+			batch: entity.MetricsList{{ID: "metric", MType: entity.Gauge}},
+		},
+		{
+			name:  "histogram without value",
+			batch: entity.MetricsList{{ID: "metric", MType: entity.Histogram}},
+		},
+		{
+			name:  "summary without value",
+			batch: entity.MetricsList{{ID: "metric", MType: entity.Summary}},
+		},
+		{
+			name:  "reserved prefix",
+			batch: entity.MetricsList{{ID: selfMetricPrefix + "reports_sent", MType: entity.Counter, Delta: utils.Ptr[int64](1)}},
+		},
+		{
+			name: "invalid labels",
+			batch: entity.MetricsList{
+				{ID: "metric", MType: entity.Gauge, Value: utils.Ptr(1.0), Labels: entity.Labels{"1a": "b"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := newLocalMetrics()
+			require.Error(t, local.Push(tt.batch))
+			require.Empty(t, local.Flush())
+		})
+	}
+}
+
+func TestAgent_ServeLocalMetrics(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "agent.sock")
+	// stale socket of previous run
+	require.NoError(t, os.WriteFile(socketPath, nil, 0o644))
+
+	listener, err := listenLocal(unixSocketPrefix + socketPath)
+	require.NoError(t, err)
+
+	a := &agent{
+		log:        logger.NewLogger(os.Stdout, "info", "test"),
+		reporter:   reporter{interval: time.Second},
+		local:      newLocalMetrics(),
+		shutdownCh: make(chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	done := make(chan struct{})
+	go func() {
+		a.ServeLocalMetrics(&wg, listener)
+		close(done)
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+
+	tests := []struct {
+		name       string
+		url        string
+		body       string
+		wantedCode int
+	}{
+		{
+			name:       "single metric",
+			url:        "http://agent/update/",
+			body:       `{"id":"requests","type":"counter","delta":2}`,
+			wantedCode: http.StatusOK,
+		},
+		{
+			name:       "batch",
+			url:        "http://agent/updates/",
+			body:       `[{"id":"requests","type":"counter","delta":3},{"id":"temperature","type":"gauge","value":1.5}]`,
+			wantedCode: http.StatusOK,
+		},
+		{
+			name:       "invalid json",
+			url:        "http://agent/updates/",
+			body:       `{`,
+			wantedCode: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid metric",
+			url:        "http://agent/update/",
+			body:       `{"id":"requests","type":"counter"}`,
+			wantedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Post(tt.url, "application/json", strings.NewReader(tt.body))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, tt.wantedCode, resp.StatusCode)
+		})
+	}
+
+	require.Equal(t, []entity.Metrics{
+		{ID: "requests", MType: entity.Counter, Delta: utils.Ptr[int64](5)},
+		{ID: "temperature", MType: entity.Gauge, Value: utils.Ptr(1.5)},
+	}, a.local.Flush())
+
+	close(a.shutdownCh)
+	<-done
+}
@@ -1,29 +1,27 @@
 package agent
 
 import (
-	"bytes"
-	"compress/gzip"
 	"crypto/rsa"
-	"fmt"
-	"github.com/rs/zerolog/log"
 	"sync"
 	"time"
 
 	"github.com/go-resty/resty/v2"
-	"github.com/mailru/easyjson"
+	"github.com/rs/zerolog/log"
 
 	"github.com/Imomali1/metrics/internal/entity"
-	"github.com/Imomali1/metrics/internal/pkg/cipher"
-	"github.com/Imomali1/metrics/internal/pkg/utils"
+	"github.com/Imomali1/metrics/internal/pkg/pb"
 )
 
 type reporter struct {
-	interval  time.Duration
-	publicKey *rsa.PublicKey
+	interval   time.Duration
+	publicKey  *rsa.PublicKey
+	httpClient *resty.Client
+	grpcClient pb.MetricsClient
+	// transport encodes reported metrics, see Config.Transport.
+	transport Transport
 }
 
 func (a *agent) ReportMetricsPeriodically(wg *sync.WaitGroup) {
-	wg.Add(1)
 	defer wg.Done()
 
 	ticker := time.NewTicker(a.reporter.interval)
@@ -31,9 +29,7 @@ func (a *agent) ReportMetricsPeriodically(wg *sync.WaitGroup) {
 	for {
 		select {
 		case <-ticker.C:
-			go a.reportMetricsV1(wg)
-			go a.reportMetricsV2(wg)
-			go a.reportMetricsV3(wg)
+			a.reportMetrics(wg)
 		case <-a.shutdownCh:
 			log.Info().Msg("stopped reporting metrics to server periodically")
 			return
@@ -41,165 +37,85 @@ func (a *agent) ReportMetricsPeriodically(wg *sync.WaitGroup) {
 	}
 }
 
-func (a *agent) reportMetricsV1(wg *sync.WaitGroup) {
+// reportMetrics reports snapshot of collected metrics using configured
+// transport. It is called by goroutines counted in wg, so the report is
+// added to wg before shutdown waits for it and closes jobs channel.
+func (a *agent) reportMetrics(wg *sync.WaitGroup) {
 	wg.Add(1)
-	defer wg.Done()
-
-	a.log.Info().Msg("started reporting metrics to server/v1...")
-	if len(a.metrics.Arr) == 0 {
-		a.log.Info().Msg("no metrics to report")
-		return
-	}
-
-	client := resty.New().SetHeader("Content-Type", "text/plain")
-
-	a.metrics.mu.RLock()
-	arr := a.metrics.Arr
-	a.metrics.mu.RUnlock()
-
-	for _, metric := range arr {
-		url := fmt.Sprintf("http://%s/update/%s/%s/", a.cfg.ServerAddress, metric.MType, metric.ID)
-		switch metric.MType {
-		case entity.Counter:
-			url = fmt.Sprintf("%s%d", url, *metric.Delta)
-		case entity.Gauge:
-			url = fmt.Sprintf("%s%f", url, *metric.Value)
-		default:
-			a.log.Info().Msgf("invalid metric type: %s", metric.MType)
-			continue
-		}
+	go a.report(wg, a.snapshot())
+}
 
-		a.jobsChan <- Job{
-			Request: client.R(),
-			URL:     url,
-		}
+// snapshot returns copy of collected metrics, metrics of agent itself and
+// metrics pushed by local applications, default labels from config are
+// attached, labels set by collectors take precedence. Counters are replaced
+// with increments, see counterTracker, labels are attached first, so that
+// increments are tracked by keys of reported series.
+func (a *agent) snapshot() []entity.Metrics {
+	arr := append(a.poller.registry.Snapshot(), a.selfSnapshot()...)
+	if a.local != nil {
+		arr = append(arr, a.local.Flush()...)
 	}
 
-	a.log.Info().Msg("finished reporting metrics to server/v1...")
+	return a.counters.Take(a.withDefaultLabels(arr))
 }
 
-func (a *agent) reportMetricsV2(wg *sync.WaitGroup) {
-	wg.Add(1)
-	defer wg.Done()
-
-	a.log.Info().Msg("started reporting metrics to server/v2...")
-	if len(a.metrics.Arr) == 0 {
-		a.log.Info().Msg("no metrics to report")
-		return
+// withDefaultLabels attaches labels from config to arr in place.
+func (a *agent) withDefaultLabels(arr []entity.Metrics) []entity.Metrics {
+	if len(a.cfg.Labels) == 0 {
+		return arr
 	}
 
-	client := resty.New().
-		SetHeader("Content-Encoding", "gzip").
-		SetHeader("Content-Type", "application/json")
-
-	url := fmt.Sprintf("http://%s/update/", a.cfg.ServerAddress)
-
-	a.metrics.mu.RLock()
-	arr := a.metrics.Arr
-	a.metrics.mu.RUnlock()
-
-	for _, metric := range arr {
-		body, err := easyjson.Marshal(metric)
-		if err != nil {
-			a.log.Info().Err(err).Msg("cannot unmarshal metric object")
-			continue
-		}
-
-		if a.reporter.publicKey != nil {
-			body, err = cipher.EncryptRSA(a.reporter.publicKey, body)
-			if err != nil {
-				a.log.Info().Err(err).Msg("cannot encrypt message")
-				continue
-			}
-		}
-
-		var buf bytes.Buffer
-		gzipWriter := gzip.NewWriter(&buf)
-
-		_, err = gzipWriter.Write(body)
-		if err != nil {
-			a.log.Info().Err(err).Msg("cannot compress body")
-			continue
-		}
-
-		err = gzipWriter.Close()
-		if err != nil {
-			a.log.Info().Err(err).Msg("cannot close gzip writer")
-			continue
-		}
-
-		if a.cfg.HashKey != "" {
-			hash := utils.GenerateHash(buf.Bytes(), a.cfg.HashKey)
-			client.SetHeader("HashSHA256", hash)
-		}
-
-		a.jobsChan <- Job{
-			Request: client.R().SetBody(buf.Bytes()),
-			URL:     url,
+	for i := range arr {
+		labels := a.cfg.Labels.Clone()
+		for name, value := range arr[i].Labels {
+			labels[name] = value
 		}
+		arr[i].Labels = labels
 	}
 
-	a.log.Info().Msg("finished reporting metrics to server/v2...")
+	return arr
 }
 
-func (a *agent) reportMetricsV3(wg *sync.WaitGroup) {
-	wg.Add(1)
+func (a *agent) report(wg *sync.WaitGroup, arr []entity.Metrics) {
 	defer wg.Done()
 
-	a.log.Info().Msg("started reporting metrics to server/v3...")
-	if len(a.metrics.Arr) == 0 {
+	name := a.reporter.transport.Name()
+
+	a.log.Info().Msgf("started reporting metrics to server over %s...", name)
+	if len(arr) == 0 {
 		a.log.Info().Msg("no metrics to report")
 		return
 	}
 
-	client := resty.New().
-		SetHeader("Content-Encoding", "gzip").
-		SetHeader("Content-Type", "application/json")
-
-	url := fmt.Sprintf("http://%s/updates/", a.cfg.ServerAddress)
-
-	a.metrics.mu.RLock()
-	arr := a.metrics.Arr
-	a.metrics.mu.RUnlock()
-
-	list := entity.MetricsList(arr)
-	body, err := easyjson.Marshal(&list)
+	jobs, err := a.reporter.transport.Encode(arr)
 	if err != nil {
-		a.log.Info().Err(err).Msg("cannot unmarshal metric object")
-		return
+		a.log.Error().Err(err).Msg("cannot encode metrics")
 	}
 
-	if a.reporter.publicKey != nil {
-		body, err = cipher.EncryptRSA(a.reporter.publicKey, body)
-		if err != nil {
-			a.log.Info().Err(err).Msg("cannot encrypt message")
-			return
+	// increments of counters that are not in any job are returned,
+	// otherwise they would stay in flight forever
+	unsent := counterDeltas(arr...)
+	for _, job := range jobs {
+		for key, delta := range job.Counters {
+			unsent[key] -= delta
 		}
 	}
-
-	var buf bytes.Buffer
-	gzipWriter := gzip.NewWriter(&buf)
-	_, err = gzipWriter.Write(body)
-	if err != nil {
-		a.log.Info().Err(err).Msg("cannot compress body")
-		return
-	}
-
-	err = gzipWriter.Close()
-	if err != nil {
-		a.log.Info().Err(err).Msg("cannot close gzip writer")
-		return
+	for key, delta := range unsent {
+		if delta == 0 {
+			delete(unsent, key)
+		}
 	}
-
-	if a.cfg.HashKey != "" {
-		hash := utils.GenerateHash(buf.Bytes(), a.cfg.HashKey)
-		client.SetHeader("HashSHA256", hash)
+	if len(unsent) != 0 {
+		a.counters.Nack(Job{Counters: unsent, Session: a.counters.session})
 	}
 
-	a.jobsChan <- Job{
-		Request: client.R().SetBody(buf.Bytes()),
-		URL:     url,
+	for _, job := range jobs {
+		if job.Counters != nil {
+			job.Session = a.counters.session
+		}
+		a.self.queued.Add(1)
+		a.jobsChan <- job
 	}
 
-	a.log.Info().Msg("finished reporting metrics to server/v3...")
+	a.log.Info().Msgf("finished reporting metrics to server over %s...", name)
 }
@@ -0,0 +1,143 @@
+package agent
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/prometheus"
+)
+
+// selfMetricPrefix is reserved for metrics describing agent itself,
+// local applications cannot push metrics with this prefix.
+const selfMetricPrefix = "metrics_agent_"
+
+// selfMetrics tracks work of agent, counters hold totals since start.
+type selfMetrics struct {
+	reportsSent   atomic.Int64
+	reportsFailed atomic.Int64
+	retries       atomic.Int64
+	// queued counts jobs waiting for worker.
+	queued atomic.Int64
+	// lastReport is unix time in nanoseconds of the last delivered job.
+	lastReport atomic.Int64
+
+	mu         sync.Mutex
+	collectors map[string]*collectorStats
+}
+
+type collectorStats struct {
+	duration time.Duration
+	errors   int64
+}
+
+// reported records job delivered after given number of attempts.
+func (s *selfMetrics) reported(attempts int) {
+	s.reportsSent.Add(1)
+	s.retries.Add(int64(max(attempts-1, 0)))
+	s.lastReport.Store(time.Now().UnixNano())
+}
+
+// failed records job that was not delivered after given number of attempts.
+func (s *selfMetrics) failed(attempts int) {
+	s.reportsFailed.Add(1)
+	s.retries.Add(int64(max(attempts-1, 0)))
+}
+
+// collected records duration and result of polling collector.
+func (s *selfMetrics) collected(name string, duration time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.collectors == nil {
+		s.collectors = make(map[string]*collectorStats)
+	}
+
+	stats, ok := s.collectors[name]
+	if !ok {
+		stats = &collectorStats{}
+		s.collectors[name] = stats
+	}
+
+	stats.duration = duration
+	if err != nil {
+		stats.errors++
+	}
+}
+
+// selfSnapshot returns metrics of agent itself, counters are totals,
+// so they are reported as increments just like collected ones.
+func (a *agent) selfSnapshot() []entity.Metrics {
+	s := &a.self
+
+	var lastReport float64
+	if ns := s.lastReport.Load(); ns != 0 {
+		lastReport = float64(ns) / float64(time.Second)
+	}
+
+	arr := []entity.Metrics{
+		selfCounter("reports_sent", s.reportsSent.Load(), nil),
+		selfCounter("reports_failed", s.reportsFailed.Load(), nil),
+		selfCounter("report_retries", s.retries.Load(), nil),
+		selfCounter("batches_dropped", a.droppedBatches(), nil),
+		selfGauge("queue_length", float64(s.queued.Load()), nil),
+		selfGauge("last_report_timestamp_seconds", lastReport, nil),
+	}
+	if a.spool != nil {
+		arr = append(arr, selfGauge("spool_batches", float64(a.spool.Len()), nil))
+	}
+
+	s.mu.Lock()
+	names := make([]string, 0, len(s.collectors))
+	for name := range s.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		stats := s.collectors[name]
+		labels := entity.Labels{"collector": name}
+		arr = append(arr,
+			selfGauge("collect_duration_seconds", stats.duration.Seconds(), labels),
+			selfCounter("collect_errors", stats.errors, labels.Clone()),
+		)
+	}
+	s.mu.Unlock()
+
+	return arr
+}
+
+func selfCounter(name string, total int64, labels entity.Labels) entity.Metrics {
+	return entity.Metrics{ID: selfMetricPrefix + name, MType: entity.Counter, Delta: &total, Labels: labels}
+}
+
+func selfGauge(name string, value float64, labels entity.Labels) entity.Metrics {
+	return entity.Metrics{ID: selfMetricPrefix + name, MType: entity.Gauge, Value: &value, Labels: labels}
+}
+
+// selfMetricsHandler exposes metrics of agent in Prometheus text format,
+// so that agents which stopped reporting can be alerted on.
+func (a *agent) selfMetricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := prometheus.WriteText(&buf, a.selfSnapshot()); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			a.log.Info().Err(err).Msg("cannot render agent metrics in prometheus format")
+			return
+		}
+
+		w.Header().Set("Content-Type", prometheus.ContentType)
+		_, _ = w.Write(buf.Bytes())
+	})
+	return mux
+}
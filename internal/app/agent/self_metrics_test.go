@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/collector"
+	"github.com/Imomali1/metrics/internal/pkg/prometheus"
+	"github.com/Imomali1/metrics/internal/pkg/retry"
+)
+
+// selfMetric finds metric of agent by name without prefix.
+func selfMetric(t *testing.T, arr []entity.Metrics, name string, labels entity.Labels) entity.Metrics {
+	t.Helper()
+
+	for _, metric := range arr {
+		if metric.ID == selfMetricPrefix+name && metric.Labels.String() == labels.String() {
+			return metric
+		}
+	}
+
+	require.Failf(t, "metric is not found", "%s%s", selfMetricPrefix, name)
+	return entity.Metrics{}
+}
+
+func TestAgent_selfSnapshot(t *testing.T) {
+	a, ts := newTestAgent(t, true)
+	a.retry = retry.Policy{MaxAttempts: 2}
+	job := a.httpTarget().job("/updates/", map[string]string{}, []byte("batch"))
+
+	arr := a.selfSnapshot()
+	assert.Zero(t, *selfMetric(t, arr, "last_report_timestamp_seconds", nil).Value)
+
+	// failed job is retried and spooled
+	ts.status = http.StatusServiceUnavailable
+	a.process(context.Background(), job)
+
+	ts.status = 0
+	ts.healthy = true
+	a.drainSpool()
+
+	a.self.collected("runtime", 2*time.Second, nil)
+	a.self.collected("runtime", time.Second, errors.New("failed"))
+
+	arr = a.selfSnapshot()
+	assert.Equal(t, int64(1), *selfMetric(t, arr, "reports_sent", nil).Delta)
+	assert.Equal(t, int64(1), *selfMetric(t, arr, "reports_failed", nil).Delta)
+	assert.Equal(t, int64(1), *selfMetric(t, arr, "report_retries", nil).Delta)
+	assert.Zero(t, *selfMetric(t, arr, "batches_dropped", nil).Delta)
+	assert.Zero(t, *selfMetric(t, arr, "spool_batches", nil).Value)
+	assert.InDelta(t, float64(time.Now().Unix()), *selfMetric(t, arr, "last_report_timestamp_seconds", nil).Value, 60)
+
+	labels := entity.Labels{"collector": "runtime"}
+	assert.Equal(t, 1.0, *selfMetric(t, arr, "collect_duration_seconds", labels).Value)
+	assert.Equal(t, int64(1), *selfMetric(t, arr, "collect_errors", labels).Delta)
+}
+
+func TestAgent_snapshot_SelfMetrics(t *testing.T) {
+	a, _ := newTestAgent(t, false)
+	a.cfg.Labels = entity.Labels{"host": "a"}
+
+	var err error
+	a.poller.registry, err = collector.NewRegistry()
+	require.NoError(t, err)
+
+	// metrics of agent are reported as increments like collected ones
+	a.self.reportsSent.Add(3)
+	arr := a.snapshot()
+	assert.Equal(t, int64(3), *selfMetric(t, arr, "reports_sent", a.cfg.Labels).Delta)
+
+	// increments are tracked by keys of labeled series
+	a.counters.Ack(Job{Counters: counterDeltas(arr...), Session: a.counters.session})
+	for key, inFlight := range a.counters.inFlight {
+		assert.Zero(t, inFlight, key)
+	}
+
+	a.self.reportsSent.Add(2)
+	assert.Equal(t, int64(2), *selfMetric(t, a.snapshot(), "reports_sent", a.cfg.Labels).Delta)
+}
+
+func TestAgent_selfMetricsHandler(t *testing.T) {
+	a, _ := newTestAgent(t, false)
+	a.self.reportsSent.Add(7)
+
+	server := httptest.NewServer(a.selfMetricsHandler())
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, prometheus.ContentType, resp.Header.Get("Content-Type"))
+	assert.Contains(t, string(body), "# TYPE metrics_agent_reports_sent counter\nmetrics_agent_reports_sent 7\n")
+
+	resp, err = http.Post(server.URL+"/metrics", "text/plain", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
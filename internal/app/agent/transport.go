@@ -0,0 +1,231 @@
+package agent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/mailru/easyjson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/cipher"
+	"github.com/Imomali1/metrics/internal/pkg/pb"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+// Transport encodes snapshot of metrics into jobs sent to server,
+// every reporting protocol implements it.
+type Transport interface {
+	// Name is the value of Config.Transport selecting the transport.
+	Name() string
+	// Encode returns jobs reporting arr. Metrics that cannot be
+	// encoded are skipped and described by returned error.
+	Encode(arr []entity.Metrics) ([]Job, error)
+}
+
+// transports maps Config.Transport to constructor of the transport,
+// new protocols are plugged in by adding them here.
+var transports = map[string]func(a *agent) Transport{
+	transportURI:   func(a *agent) Transport { return &uriTransport{a.httpTarget()} },
+	transportJSON:  func(a *agent) Transport { return &jsonTransport{a.httpTarget()} },
+	transportBatch: func(a *agent) Transport { return &batchTransport{a.httpTarget()} },
+	transportHTTP:  func(a *agent) Transport { return &batchTransport{a.httpTarget()} },
+	transportGRPC:  func(*agent) Transport { return &grpcTransport{} },
+}
+
+func newTransport(a *agent) (Transport, error) {
+	newFunc, ok := transports[a.cfg.Transport]
+	if !ok {
+		return nil, fmt.Errorf("unknown transport: %s", a.cfg.Transport)
+	}
+	return newFunc(a), nil
+}
+
+// httpTarget holds settings common for transports over HTTP,
+// server is chosen when job is sent, see endpoints.
+type httpTarget struct {
+	hashKey   string
+	publicKey *rsa.PublicKey
+}
+
+func (a *agent) httpTarget() httpTarget {
+	return httpTarget{
+		hashKey:   a.cfg.HashKey,
+		publicKey: a.reporter.publicKey,
+	}
+}
+
+// job creates job posting body to path of server, headers
+// common for every report are added to given ones.
+func (t httpTarget) job(path string, headers map[string]string, body []byte) Job {
+	return Job{
+		Transport: transportHTTP,
+		Path:      path,
+		Headers:   headers,
+		Body:      body,
+	}
+}
+
+// jsonJob encrypts and compresses JSON body, the result is signed with hash key.
+func (t httpTarget) jsonJob(path string, body []byte) (Job, error) {
+	var err error
+	if t.publicKey != nil {
+		body, err = cipher.EncryptHybrid(t.publicKey, body)
+		if err != nil {
+			return Job{}, fmt.Errorf("cannot encrypt message: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	if _, err = gzipWriter.Write(body); err != nil {
+		return Job{}, fmt.Errorf("cannot compress body: %w", err)
+	}
+
+	if err = gzipWriter.Close(); err != nil {
+		return Job{}, fmt.Errorf("cannot close gzip writer: %w", err)
+	}
+
+	headers := map[string]string{
+		"Content-Encoding": "gzip",
+		"Content-Type":     "application/json",
+	}
+
+	if t.hashKey != "" {
+		headers["HashSHA256"] = utils.GenerateHash(buf.Bytes(), t.hashKey)
+	}
+
+	return t.job(path, headers, buf.Bytes()), nil
+}
+
+// uriTransport sends every metric in URI of POST /update/{type}/{name}/{value}.
+type uriTransport struct {
+	httpTarget
+}
+
+func (t *uriTransport) Name() string {
+	return transportURI
+}
+
+func (t *uriTransport) Encode(arr []entity.Metrics) ([]Job, error) {
+	jobs := make([]Job, 0, len(arr))
+
+	var errs []error
+	for _, metric := range arr {
+		var query string
+		if len(metric.Labels) != 0 {
+			values := make(url.Values, len(metric.Labels))
+			for name, value := range metric.Labels {
+				values.Set(name, value)
+			}
+			query = "?" + values.Encode()
+		}
+
+		path := fmt.Sprintf("/update/%s/%s/", metric.MType, metric.ID)
+		switch metric.MType {
+		case entity.Counter:
+			path = fmt.Sprintf("%s%d", path, *metric.Delta)
+		case entity.Gauge:
+			path = fmt.Sprintf("%s%f", path, *metric.Value)
+		case entity.Histogram:
+			// URI carries single observation, buckets are reported by JSON transports only
+			errs = append(errs, fmt.Errorf("histogram %s cannot be reported in uri", metric.Key()))
+			continue
+		case entity.Summary:
+			errs = append(errs, fmt.Errorf("summary %s cannot be reported in uri", metric.Key()))
+			continue
+		default:
+			errs = append(errs, fmt.Errorf("invalid metric type: %s", metric.MType))
+			continue
+		}
+
+		job := t.job(path+query, map[string]string{"Content-Type": "text/plain"}, nil)
+		job.Counters = counterDeltas(metric)
+		jobs = append(jobs, job)
+	}
+
+	return jobs, errors.Join(errs...)
+}
+
+// jsonTransport sends every metric as JSON object to POST /update/.
+type jsonTransport struct {
+	httpTarget
+}
+
+func (t *jsonTransport) Name() string {
+	return transportJSON
+}
+
+func (t *jsonTransport) Encode(arr []entity.Metrics) ([]Job, error) {
+	jobs := make([]Job, 0, len(arr))
+
+	var errs []error
+	for _, metric := range arr {
+		body, err := easyjson.Marshal(metric)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot marshal metric object: %w", err))
+			continue
+		}
+
+		job, err := t.jsonJob("/update/", body)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		job.Counters = counterDeltas(metric)
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, errors.Join(errs...)
+}
+
+// batchTransport sends all metrics as JSON array to POST /updates/.
+type batchTransport struct {
+	httpTarget
+}
+
+func (t *batchTransport) Name() string {
+	return transportBatch
+}
+
+func (t *batchTransport) Encode(arr []entity.Metrics) ([]Job, error) {
+	list := entity.MetricsList(arr)
+	body, err := easyjson.Marshal(&list)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal metric objects: %w", err)
+	}
+
+	job, err := t.jsonJob("/updates/", body)
+	if err != nil {
+		return nil, err
+	}
+	job.Counters = counterDeltas(arr...)
+
+	return []Job{job}, nil
+}
+
+// grpcTransport sends all metrics in single UpdateMetrics call,
+// encryption and hash are added by client interceptors when job is sent.
+type grpcTransport struct{}
+
+func (t *grpcTransport) Name() string {
+	return transportGRPC
+}
+
+func (t *grpcTransport) Encode(arr []entity.Metrics) ([]Job, error) {
+	req := &pb.UpdateMetricsRequest{
+		Metrics: pb.FromEntityList(arr),
+	}
+
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal metrics: %w", err)
+	}
+
+	return []Job{{Transport: transportGRPC, Body: body, Counters: counterDeltas(arr...)}}, nil
+}
@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/mailru/easyjson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/collector"
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+	"github.com/Imomali1/metrics/internal/pkg/pb"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+func testMetrics() []entity.Metrics {
+	return []entity.Metrics{
+		{ID: "PollCount", MType: entity.Counter, Delta: utils.Ptr(int64(5))},
+		{ID: "Alloc", MType: entity.Gauge, Value: utils.Ptr(1.5), Labels: entity.Labels{"host": "a"}},
+		{ID: "latency", MType: entity.Histogram, Histogram: entity.NewHistogram([]float64{1})},
+	}
+}
+
+func gunzip(t *testing.T, body []byte) []byte {
+	t.Helper()
+
+	r, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return data
+}
+
+func Test_newTransport(t *testing.T) {
+	tests := []struct {
+		transport string
+		want      string
+		wantErr   bool
+	}{
+		{transport: transportURI, want: transportURI},
+		{transport: transportJSON, want: transportJSON},
+		{transport: transportBatch, want: transportBatch},
+		{transport: transportHTTP, want: transportBatch},
+		{transport: transportGRPC, want: transportGRPC},
+		{transport: "smoke-signals", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.transport, func(t *testing.T) {
+			got, err := newTransport(&agent{cfg: Config{Transport: tt.transport}})
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Name())
+		})
+	}
+}
+
+func Test_uriTransport_Encode(t *testing.T) {
+	tr := &uriTransport{httpTarget{}}
+
+	jobs, err := tr.Encode(testMetrics())
+	require.Error(t, err, "histogram is not reported in uri")
+	require.Len(t, jobs, 2)
+
+	assert.Equal(t, "/update/counter/PollCount/5", jobs[0].Path)
+	assert.Equal(t, "/update/gauge/Alloc/1.500000?host=a", jobs[1].Path)
+}
+
+func Test_jsonTransport_Encode(t *testing.T) {
+	tr := &jsonTransport{httpTarget{hashKey: "key"}}
+
+	arr := testMetrics()
+	jobs, err := tr.Encode(arr)
+	require.NoError(t, err)
+	require.Len(t, jobs, len(arr))
+
+	for i, job := range jobs {
+		assert.Equal(t, "/update/", job.Path)
+		assert.Equal(t, utils.GenerateHash(job.Body, "key"), job.Headers["HashSHA256"])
+
+		var got entity.Metrics
+		require.NoError(t, easyjson.Unmarshal(gunzip(t, job.Body), &got))
+		assert.Equal(t, arr[i], got)
+	}
+}
+
+func Test_batchTransport_Encode(t *testing.T) {
+	tr := &batchTransport{httpTarget{}}
+
+	arr := testMetrics()
+	jobs, err := tr.Encode(arr)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "/updates/", jobs[0].Path)
+	assert.NotContains(t, jobs[0].Headers, "HashSHA256")
+
+	var got entity.MetricsList
+	require.NoError(t, easyjson.Unmarshal(gunzip(t, jobs[0].Body), &got))
+	assert.Equal(t, entity.MetricsList(arr), got)
+}
+
+func Test_grpcTransport_Encode(t *testing.T) {
+	arr := testMetrics()
+	jobs, err := (&grpcTransport{}).Encode(arr)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, transportGRPC, jobs[0].Transport)
+
+	var req pb.UpdateMetricsRequest
+	require.NoError(t, proto.Unmarshal(jobs[0].Body, &req))
+	assert.Len(t, req.GetMetrics(), len(arr))
+}
+
+func TestAgent_report(t *testing.T) {
+	// every snapshot is reported once with the configured transport
+	a := &agent{
+		log:      logger.NewLogger(os.Stdout, "info", "test"),
+		jobsChan: make(chan Job, 10),
+		counters: newCounterTracker(),
+	}
+	a.reporter.transport = &batchTransport{a.httpTarget()}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	a.report(&wg, testMetrics())
+	wg.Wait()
+	close(a.jobsChan)
+
+	var jobs []Job
+	for job := range a.jobsChan {
+		jobs = append(jobs, job)
+	}
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "/updates/", jobs[0].Path)
+}
+
+// failingTransport cannot encode any metrics.
+type failingTransport struct{}
+
+func (failingTransport) Name() string {
+	return "failing"
+}
+
+func (failingTransport) Encode([]entity.Metrics) ([]Job, error) {
+	return nil, errors.New("cannot marshal metrics")
+}
+
+func TestAgent_report_EncodeError(t *testing.T) {
+	a := &agent{
+		log:      logger.NewLogger(os.Stdout, "info", "test"),
+		jobsChan: make(chan Job, 10),
+		counters: newCounterTracker(),
+	}
+	a.reporter.transport = failingTransport{}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	a.report(&wg, a.counters.Take(testMetrics()))
+	wg.Wait()
+
+	// increments that were not sent are reported next time
+	require.Empty(t, a.jobsChan)
+	assert.Zero(t, a.counters.inFlight["PollCount"])
+	arr := a.counters.Take(testMetrics())
+	assert.Equal(t, int64(5), *arr[0].Delta)
+}
+
+func TestAgent_PollMetricsPeriodically_Shutdown(t *testing.T) {
+	a := &agent{
+		log:        logger.NewLogger(os.Stdout, "info", "test"),
+		jobsChan:   make(chan Job, 10),
+		shutdownCh: make(chan struct{}),
+		counters:   newCounterTracker(),
+	}
+	a.reporter.transport = &batchTransport{a.httpTarget()}
+
+	var err error
+	a.poller.registry, err = collector.NewRegistry()
+	require.NoError(t, err)
+
+	// the last report is waited for, so jobs channel can be closed
+	close(a.shutdownCh)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	a.PollMetricsPeriodically(&wg)
+	wg.Wait()
+	close(a.jobsChan)
+
+	require.Len(t, a.jobsChan, 1)
+}
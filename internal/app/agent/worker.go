@@ -1,29 +1,261 @@
 package agent
 
 import (
-	"github.com/Imomali1/metrics/internal/pkg/utils"
-	"github.com/go-resty/resty/v2"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Imomali1/metrics/internal/pkg/pb"
+	"github.com/Imomali1/metrics/internal/pkg/retry"
 )
 
+// Job is a single report to server. Jobs are serializable,
+// so that unsent ones can be kept in spool until server is back.
 type Job struct {
-	Request *resty.Request
-	URL     string
+	// Transport is either transportHTTP or transportGRPC.
+	Transport string `json:"transport"`
+	// Path, Headers and Body of HTTP request to the current endpoint,
+	// Body of gRPC job is serialized pb.UpdateMetricsRequest.
+	Path    string            `json:"path,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    []byte            `json:"body,omitempty"`
+	// Counters holds increments of counters in Body by series key,
+	// Session identifies tracker they were taken from, see counterTracker.
+	Counters map[string]int64 `json:"counters,omitempty"`
+	Session  int64            `json:"session,omitempty"`
 }
 
-func (t *Job) Process() error {
-	err := utils.DoWithRetries(func() error {
-		_, err := t.Request.Post(t.URL)
-		return err
+func (a *agent) worker(ctx context.Context) {
+	for job := range a.jobsChan {
+		a.self.queued.Add(-1)
+		a.process(ctx, job)
+	}
+}
+
+// process sends job with retries, waiting for next attempt stops
+// once ctx is done and job is spooled then.
+func (a *agent) process(ctx context.Context, job Job) {
+	if a.cfg.FanOut && job.Transport == transportHTTP {
+		a.mirror(job)
+	}
+
+	// new jobs wait behind spooled ones, so that batches reach server in order
+	if a.spool != nil && a.spool.Len() != 0 {
+		a.spoolJob(job)
+		return
+	}
+
+	var attempts int
+	err := a.retry.Do(ctx, func(context.Context) error {
+		attempts++
+		return a.send(job)
 	})
-	return err
+	if err != nil && !a.retry.IsRetryable(err) {
+		// server will not accept the same job again
+		a.self.failed(attempts)
+		a.dropJob(job, err)
+		return
+	}
+	if err != nil {
+		a.log.Info().Err(err).Msg("error in reporting metrics to server")
+		a.self.failed(attempts)
+		a.spoolJob(job)
+		return
+	}
+
+	a.self.reported(attempts)
+	a.counters.Ack(job)
+	a.log.Info().Msg("metrics reported successfully")
 }
 
-func (a *agent) worker() {
-	for job := range a.jobsChan {
-		if err := job.Process(); err != nil {
-			a.log.Info().Err(err).Msg("error in reporting metrics to server")
-		} else {
-			a.log.Info().Msg("metrics reported successfully")
+// send makes single attempt to send job to server,
+// unsuccessful response is returned as retry.StatusError.
+func (a *agent) send(job Job) error {
+	switch job.Transport {
+	case transportGRPC:
+		var req pb.UpdateMetricsRequest
+		if err := proto.Unmarshal(job.Body, &req); err != nil {
+			return err
 		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), a.reporter.interval)
+		defer cancel()
+
+		_, err := a.reporter.grpcClient.UpdateMetrics(ctx, &req)
+		return err
+	default:
+		ep := a.endpoints.current()
+		err := a.sendHTTP(ep, job)
+		a.endpoints.report(ep, err)
+		return err
+	}
+}
+
+// sendHTTP makes single attempt to send job to ep.
+func (a *agent) sendHTTP(ep *endpoint, job Job) error {
+	req := a.reporter.httpClient.R().SetHeaders(job.Headers)
+	if ep.realIP != "" {
+		req.SetHeader("X-Real-IP", ep.realIP)
+	}
+	// jobs of uri transport have no body, resty rejects nil one
+	if job.Body != nil {
+		req.SetBody(job.Body)
+	}
+
+	resp, err := req.Post(fmt.Sprintf("http://%s%s", ep.address, job.Path))
+	if err != nil {
+		return err
+	}
+
+	// job is acknowledged by successful response only
+	if resp.StatusCode() >= http.StatusBadRequest {
+		return &retry.StatusError{Code: resp.StatusCode()}
+	}
+	return nil
+}
+
+// mirror sends copy of job to every available endpoint except the
+// current one, see Config.FanOut. Copies are sent once and are neither
+// spooled nor acknowledged, the current endpoint stays the source of truth.
+func (a *agent) mirror(job Job) {
+	for _, ep := range a.endpoints.mirrors(a.endpoints.current()) {
+		err := a.sendHTTP(ep, job)
+		a.endpoints.report(ep, err)
+		if err != nil {
+			a.log.Info().Err(err).Msgf("failed to mirror metrics to server %s", ep.address)
+		}
+	}
+}
+
+// spoolJob keeps job that could not be sent, the job is dropped
+// when spool is disabled or job cannot be stored.
+func (a *agent) spoolJob(job Job) {
+	if a.spool == nil {
+		a.dropJob(job, nil)
+		return
+	}
+
+	data, err := json.Marshal(job)
+	if err == nil {
+		err = a.spool.Put(data)
+	}
+
+	if err != nil {
+		a.dropJob(job, err)
+		return
 	}
+
+	a.log.Info().Msgf("metrics batch is spooled, %d batches are waiting", a.spool.Len())
+}
+
+// evictJob returns counter increments of job dropped by spool limits.
+func (a *agent) evictJob(data []byte) {
+	if job, err := decodeJob(data); err == nil {
+		a.counters.Nack(job)
+	}
+}
+
+// decodeJob decodes spooled job. Agents reporting to single server
+// spooled absolute URL instead of Path, such jobs are migrated to
+// the path of URL and sent to the current endpoint.
+func decodeJob(data []byte) (Job, error) {
+	var job struct {
+		Job
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(data, &job); err != nil {
+		return job.Job, err
+	}
+
+	if job.Path == "" && job.URL != "" {
+		u, err := url.Parse(job.URL)
+		if err != nil {
+			return job.Job, err
+		}
+		job.Path = u.RequestURI()
+	}
+
+	return job.Job, nil
+}
+
+// dropJob gives up on job, its counter increments are reported again.
+func (a *agent) dropJob(job Job, err error) {
+	a.counters.Nack(job)
+	a.dropped.Add(1)
+	a.log.Info().Err(err).Msgf("metrics batch is dropped, %d batches are dropped in total", a.droppedBatches())
+}
+
+// droppedBatches returns number of batches that never reached server.
+func (a *agent) droppedBatches() int64 {
+	dropped := a.dropped.Load()
+	if a.spool != nil {
+		dropped += a.spool.Dropped()
+	}
+	return dropped
+}
+
+// DrainSpoolPeriodically sends spooled jobs in order once server is healthy.
+func (a *agent) DrainSpoolPeriodically(wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(a.reporter.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			a.drainSpool()
+		case <-a.shutdownCh:
+			a.log.Info().Msg("stopped draining spool")
+			return
+		}
+	}
+}
+
+func (a *agent) drainSpool() {
+	if a.spool.Len() == 0 {
+		return
+	}
+
+	// gRPC server is probed by the first spooled job, it has no /healthz
+	if a.cfg.Transport != transportGRPC {
+		if err := a.endpoints.check(context.Background()); err != nil {
+			a.log.Info().Err(err).Msgf("server is not healthy, %d batches are waiting in spool", a.spool.Len())
+			return
+		}
+	}
+
+	err := a.spool.Drain(func(data []byte) error {
+		job, err := decodeJob(data)
+		if err != nil {
+			a.dropJob(job, err)
+			return nil
+		}
+
+		err = a.send(job)
+		if err != nil && !a.retry.IsRetryable(err) {
+			a.self.failed(1)
+			a.dropJob(job, err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		a.self.reported(1)
+		a.counters.Ack(job)
+		return nil
+	})
+	if err != nil {
+		a.log.Info().Err(err).Msgf("failed to drain spool, %d batches are waiting", a.spool.Len())
+		return
+	}
+
+	a.log.Info().Msg("spooled metrics reported successfully")
 }
@@ -0,0 +1,250 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+	"github.com/Imomali1/metrics/internal/pkg/pb"
+	"github.com/Imomali1/metrics/internal/pkg/retry"
+	"github.com/Imomali1/metrics/internal/pkg/spool"
+)
+
+type testServer struct {
+	mu      sync.Mutex
+	healthy bool
+	status  int
+	bodies  []string
+	// realIPs holds X-Real-IP header of every report.
+	realIPs []string
+	// requests counts reports, including unsuccessful ones.
+	requests int
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/healthz" {
+		if !s.healthy {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.requests++
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	s.bodies = append(s.bodies, r.Header.Get("X-Test")+":"+string(body))
+	s.realIPs = append(s.realIPs, r.Header.Get("X-Real-IP"))
+}
+
+func newTestAgent(t *testing.T, withSpool bool) (*agent, *testServer) {
+	ts := &testServer{}
+	server := httptest.NewServer(ts)
+	t.Cleanup(server.Close)
+
+	a := &agent{
+		log: logger.NewLogger(os.Stdout, "info", "test"),
+		reporter: reporter{
+			interval:   time.Second,
+			httpClient: resty.New(),
+		},
+		counters: newCounterTracker(),
+	}
+	a.endpoints = newEndpoints([]string{strings.TrimPrefix(server.URL, "http://")},
+		1, time.Second, a.reporter.httpClient, a.log)
+
+	if withSpool {
+		var err error
+		a.spool, err = spool.New(t.TempDir(), 0, 0)
+		require.NoError(t, err)
+	}
+
+	return a, ts
+}
+
+func TestAgent_drainSpool(t *testing.T) {
+	a, ts := newTestAgent(t, true)
+
+	for _, body := range []string{"batch1", "batch2", "batch3"} {
+		a.spoolJob(a.httpTarget().job("/updates/", map[string]string{"X-Test": "header"}, []byte(body)))
+	}
+	require.Equal(t, 3, a.spool.Len())
+
+	// server is not healthy yet
+	a.drainSpool()
+	require.Empty(t, ts.bodies)
+	require.Equal(t, 3, a.spool.Len())
+
+	ts.mu.Lock()
+	ts.healthy = true
+	ts.mu.Unlock()
+
+	a.drainSpool()
+	require.Equal(t, []string{"header:batch1", "header:batch2", "header:batch3"}, ts.bodies)
+	require.Equal(t, 0, a.spool.Len())
+	require.Equal(t, int64(0), a.droppedBatches())
+}
+
+// testGRPCClient accepts UpdateMetrics calls unless err is set.
+type testGRPCClient struct {
+	pb.MetricsClient
+	err   error
+	calls int
+}
+
+func (c *testGRPCClient) UpdateMetrics(
+	context.Context,
+	*pb.UpdateMetricsRequest,
+	...grpc.CallOption,
+) (*pb.UpdateMetricsResponse, error) {
+	c.calls++
+	return &pb.UpdateMetricsResponse{}, c.err
+}
+
+func TestAgent_drainSpool_GRPC(t *testing.T) {
+	// HTTP server is never healthy, gRPC jobs do not depend on it
+	a, _ := newTestAgent(t, true)
+	a.cfg.Transport = transportGRPC
+
+	client := &testGRPCClient{err: status.Error(codes.Unavailable, "down")}
+	a.reporter.grpcClient = client
+
+	jobs, err := (&grpcTransport{}).Encode(testMetrics())
+	require.NoError(t, err)
+	a.spoolJob(jobs[0])
+	a.spoolJob(jobs[0])
+
+	a.drainSpool()
+	require.Equal(t, 1, client.calls, "draining stops on unavailable server")
+	require.Equal(t, 2, a.spool.Len())
+
+	client.err = nil
+	a.drainSpool()
+	require.Equal(t, 3, client.calls)
+	require.Zero(t, a.spool.Len())
+}
+
+func TestAgent_spoolJob_WithoutSpool(t *testing.T) {
+	a, _ := newTestAgent(t, false)
+
+	a.spoolJob(Job{Transport: transportHTTP})
+	a.spoolJob(Job{Transport: transportHTTP})
+	require.Equal(t, int64(2), a.droppedBatches())
+}
+
+func TestAgent_process_AcknowledgesCounters(t *testing.T) {
+	a, ts := newTestAgent(t, true)
+	ts.healthy = true
+
+	newJob := func(total int64) Job {
+		job := a.httpTarget().job("/updates/", map[string]string{}, []byte("batch"))
+		job.Counters = counterDeltas(a.counters.Take(pollCount(total))...)
+		job.Session = a.counters.session
+		return job
+	}
+
+	// rejected job is dropped and its increment is reported again
+	ts.status = http.StatusBadRequest
+	a.process(context.Background(), newJob(5))
+	require.Equal(t, int64(1), a.droppedBatches())
+	require.Zero(t, a.spool.Len())
+
+	// job is acknowledged by successful response only
+	ts.status = 0
+	a.process(context.Background(), newJob(5))
+	require.Equal(t, int64(5), a.counters.acked["PollCount"])
+
+	// spooled job stays in flight until it is drained
+	a.spoolJob(newJob(7))
+	require.Equal(t, int64(2), a.counters.inFlight["PollCount"])
+	require.Len(t, a.counters.Take(pollCount(7)), 1, "increment is not taken twice")
+
+	a.drainSpool()
+	require.Equal(t, int64(7), a.counters.acked["PollCount"])
+	require.Zero(t, a.counters.inFlight["PollCount"])
+}
+
+func TestAgent_spoolJob_Evicted(t *testing.T) {
+	a, _ := newTestAgent(t, false)
+
+	var err error
+	a.spool, err = spool.New(t.TempDir(), 1024, 0)
+	require.NoError(t, err)
+	a.spool.OnEvict(a.evictJob)
+
+	newJob := func(total int64) Job {
+		job := a.httpTarget().job("/updates/", map[string]string{}, make([]byte, 600))
+		job.Counters = counterDeltas(a.counters.Take(pollCount(total))...)
+		job.Session = a.counters.session
+		return job
+	}
+
+	// the first job is evicted by the second one, its increment is taken again
+	a.spoolJob(newJob(5))
+	a.spoolJob(newJob(7))
+	require.Equal(t, 1, a.spool.Len())
+	require.Equal(t, int64(2), a.counters.inFlight["PollCount"])
+
+	taken := a.counters.Take(pollCount(7))
+	require.Equal(t, int64(5), *taken[0].Delta)
+}
+
+func TestAgent_process_Retries(t *testing.T) {
+	a, ts := newTestAgent(t, true)
+	a.retry = retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	job := a.httpTarget().job("/updates/", map[string]string{}, []byte("batch"))
+
+	// server errors are retried and job is spooled once attempts are over
+	ts.status = http.StatusServiceUnavailable
+	a.process(context.Background(), job)
+	require.Equal(t, 3, ts.requests)
+	require.Equal(t, 1, a.spool.Len())
+
+	a.spool, _ = spool.New(t.TempDir(), 0, 0)
+	ts.requests = 0
+
+	// rejected job is not retried
+	ts.status = http.StatusBadRequest
+	a.process(context.Background(), job)
+	require.Equal(t, 1, ts.requests)
+	require.Zero(t, a.spool.Len())
+
+	ts.requests = 0
+
+	// job is spooled without waiting for retries on shutdown
+	a.retry.BaseDelay, a.retry.MaxDelay = time.Hour, time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ts.status = http.StatusServiceUnavailable
+	a.process(ctx, job)
+	require.Equal(t, 1, ts.requests)
+	require.Equal(t, 1, a.spool.Len())
+}
+
+func TestAgent_send_WithoutBody(t *testing.T) {
+	a, ts := newTestAgent(t, false)
+
+	job := a.httpTarget().job("/update/counter/PollCount/1", map[string]string{"X-Test": "uri"}, nil)
+	require.NoError(t, a.send(job))
+	require.Equal(t, []string{"uri:"}, ts.bodies)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -16,6 +17,8 @@ import (
 
 	"crypto/rsa"
 
+	"google.golang.org/grpc"
+
 	"github.com/Imomali1/metrics/internal/api"
 	"github.com/Imomali1/metrics/internal/pkg/cipher"
 	"github.com/Imomali1/metrics/internal/pkg/logger"
@@ -31,12 +34,31 @@ const (
 )
 
 func Run(cfg Config, log logger.Logger) error {
-	store, err := storage.New(context.Background(), cfg.DatabaseDSN)
+	storeOpts := []storage.Option{storage.WithRetryPolicy(cfg.RetryPolicy())}
+	if cfg.DisableMigrations {
+		storeOpts = append(storeOpts, storage.WithoutMigrations())
+	}
+
+	store, err := storage.New(context.Background(), cfg.DatabaseDSN, storeOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
-	if cfg.Restore {
+	// WAL replaces both restoring and synchronous writing of the snapshot,
+	// it is used only with memory storage, see LoadConfig.
+	var walStore *file.WALStorage
+	if cfg.WALPath != "" {
+		walStore, err = file.OpenWALStorage(context.Background(),
+			store,
+			cfg.FileStoragePath,
+			cfg.WALPath,
+			cfg.Restore,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to open wal: %w", err)
+		}
+		store = walStore
+	} else if cfg.Restore {
 		err = file.RestoreMetrics(context.Background(), cfg.FileStoragePath, store)
 		if err != nil {
 			return fmt.Errorf("failed to restore metrics: %w", err)
@@ -44,7 +66,7 @@ func Run(cfg Config, log logger.Logger) error {
 	}
 
 	var syncFileWriter file.SyncFileWriter
-	if cfg.StoreInterval == 0 {
+	if cfg.StoreInterval == 0 && walStore == nil {
 		syncFileWriter, err = file.NewSyncMetricsWriter(cfg.FileStoragePath)
 		if err != nil {
 			return fmt.Errorf("failed to initialize sync file writer: %w", err)
@@ -61,13 +83,14 @@ func Run(cfg Config, log logger.Logger) error {
 
 	repo := repository.New(store, syncFileWriter)
 	uc := usecase.New(repo)
-	handler := api.NewRouter(api.Options{
+	options := api.Options{
 		Logger:           log,
 		UseCase:          uc,
 		Cfg:              cfg.API,
 		HTMLTemplatePath: _htmlTemplatePath,
 		PrivateKey:       privateKey,
-	})
+	}
+	handler := api.NewRouter(options)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -84,8 +107,41 @@ func Run(cfg Config, log logger.Logger) error {
 		}
 	}()
 
+	var grpcServer *grpc.Server
+	if cfg.GRPCAddress != "" {
+		var listener net.Listener
+		listener, err = net.Listen("tcp", cfg.GRPCAddress)
+		if err != nil {
+			return fmt.Errorf("failed to listen grpc address: %w", err)
+		}
+
+		grpcServer = api.NewGRPCServer(options)
+
+		go func() {
+			if errServe := grpcServer.Serve(listener); errServe != nil {
+				log.Fatal().Err(errServe).Msg("failed to serve grpc server")
+			}
+		}()
+	}
+
 	var wg sync.WaitGroup
-	if cfg.FileStoragePath != "" && cfg.StoreInterval != 0 {
+	if walStore != nil {
+		interval := cfg.StoreInterval
+		if interval == 0 {
+			interval = defaultStoreInterval
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errCheckpoint := tasks.RunCheckpoints(ctx, walStore, time.Duration(interval)*time.Second, log)
+			if errCheckpoint != nil {
+				log.Error().Err(errCheckpoint).Msg("error in making wal checkpoint")
+			}
+		}()
+	} else if cfg.FileStoragePath != "" && cfg.StoreInterval != 0 {
 		wg.Add(1)
 
 		go func() {
@@ -102,6 +158,22 @@ func Run(cfg Config, log logger.Logger) error {
 		}()
 	}
 
+	if cfg.StatsDAddress != "" {
+		var conn net.PacketConn
+		conn, err = net.ListenPacket("udp", cfg.StatsDAddress)
+		if err != nil {
+			return fmt.Errorf("failed to listen statsd address: %w", err)
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			serveStatsD(ctx, conn, uc, time.Duration(cfg.StatsDFlushInterval)*time.Second, log)
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 
@@ -112,10 +184,21 @@ func Run(cfg Config, log logger.Logger) error {
 	ctxShutdown, cancel := context.WithTimeout(context.Background(), _timeout)
 	defer cancel()
 
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+
 	if err = server.Shutdown(ctxShutdown); err != nil {
 		return fmt.Errorf("error in shutting down server: %w", err)
 	}
 
+	if walStore != nil {
+		if err = walStore.Checkpoint(context.Background()); err != nil {
+			return fmt.Errorf("failed to make wal checkpoint: %w", err)
+		}
+		walStore.Close()
+	}
+
 	log.Info().Msg("server stopped successfully")
 
 	return nil
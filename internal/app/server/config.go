@@ -2,15 +2,19 @@ package server
 
 import (
 	"flag"
+	"net"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/Imomali1/metrics/internal/api"
+	"github.com/Imomali1/metrics/internal/pkg/retry"
 	"github.com/Imomali1/metrics/internal/pkg/utils"
 )
 
 type Config struct {
 	ServerAddress   string
+	GRPCAddress     string
 	StoreInterval   int
 	FileStoragePath string
 	Restore         bool
@@ -18,29 +22,58 @@ type Config struct {
 	API             api.Config
 	PrivateKeyPath  string
 
+	StatsDAddress       string
+	StatsDFlushInterval int
+
+	DisableMigrations bool
+	// RetryMaxAttempts limits attempts of connecting to database,
+	// RetryBaseDelay and RetryMaxDelay bound backoff in milliseconds.
+	RetryMaxAttempts int
+	RetryBaseDelay   int
+	RetryMaxDelay    int
+
+	WALPath string
+
 	ServiceName string
 	LogLevel    string
 }
 
 const (
 	defaultServerAddress   = "localhost:8080"
+	defaultGRPCAddress     = ""
 	defaultStoreInterval   = 300
 	defaultFileStoragePath = "/tmp/metrics-database.json"
 	defaultRestore         = true
 	defaultDSN             = ""
 
+	defaultStatsDAddress       = ""
+	defaultStatsDFlushInterval = 10
+
+	defaultRetryAttempts  = 4
+	defaultRetryBaseDelay = 1000
+	defaultRetryMaxDelay  = 5000
+
 	defaultServiceName = "metrics_server"
 	defaultLogLevel    = "info"
 )
 
 func LoadConfig() (cfg Config) {
 	serverAddress := flag.String("a", "", "отвечает за адрес эндпоинта HTTP-сервера")
+	grpcAddress := flag.String("g", "", "адрес gRPC-сервера, пустое значение отключает gRPC")
 	storeInterval := flag.Int("i", 0, "интервал времени в секундах, по истечении которого текущие показания сервера сохраняются на диск")
 	fileStoragePath := flag.String("f", "", "полное имя файла, куда сохраняются текущие значения")
+	walPath := flag.String("wal-file", "", "полное имя файла журнала упреждающей записи (WAL), пустое значение отключает журнал")
 	restore := flag.Bool("r", false, "булево значение, определяющее, загружать или нет ранее сохранённые значения из указанного файла при старте сервера")
-	databaseDSN := flag.String("d", "", "адрес подключения к БД")
+	databaseDSN := flag.String("d", "", "адрес подключения к БД, bolt://<путь> для встроенной БД в файле")
+	disableMigrations := flag.Bool("disable-migrations", false, "не применять миграции БД при старте сервера")
+	retryMaxAttempts := flag.Int("retry-attempts", 0, "максимальное количество попыток подключения к БД")
+	retryBaseDelay := flag.Int("retry-base-delay", 0, "начальная задержка между попытками подключения в миллисекундах")
+	retryMaxDelay := flag.Int("retry-max-delay", 0, "максимальная задержка между попытками подключения в миллисекундах")
 	hashKey := flag.String("k", "", "Ключ для подписи данных")
+	trustedSubnet := flag.String("t", "", "доверенная подсеть в формате CIDR")
 	privateKeyPath := flag.String("crypto-key", "", "путь до файла с приватным ключом")
+	statsdAddress := flag.String("statsd-address", "", "UDP-адрес для приёма метрик в формате StatsD, пустое значение отключает приём")
+	statsdFlushInterval := flag.Int("statsd-flush-interval", 0, "интервал времени в секундах, с которым накопленные метрики StatsD сохраняются")
 	shortConfigFilePath := flag.String("c", "", "путь до файла конфигурации short")
 	longConfigFilePath := flag.String("config", "", "путь до файла конфигурации long")
 
@@ -73,6 +106,13 @@ func LoadConfig() (cfg Config) {
 		defaultServerAddress,
 	)
 
+	cfg.GRPCAddress = getEnvString(
+		"GRPC_ADDRESS",
+		*grpcAddress,
+		fileConf.GRPCAddress,
+		defaultGRPCAddress,
+	)
+
 	var fileStoreInterval *int
 	if fileConf.StoreInterval != nil {
 		fileStoreInterval = utils.Ptr(int(fileConf.StoreInterval.Seconds()))
@@ -99,6 +139,17 @@ func LoadConfig() (cfg Config) {
 		defaultRestore,
 	)
 
+	cfg.WALPath = getEnvString(
+		"WAL_FILE",
+		*walPath,
+		fileConf.WALPath,
+		"",
+	)
+
+	if cfg.WALPath != "" && cfg.FileStoragePath == "" {
+		panic("wal file requires file storage path for checkpoints")
+	}
+
 	cfg.DatabaseDSN = getEnvString(
 		"DATABASE_DSN",
 		*databaseDSN,
@@ -106,6 +157,53 @@ func LoadConfig() (cfg Config) {
 		defaultDSN,
 	)
 
+	// database storage is durable on its own, WAL would be silently ignored
+	if cfg.WALPath != "" && cfg.DatabaseDSN != "" {
+		panic("wal file cannot be used with database storage")
+	}
+
+	cfg.DisableMigrations = getEnvBool(
+		"DISABLE_MIGRATIONS",
+		*disableMigrations,
+		fileConf.DisableMigrations,
+		false,
+	)
+
+	cfg.RetryMaxAttempts = getEnvInt(
+		"RETRY_MAX_ATTEMPTS",
+		*retryMaxAttempts,
+		fileConf.RetryMaxAttempts,
+		defaultRetryAttempts,
+	)
+
+	var fileRetryBaseDelay *int
+	if fileConf.RetryBaseDelay != nil {
+		fileRetryBaseDelay = utils.Ptr(int(fileConf.RetryBaseDelay.Milliseconds()))
+	}
+
+	cfg.RetryBaseDelay = getEnvInt(
+		"RETRY_BASE_DELAY",
+		*retryBaseDelay,
+		fileRetryBaseDelay,
+		defaultRetryBaseDelay,
+	)
+
+	var fileRetryMaxDelay *int
+	if fileConf.RetryMaxDelay != nil {
+		fileRetryMaxDelay = utils.Ptr(int(fileConf.RetryMaxDelay.Milliseconds()))
+	}
+
+	cfg.RetryMaxDelay = getEnvInt(
+		"RETRY_MAX_DELAY",
+		*retryMaxDelay,
+		fileRetryMaxDelay,
+		defaultRetryMaxDelay,
+	)
+
+	if err = cfg.RetryPolicy().Validate(); err != nil {
+		panic(err)
+	}
+
 	cfg.PrivateKeyPath = getEnvString(
 		"CRYPTO_KEY",
 		*privateKeyPath,
@@ -113,14 +211,60 @@ func LoadConfig() (cfg Config) {
 		"",
 	)
 
+	cfg.StatsDAddress = getEnvString(
+		"STATSD_ADDRESS",
+		*statsdAddress,
+		fileConf.StatsDAddress,
+		defaultStatsDAddress,
+	)
+
+	var fileStatsDFlushInterval *int
+	if fileConf.StatsDFlushInterval != nil {
+		fileStatsDFlushInterval = utils.Ptr(int(fileConf.StatsDFlushInterval.Seconds()))
+	}
+
+	cfg.StatsDFlushInterval = getEnvInt(
+		"STATSD_FLUSH_INTERVAL",
+		*statsdFlushInterval,
+		fileStatsDFlushInterval,
+		defaultStatsDFlushInterval,
+	)
+
+	if cfg.StatsDFlushInterval <= 0 {
+		panic("statsd flush interval must be positive")
+	}
+
 	cfg.API.HashKey = getEnvString("KEY", *hashKey, nil, "")
 
+	rawTrustedSubnet := getEnvString(
+		"TRUSTED_SUBNET",
+		*trustedSubnet,
+		fileConf.TrustedSubnet,
+		"",
+	)
+
+	if rawTrustedSubnet != "" {
+		_, cfg.API.TrustedSubnet, err = net.ParseCIDR(rawTrustedSubnet)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	cfg.ServiceName = defaultServiceName
 	cfg.LogLevel = defaultLogLevel
 
 	return cfg
 }
 
+// RetryPolicy returns policy of connecting to database.
+func (cfg Config) RetryPolicy() retry.Policy {
+	return retry.Policy{
+		MaxAttempts: cfg.RetryMaxAttempts,
+		BaseDelay:   time.Duration(cfg.RetryBaseDelay) * time.Millisecond,
+		MaxDelay:    time.Duration(cfg.RetryMaxDelay) * time.Millisecond,
+	}
+}
+
 func getEnvString(
 	key string,
 	flagValue string,
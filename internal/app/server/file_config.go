@@ -9,11 +9,24 @@ import (
 
 type FileConfig struct {
 	ServerAddress   *string        `json:"address"`
+	GRPCAddress     *string        `json:"grpc_address"`
 	StoreInterval   *time.Duration `json:"store_interval"`
 	FileStoragePath *string        `json:"store_file"`
 	Restore         *bool          `json:"restore"`
 	DatabaseDSN     *string        `json:"database_dsn"`
 	PrivateKeyPath  *string        `json:"crypto_key"`
+	TrustedSubnet   *string        `json:"trusted_subnet"`
+
+	StatsDAddress       *string        `json:"statsd_address"`
+	StatsDFlushInterval *time.Duration `json:"statsd_flush_interval"`
+
+	DisableMigrations *bool `json:"disable_migrations"`
+
+	RetryMaxAttempts *int           `json:"retry_max_attempts"`
+	RetryBaseDelay   *time.Duration `json:"retry_base_delay"`
+	RetryMaxDelay    *time.Duration `json:"retry_max_delay"`
+
+	WALPath *string `json:"wal_file"`
 }
 
 func LoadFileConfig(configPath string) (FileConfig, error) {
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+	"github.com/Imomali1/metrics/internal/pkg/storage"
+)
+
+var ErrInvalidMigrateCommand = errors.New("invalid migrate command")
+
+type migrateCommand struct {
+	action string
+	steps  int
+}
+
+// parseMigrateArgs parses arguments of migrate subcommand:
+// up (default), down [N] and status.
+func parseMigrateArgs(args []string) (migrateCommand, error) {
+	if len(args) == 0 {
+		return migrateCommand{action: "up"}, nil
+	}
+
+	cmd := migrateCommand{action: args[0]}
+	switch cmd.action {
+	case "up", "status":
+		if len(args) > 1 {
+			return migrateCommand{}, fmt.Errorf("%w: unexpected arguments %v", ErrInvalidMigrateCommand, args[1:])
+		}
+	case "down":
+		cmd.steps = 1
+		if len(args) > 2 {
+			return migrateCommand{}, fmt.Errorf("%w: unexpected arguments %v", ErrInvalidMigrateCommand, args[2:])
+		}
+		if len(args) == 2 {
+			steps, err := strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return migrateCommand{}, fmt.Errorf("%w: invalid steps %q", ErrInvalidMigrateCommand, args[1])
+			}
+			cmd.steps = steps
+		}
+	default:
+		return migrateCommand{}, fmt.Errorf("%w: %q", ErrInvalidMigrateCommand, cmd.action)
+	}
+
+	return cmd, nil
+}
+
+// Migrate runs migrate subcommand against cfg.DatabaseDSN database.
+func Migrate(cfg Config, log logger.Logger, args []string) error {
+	cmd, err := parseMigrateArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if cfg.DatabaseDSN == "" {
+		return errors.New("database dsn is required for migrations")
+	}
+
+	if strings.HasPrefix(cfg.DatabaseDSN, storage.BoltScheme) {
+		return errors.New("migrations are applied to postgresql only")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.DatabaseDSN)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer pool.Close()
+
+	switch cmd.action {
+	case "up":
+		err = storage.MigrateUp(ctx, pool)
+	case "down":
+		err = storage.MigrateDown(ctx, pool, cmd.steps)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate %s: %w", cmd.action, err)
+	}
+
+	if cmd.action == "status" {
+		return logMigrationsStatus(ctx, pool, log)
+	}
+
+	version, err := storage.SchemaVersion(ctx, pool)
+	if err != nil {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	log.Info().Int64("version", version).Msg("schema version")
+	return nil
+}
+
+// logMigrationsStatus logs every migration as applied or pending.
+func logMigrationsStatus(ctx context.Context, pool *pgxpool.Pool, log logger.Logger) error {
+	list, err := storage.MigrationsStatus(ctx, pool)
+	if err != nil {
+		return fmt.Errorf("failed to get migrations status: %w", err)
+	}
+
+	var pending int
+	for _, m := range list {
+		if !m.Applied() {
+			pending++
+			log.Info().Int64("version", m.Version).Str("name", m.Name).Msg("pending migration")
+			continue
+		}
+		log.Info().Int64("version", m.Version).Str("name", m.Name).
+			Time("applied_at", m.AppliedAt).Msg("applied migration")
+	}
+
+	log.Info().Int("applied", len(list)-pending).Int("pending", pending).Msg("migrations status")
+	return nil
+}
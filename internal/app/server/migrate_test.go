@@ -0,0 +1,38 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseMigrateArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    migrateCommand
+		wantErr bool
+	}{
+		{name: "default up", args: nil, want: migrateCommand{action: "up"}},
+		{name: "up", args: []string{"up"}, want: migrateCommand{action: "up"}},
+		{name: "status", args: []string{"status"}, want: migrateCommand{action: "status"}},
+		{name: "down default", args: []string{"down"}, want: migrateCommand{action: "down", steps: 1}},
+		{name: "down n", args: []string{"down", "3"}, want: migrateCommand{action: "down", steps: 3}},
+		{name: "down zero", args: []string{"down", "0"}, wantErr: true},
+		{name: "down garbage", args: []string{"down", "x"}, wantErr: true},
+		{name: "up extra", args: []string{"up", "1"}, wantErr: true},
+		{name: "unknown", args: []string{"redo"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMigrateArgs(tt.args)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidMigrateCommand)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
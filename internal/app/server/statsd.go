@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+	"github.com/Imomali1/metrics/internal/pkg/statsd"
+)
+
+// maxDatagramSize is the largest UDP payload.
+const maxDatagramSize = 65535
+
+// serveStatsD reads StatsD datagrams from conn and saves aggregated metrics
+// every interval until ctx is done. Remaining metrics are saved on return.
+func serveStatsD(
+	ctx context.Context,
+	conn net.PacketConn,
+	updater statsd.Updater,
+	interval time.Duration,
+	log logger.Logger,
+) {
+	aggregator := statsd.NewAggregator()
+
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				// connection is closed on shutdown, other errors,
+				// e.g. ICMP unreachable, affect only single datagram
+				if errors.Is(err, net.ErrClosed) || ctx.Err() != nil {
+					return
+				}
+				log.Error().Err(err).Msg("failed to read statsd datagram")
+				continue
+			}
+
+			for _, line := range strings.Split(string(buf[:n]), "\n") {
+				line = strings.TrimSpace(line)
+				if line == "" {
+					continue
+				}
+
+				sample, err := statsd.Parse(line)
+				if err != nil {
+					log.Warn().Err(err).Msg("skipping statsd line")
+					continue
+				}
+
+				aggregator.Add(sample)
+			}
+		}
+	}()
+
+	flush := func(ctx context.Context) {
+		metrics, err := aggregator.Flush(ctx, updater)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to aggregate statsd metrics")
+			return
+		}
+
+		if len(metrics) == 0 {
+			return
+		}
+
+		if err = updater.UpdateMetrics(ctx, metrics); err != nil {
+			log.Error().Err(err).Msg("failed to update statsd metrics")
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			flush(ctx)
+		case <-done:
+			flush(context.Background())
+			return
+		}
+	}
+}
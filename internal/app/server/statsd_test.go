@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+)
+
+type recordingUpdater struct {
+	mu      sync.Mutex
+	updates entity.MetricsList
+}
+
+func (r *recordingUpdater) UpdateMetrics(_ context.Context, list entity.MetricsList) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updates = append(r.updates, list...)
+	return nil
+}
+
+func (r *recordingUpdater) GetMetrics(context.Context, entity.Metrics) (entity.Metrics, error) {
+	return entity.Metrics{}, entity.ErrMetricNotFound
+}
+
+// flakyConn fails the first read like socket reporting ICMP error.
+type flakyConn struct {
+	net.PacketConn
+	failed bool
+}
+
+func (c *flakyConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	if !c.failed {
+		c.failed = true
+		return 0, nil, errors.New("connection refused")
+	}
+	return c.PacketConn.ReadFrom(p)
+}
+
+func Test_serveStatsD(t *testing.T) {
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	// reader keeps going after failed read
+	conn := &flakyConn{PacketConn: udpConn}
+
+	updater := &recordingUpdater{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serveStatsD(ctx, conn, updater, time.Hour, logger.NewLogger(os.Stdout, "info", "test"))
+	}()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Write([]byte("requests:2|c\nrequests:3|c\ninvalid\n"))
+	require.NoError(t, err)
+
+	// give the reader time to consume the datagram, it is flushed on shutdown
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	delta := int64(5)
+	assert.Equal(t, entity.MetricsList{
+		{ID: "requests", MType: entity.Counter, Delta: &delta},
+	}, updater.updates)
+}
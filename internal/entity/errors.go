@@ -7,4 +7,10 @@ import (
 var (
 	ErrMetricNotFound    = errors.New("metric not found")
 	ErrInvalidMetricType = errors.New("invalid metric type")
+	ErrInvalidLabels     = errors.New("invalid metric labels")
+	ErrInvalidName       = errors.New("invalid metric name")
+
+	ErrInvalidHistogram         = errors.New("invalid histogram")
+	ErrHistogramBucketsMismatch = errors.New("histogram buckets mismatch")
+	ErrInvalidSummary           = errors.New("invalid summary")
 )
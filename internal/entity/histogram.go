@@ -0,0 +1,100 @@
+package entity
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// NewHistogram returns empty histogram with buckets of given upper bounds.
+func NewHistogram(bounds []float64) *HistogramValue {
+	buckets := make([]Bucket, len(bounds))
+	for i, bound := range bounds {
+		buckets[i].UpperBound = bound
+	}
+	return &HistogramValue{Buckets: buckets}
+}
+
+// Validate checks that upper bounds are finite and strictly increasing
+// and that cumulative counts do not decrease and do not exceed Count.
+func (h *HistogramValue) Validate() error {
+	if h == nil {
+		return fmt.Errorf("%w: missing value", ErrInvalidHistogram)
+	}
+
+	if math.IsNaN(h.Sum) || math.IsInf(h.Sum, 0) {
+		return fmt.Errorf("%w: sum is not finite", ErrInvalidHistogram)
+	}
+
+	for i, bucket := range h.Buckets {
+		if math.IsNaN(bucket.UpperBound) || math.IsInf(bucket.UpperBound, 0) {
+			return fmt.Errorf("%w: upper bound is not finite", ErrInvalidHistogram)
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		prev := h.Buckets[i-1]
+		if bucket.UpperBound <= prev.UpperBound {
+			return fmt.Errorf("%w: upper bounds are not increasing", ErrInvalidHistogram)
+		}
+		if bucket.Count < prev.Count {
+			return fmt.Errorf("%w: bucket counts are not cumulative", ErrInvalidHistogram)
+		}
+	}
+
+	if n := len(h.Buckets); n > 0 && h.Buckets[n-1].Count > h.Count {
+		return fmt.Errorf("%w: bucket count exceeds total count", ErrInvalidHistogram)
+	}
+
+	return nil
+}
+
+// Observe adds single observation to histogram.
+func (h *HistogramValue) Observe(value float64) {
+	// buckets are sorted, observation falls into all buckets starting from i
+	i := sort.Search(len(h.Buckets), func(i int) bool {
+		return value <= h.Buckets[i].UpperBound
+	})
+	for ; i < len(h.Buckets); i++ {
+		h.Buckets[i].Count++
+	}
+
+	h.Sum += value
+	h.Count++
+}
+
+// Merge returns histogram holding observations of both histograms,
+// histograms must have the same upper bounds.
+func (h *HistogramValue) Merge(other *HistogramValue) (*HistogramValue, error) {
+	if len(h.Buckets) != len(other.Buckets) {
+		return nil, ErrHistogramBucketsMismatch
+	}
+
+	merged := h.Clone()
+	for i, bucket := range other.Buckets {
+		if merged.Buckets[i].UpperBound != bucket.UpperBound {
+			return nil, ErrHistogramBucketsMismatch
+		}
+		merged.Buckets[i].Count += bucket.Count
+	}
+
+	merged.Sum += other.Sum
+	merged.Count += other.Count
+
+	return merged, nil
+}
+
+// Clone returns deep copy of histogram, nil is cloned to nil.
+func (h *HistogramValue) Clone() *HistogramValue {
+	if h == nil {
+		return nil
+	}
+
+	clone := *h
+	if h.Buckets != nil {
+		clone.Buckets = append(make([]Bucket, 0, len(h.Buckets)), h.Buckets...)
+	}
+	return &clone
+}
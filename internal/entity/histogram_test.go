@@ -0,0 +1,97 @@
+package entity
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogramValue_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		histogram *HistogramValue
+		wantErr   bool
+	}{
+		{
+			name:      "valid",
+			histogram: &HistogramValue{Buckets: []Bucket{{0.1, 1}, {1, 3}}, Sum: 2, Count: 4},
+		},
+		{
+			name:      "without buckets",
+			histogram: &HistogramValue{Sum: 2, Count: 4},
+		},
+		{
+			name:    "missing value",
+			wantErr: true,
+		},
+		{
+			name:      "upper bounds are not increasing",
+			histogram: &HistogramValue{Buckets: []Bucket{{1, 1}, {1, 1}}, Count: 1},
+			wantErr:   true,
+		},
+		{
+			name:      "infinite upper bound",
+			histogram: &HistogramValue{Buckets: []Bucket{{math.Inf(1), 1}}, Count: 1},
+			wantErr:   true,
+		},
+		{
+			name:      "counts are not cumulative",
+			histogram: &HistogramValue{Buckets: []Bucket{{0.1, 2}, {1, 1}}, Count: 2},
+			wantErr:   true,
+		},
+		{
+			name:      "bucket count exceeds total count",
+			histogram: &HistogramValue{Buckets: []Bucket{{0.1, 2}}, Count: 1},
+			wantErr:   true,
+		},
+		{
+			name:      "sum is not finite",
+			histogram: &HistogramValue{Sum: math.NaN()},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.histogram.Validate()
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidHistogram)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestHistogramValue_Observe(t *testing.T) {
+	h := NewHistogram([]float64{0.1, 1})
+	for _, value := range []float64{0.05, 0.1, 0.5, 3} {
+		h.Observe(value)
+	}
+
+	require.Equal(t, &HistogramValue{
+		Buckets: []Bucket{{0.1, 2}, {1, 3}},
+		Sum:     3.65,
+		Count:   4,
+	}, h)
+	require.NoError(t, h.Validate())
+}
+
+func TestHistogramValue_Merge(t *testing.T) {
+	a := &HistogramValue{Buckets: []Bucket{{0.1, 1}, {1, 2}}, Sum: 1, Count: 2}
+	b := &HistogramValue{Buckets: []Bucket{{0.1, 0}, {1, 1}}, Sum: 0.5, Count: 3}
+
+	merged, err := a.Merge(b)
+	require.NoError(t, err)
+	require.Equal(t, &HistogramValue{Buckets: []Bucket{{0.1, 1}, {1, 3}}, Sum: 1.5, Count: 5}, merged)
+
+	// operands are not modified
+	require.Equal(t, uint64(2), a.Count)
+	require.Equal(t, uint64(2), a.Buckets[1].Count)
+
+	_, err = a.Merge(&HistogramValue{Buckets: []Bucket{{0.2, 1}, {1, 1}}, Count: 1})
+	require.ErrorIs(t, err, ErrHistogramBucketsMismatch)
+
+	_, err = a.Merge(&HistogramValue{Buckets: []Bucket{{0.1, 1}}, Count: 1})
+	require.ErrorIs(t, err, ErrHistogramBucketsMismatch)
+}
@@ -0,0 +1,95 @@
+package entity
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Labels is a set of metrics dimensions, e.g. host or service.
+// Metrics with the same name but different labels are different series.
+type Labels map[string]string
+
+// String returns canonical representation of labels
+// sorted by name, e.g. host="a",service="b".
+func (l Labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(name)
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(l[name]))
+	}
+
+	return sb.String()
+}
+
+// Validate checks that every label name matches [a-zA-Z_][a-zA-Z0-9_]*.
+func (l Labels) Validate() error {
+	for name := range l {
+		if !isValidLabelName(name) {
+			return fmt.Errorf("%w: label name %q", ErrInvalidLabels, name)
+		}
+	}
+	return nil
+}
+
+// Clone returns copy of labels, nil and empty labels are cloned to nil.
+func (l Labels) Clone() Labels {
+	if len(l) == 0 {
+		return nil
+	}
+
+	clone := make(Labels, len(l))
+	for name, value := range l {
+		clone[name] = value
+	}
+	return clone
+}
+
+// ValidateName checks that metrics name can be part of series key,
+// braces are reserved for labels, see SeriesKey.
+func ValidateName(id string) error {
+	if strings.ContainsAny(id, "{}") {
+		return fmt.Errorf("%w: %q contains braces", ErrInvalidName, id)
+	}
+	return nil
+}
+
+// SeriesKey returns identity of metrics series, metrics
+// without labels are identified only by their name.
+func SeriesKey(id string, labels Labels) string {
+	if len(labels) == 0 {
+		return id
+	}
+	return id + "{" + labels.String() + "}"
+}
+
+func isValidLabelName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
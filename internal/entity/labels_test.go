@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		labels Labels
+		want   string
+	}{
+		{
+			name: "without labels",
+			id:   "Alloc",
+			want: "Alloc",
+		},
+		{
+			name:   "empty labels",
+			id:     "Alloc",
+			labels: Labels{},
+			want:   "Alloc",
+		},
+		{
+			name:   "labels are sorted and quoted",
+			id:     "Alloc",
+			labels: Labels{"service": `a"b`, "host": "x,y=z"},
+			want:   `Alloc{host="x,y=z",service="a\"b"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, SeriesKey(tt.id, tt.labels))
+		})
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	require.NoError(t, ValidateName("Alloc"))
+	require.NoError(t, ValidateName("http.requests:total"))
+
+	// name with braces would share series key with labeled series
+	require.ErrorIs(t, ValidateName(`Alloc{host="a"}`), ErrInvalidName)
+	require.ErrorIs(t, ValidateName("Alloc}"), ErrInvalidName)
+
+	require.ErrorIs(t, Metrics{ID: "Alloc", Labels: Labels{"1a": "b"}}.ValidateSeries(), ErrInvalidLabels)
+	require.ErrorIs(t, Metrics{ID: "Alloc{"}.ValidateSeries(), ErrInvalidName)
+
+	// le label of histogram would clash with bucket bounds in exposition
+	require.ErrorIs(t, Metrics{ID: "latency", MType: Histogram, Labels: Labels{"le": "1"}}.ValidateSeries(), ErrInvalidLabels)
+	require.NoError(t, Metrics{ID: "Alloc", MType: Gauge, Labels: Labels{"le": "1"}}.ValidateSeries())
+	require.ErrorIs(t, Metrics{ID: "latency", MType: Summary, Labels: Labels{"quantile": "0.5"}}.ValidateSeries(), ErrInvalidLabels)
+}
+
+func TestLabels_Validate(t *testing.T) {
+	require.NoError(t, Labels(nil).Validate())
+	require.NoError(t, Labels{"host": "a", "_service1": ""}.Validate())
+	require.ErrorIs(t, Labels{"": "a"}.Validate(), ErrInvalidLabels)
+	require.ErrorIs(t, Labels{"1host": "a"}.Validate(), ErrInvalidLabels)
+	require.ErrorIs(t, Labels{"host-name": "a"}.Validate(), ErrInvalidLabels)
+}
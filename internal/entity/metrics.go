@@ -1,15 +1,78 @@
 //go:generate easyjson -no_std_marshalers metrics.go
 package entity
 
+import (
+	"fmt"
+	"time"
+)
+
 //easyjson:json
 type MetricsList []Metrics
 
 //easyjson:json
 type Metrics struct {
-	ID    string   `json:"id"`              // имя метрики
-	MType string   `json:"type"`            // параметр, принимающий значение gauge или counter
-	Delta *int64   `json:"delta,omitempty"` // значение метрики в случае передачи counter
-	Value *float64 `json:"value,omitempty"` // значение метрики в случае передачи gauge
+	ID        string          `json:"id"`                  // имя метрики
+	MType     string          `json:"type"`                // параметр, принимающий значение gauge, counter, histogram или summary
+	Delta     *int64          `json:"delta,omitempty"`     // значение метрики в случае передачи counter
+	Value     *float64        `json:"value,omitempty"`     // значение метрики в случае передачи gauge
+	Histogram *HistogramValue `json:"histogram,omitempty"` // значение метрики в случае передачи histogram
+	Summary   *SummaryValue   `json:"summary,omitempty"`   // значение метрики в случае передачи summary
+	Labels    Labels          `json:"labels,omitempty"`    // необязательный набор меток, например host или service
+}
+
+// Key returns identity of metrics series built from name and labels.
+func (m Metrics) Key() string {
+	return SeriesKey(m.ID, m.Labels)
+}
+
+// BucketLabel is label of histogram bucket upper bound and QuantileLabel
+// is label of summary quantile in Prometheus exposition, histograms and
+// summaries cannot have their own label with corresponding name.
+const (
+	BucketLabel   = "le"
+	QuantileLabel = "quantile"
+)
+
+// ValidateSeries checks name and labels of metrics, see ValidateName.
+func (m Metrics) ValidateSeries() error {
+	if err := ValidateName(m.ID); err != nil {
+		return err
+	}
+	if _, ok := m.Labels[BucketLabel]; ok && m.MType == Histogram {
+		return fmt.Errorf("%w: label name %q is reserved for histogram buckets", ErrInvalidLabels, BucketLabel)
+	}
+	if _, ok := m.Labels[QuantileLabel]; ok && m.MType == Summary {
+		return fmt.Errorf("%w: label name %q is reserved for summary quantiles", ErrInvalidLabels, QuantileLabel)
+	}
+	return m.Labels.Validate()
+}
+
+//easyjson:json
+type HistogramValue struct {
+	Buckets []Bucket `json:"buckets"` // корзины в порядке возрастания верхних границ
+	Sum     float64  `json:"sum"`     // сумма наблюдаемых значений
+	Count   uint64   `json:"count"`   // количество наблюдений, соответствует корзине +Inf
+}
+
+// Bucket holds cumulative number of observations
+// less than or equal to UpperBound, like in Prometheus.
+type Bucket struct {
+	UpperBound float64 `json:"le"`    // верхняя граница корзины
+	Count      uint64  `json:"count"` // количество наблюдений не больше верхней границы
+}
+
+//easyjson:json
+type SummaryValue struct {
+	Quantiles []Quantile `json:"quantiles"` // квантили в порядке возрастания рангов
+	Sum       float64    `json:"sum"`       // сумма наблюдаемых значений
+	Count     uint64     `json:"count"`     // количество наблюдений
+}
+
+// Quantile holds value of φ-quantile of observations
+// calculated by client over its sliding window.
+type Quantile struct {
+	Quantile float64 `json:"quantile"` // ранг квантиля от 0 до 1
+	Value    float64 `json:"value"`    // значение квантиля
 }
 
 //easyjson:json
@@ -23,8 +86,27 @@ type MetricsWithoutPointer struct {
 	Value float64
 }
 
+//easyjson:json
+type SampleList []Sample
+
+//easyjson:json
+type Sample struct {
+	Timestamp time.Time       `json:"timestamp"`           // время получения значения сервером
+	Delta     *int64          `json:"delta,omitempty"`     // накопленное значение counter на момент времени
+	Value     *float64        `json:"value,omitempty"`     // значение gauge на момент времени
+	Histogram *HistogramValue `json:"histogram,omitempty"` // накопленное значение histogram на момент времени
+	Summary   *SummaryValue   `json:"summary,omitempty"`   // накопленное значение summary на момент времени
+}
+
 const (
-	// Gauge Counter are metric types
-	Gauge   = "gauge"
-	Counter = "counter"
+	// Gauge Counter Histogram Summary are metric types
+	Gauge     = "gauge"
+	Counter   = "counter"
+	Histogram = "histogram"
+	Summary   = "summary"
 )
+
+// IsValidType reports whether mType is one of metric types.
+func IsValidType(mType string) bool {
+	return mType == Gauge || mType == Counter || mType == Histogram || mType == Summary
+}
@@ -18,7 +18,154 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity(in *jlexer.Lexer, out *MetricsWithoutPointerList) {
+func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity(in *jlexer.Lexer, out *SummaryValue) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "quantiles":
+			if in.IsNull() {
+				in.Skip()
+				out.Quantiles = nil
+			} else {
+				in.Delim('[')
+				if out.Quantiles == nil {
+					if !in.IsDelim(']') {
+						out.Quantiles = make([]Quantile, 0, 4)
+					} else {
+						out.Quantiles = []Quantile{}
+					}
+				} else {
+					out.Quantiles = (out.Quantiles)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 Quantile
+					easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity1(in, &v1)
+					out.Quantiles = append(out.Quantiles, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "sum":
+			out.Sum = float64(in.Float64())
+		case "count":
+			out.Count = uint64(in.Uint64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity(out *jwriter.Writer, in SummaryValue) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"quantiles\":"
+		out.RawString(prefix[1:])
+		if in.Quantiles == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.Quantiles {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity1(out, v3)
+			}
+			out.RawByte(']')
+		}
+	}
+	{
+		const prefix string = ",\"sum\":"
+		out.RawString(prefix)
+		out.Float64(float64(in.Sum))
+	}
+	{
+		const prefix string = ",\"count\":"
+		out.RawString(prefix)
+		out.Uint64(uint64(in.Count))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v SummaryValue) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *SummaryValue) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity(l, v)
+}
+func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity1(in *jlexer.Lexer, out *Quantile) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "quantile":
+			out.Quantile = float64(in.Float64())
+		case "value":
+			out.Value = float64(in.Float64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity1(out *jwriter.Writer, in Quantile) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"quantile\":"
+		out.RawString(prefix[1:])
+		out.Float64(float64(in.Quantile))
+	}
+	{
+		const prefix string = ",\"value\":"
+		out.RawString(prefix)
+		out.Float64(float64(in.Value))
+	}
+	out.RawByte('}')
+}
+func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity2(in *jlexer.Lexer, out *SampleList) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		in.Skip()
@@ -27,17 +174,17 @@ func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity(in *jlexer.Lex
 		in.Delim('[')
 		if *out == nil {
 			if !in.IsDelim(']') {
-				*out = make(MetricsWithoutPointerList, 0, 1)
+				*out = make(SampleList, 0, 1)
 			} else {
-				*out = MetricsWithoutPointerList{}
+				*out = SampleList{}
 			}
 		} else {
 			*out = (*out)[:0]
 		}
 		for !in.IsDelim(']') {
-			var v1 MetricsWithoutPointer
-			(v1).UnmarshalEasyJSON(in)
-			*out = append(*out, v1)
+			var v4 Sample
+			(v4).UnmarshalEasyJSON(in)
+			*out = append(*out, v4)
 			in.WantComma()
 		}
 		in.Delim(']')
@@ -46,45 +193,197 @@ func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity(in *jlexer.Lex
 		in.Consumed()
 	}
 }
-func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity(out *jwriter.Writer, in MetricsWithoutPointerList) {
+func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity2(out *jwriter.Writer, in SampleList) {
 	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
 		out.RawString("null")
 	} else {
 		out.RawByte('[')
-		for v2, v3 := range in {
-			if v2 > 0 {
+		for v5, v6 := range in {
+			if v5 > 0 {
 				out.RawByte(',')
 			}
-			(v3).MarshalEasyJSON(out)
+			(v6).MarshalEasyJSON(out)
 		}
 		out.RawByte(']')
 	}
 }
 
-// MarshalJSON supports json.Marshaler interface
-func (v MetricsWithoutPointerList) MarshalJSON() ([]byte, error) {
-	w := jwriter.Writer{}
-	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity(&w, v)
-	return w.Buffer.BuildBytes(), w.Error
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v SampleList) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity2(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *SampleList) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity2(l, v)
+}
+func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity3(in *jlexer.Lexer, out *Sample) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "timestamp":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Timestamp).UnmarshalJSON(data))
+			}
+		case "delta":
+			if in.IsNull() {
+				in.Skip()
+				out.Delta = nil
+			} else {
+				if out.Delta == nil {
+					out.Delta = new(int64)
+				}
+				*out.Delta = int64(in.Int64())
+			}
+		case "value":
+			if in.IsNull() {
+				in.Skip()
+				out.Value = nil
+			} else {
+				if out.Value == nil {
+					out.Value = new(float64)
+				}
+				*out.Value = float64(in.Float64())
+			}
+		case "histogram":
+			if in.IsNull() {
+				in.Skip()
+				out.Histogram = nil
+			} else {
+				if out.Histogram == nil {
+					out.Histogram = new(HistogramValue)
+				}
+				(*out.Histogram).UnmarshalEasyJSON(in)
+			}
+		case "summary":
+			if in.IsNull() {
+				in.Skip()
+				out.Summary = nil
+			} else {
+				if out.Summary == nil {
+					out.Summary = new(SummaryValue)
+				}
+				(*out.Summary).UnmarshalEasyJSON(in)
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity3(out *jwriter.Writer, in Sample) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"timestamp\":"
+		out.RawString(prefix[1:])
+		out.Raw((in.Timestamp).MarshalJSON())
+	}
+	if in.Delta != nil {
+		const prefix string = ",\"delta\":"
+		out.RawString(prefix)
+		out.Int64(int64(*in.Delta))
+	}
+	if in.Value != nil {
+		const prefix string = ",\"value\":"
+		out.RawString(prefix)
+		out.Float64(float64(*in.Value))
+	}
+	if in.Histogram != nil {
+		const prefix string = ",\"histogram\":"
+		out.RawString(prefix)
+		(*in.Histogram).MarshalEasyJSON(out)
+	}
+	if in.Summary != nil {
+		const prefix string = ",\"summary\":"
+		out.RawString(prefix)
+		(*in.Summary).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
-func (v MetricsWithoutPointerList) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity(w, v)
+func (v Sample) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity3(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Sample) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity3(l, v)
+}
+func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity4(in *jlexer.Lexer, out *MetricsWithoutPointerList) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*out = nil
+	} else {
+		in.Delim('[')
+		if *out == nil {
+			if !in.IsDelim(']') {
+				*out = make(MetricsWithoutPointerList, 0, 1)
+			} else {
+				*out = MetricsWithoutPointerList{}
+			}
+		} else {
+			*out = (*out)[:0]
+		}
+		for !in.IsDelim(']') {
+			var v7 MetricsWithoutPointer
+			(v7).UnmarshalEasyJSON(in)
+			*out = append(*out, v7)
+			in.WantComma()
+		}
+		in.Delim(']')
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity4(out *jwriter.Writer, in MetricsWithoutPointerList) {
+	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v8, v9 := range in {
+			if v8 > 0 {
+				out.RawByte(',')
+			}
+			(v9).MarshalEasyJSON(out)
+		}
+		out.RawByte(']')
+	}
 }
 
-// UnmarshalJSON supports json.Unmarshaler interface
-func (v *MetricsWithoutPointerList) UnmarshalJSON(data []byte) error {
-	r := jlexer.Lexer{Data: data}
-	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity(&r, v)
-	return r.Error()
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v MetricsWithoutPointerList) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity4(w, v)
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *MetricsWithoutPointerList) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity(l, v)
+	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity4(l, v)
 }
-func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity1(in *jlexer.Lexer, out *MetricsWithoutPointer) {
+func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity5(in *jlexer.Lexer, out *MetricsWithoutPointer) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -121,7 +420,7 @@ func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity1(in *jlexer.Le
 		in.Consumed()
 	}
 }
-func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity1(out *jwriter.Writer, in MetricsWithoutPointer) {
+func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity5(out *jwriter.Writer, in MetricsWithoutPointer) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -148,30 +447,16 @@ func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity1(out *jwriter.
 	out.RawByte('}')
 }
 
-// MarshalJSON supports json.Marshaler interface
-func (v MetricsWithoutPointer) MarshalJSON() ([]byte, error) {
-	w := jwriter.Writer{}
-	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity1(&w, v)
-	return w.Buffer.BuildBytes(), w.Error
-}
-
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v MetricsWithoutPointer) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity1(w, v)
-}
-
-// UnmarshalJSON supports json.Unmarshaler interface
-func (v *MetricsWithoutPointer) UnmarshalJSON(data []byte) error {
-	r := jlexer.Lexer{Data: data}
-	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity1(&r, v)
-	return r.Error()
+	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity5(w, v)
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *MetricsWithoutPointer) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity1(l, v)
+	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity5(l, v)
 }
-func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity2(in *jlexer.Lexer, out *MetricsList) {
+func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity6(in *jlexer.Lexer, out *MetricsList) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		in.Skip()
@@ -180,7 +465,7 @@ func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity2(in *jlexer.Le
 		in.Delim('[')
 		if *out == nil {
 			if !in.IsDelim(']') {
-				*out = make(MetricsList, 0, 1)
+				*out = make(MetricsList, 0, 0)
 			} else {
 				*out = MetricsList{}
 			}
@@ -188,9 +473,9 @@ func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity2(in *jlexer.Le
 			*out = (*out)[:0]
 		}
 		for !in.IsDelim(']') {
-			var v4 Metrics
-			(v4).UnmarshalEasyJSON(in)
-			*out = append(*out, v4)
+			var v10 Metrics
+			(v10).UnmarshalEasyJSON(in)
+			*out = append(*out, v10)
 			in.WantComma()
 		}
 		in.Delim(']')
@@ -199,45 +484,31 @@ func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity2(in *jlexer.Le
 		in.Consumed()
 	}
 }
-func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity2(out *jwriter.Writer, in MetricsList) {
+func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity6(out *jwriter.Writer, in MetricsList) {
 	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
 		out.RawString("null")
 	} else {
 		out.RawByte('[')
-		for v5, v6 := range in {
-			if v5 > 0 {
+		for v11, v12 := range in {
+			if v11 > 0 {
 				out.RawByte(',')
 			}
-			(v6).MarshalEasyJSON(out)
+			(v12).MarshalEasyJSON(out)
 		}
 		out.RawByte(']')
 	}
 }
 
-// MarshalJSON supports json.Marshaler interface
-func (v MetricsList) MarshalJSON() ([]byte, error) {
-	w := jwriter.Writer{}
-	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity2(&w, v)
-	return w.Buffer.BuildBytes(), w.Error
-}
-
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v MetricsList) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity2(w, v)
-}
-
-// UnmarshalJSON supports json.Unmarshaler interface
-func (v *MetricsList) UnmarshalJSON(data []byte) error {
-	r := jlexer.Lexer{Data: data}
-	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity2(&r, v)
-	return r.Error()
+	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity6(w, v)
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *MetricsList) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity2(l, v)
+	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity6(l, v)
 }
-func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity3(in *jlexer.Lexer, out *Metrics) {
+func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity7(in *jlexer.Lexer, out *Metrics) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -280,6 +551,46 @@ func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity3(in *jlexer.Le
 				}
 				*out.Value = float64(in.Float64())
 			}
+		case "histogram":
+			if in.IsNull() {
+				in.Skip()
+				out.Histogram = nil
+			} else {
+				if out.Histogram == nil {
+					out.Histogram = new(HistogramValue)
+				}
+				(*out.Histogram).UnmarshalEasyJSON(in)
+			}
+		case "summary":
+			if in.IsNull() {
+				in.Skip()
+				out.Summary = nil
+			} else {
+				if out.Summary == nil {
+					out.Summary = new(SummaryValue)
+				}
+				(*out.Summary).UnmarshalEasyJSON(in)
+			}
+		case "labels":
+			if in.IsNull() {
+				in.Skip()
+			} else {
+				in.Delim('{')
+				if !in.IsDelim('}') {
+					out.Labels = make(Labels)
+				} else {
+					out.Labels = nil
+				}
+				for !in.IsDelim('}') {
+					key := string(in.String())
+					in.WantColon()
+					var v13 string
+					v13 = string(in.String())
+					(out.Labels)[key] = v13
+					in.WantComma()
+				}
+				in.Delim('}')
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -290,7 +601,7 @@ func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity3(in *jlexer.Le
 		in.Consumed()
 	}
 }
-func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity3(out *jwriter.Writer, in Metrics) {
+func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity7(out *jwriter.Writer, in Metrics) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -314,29 +625,191 @@ func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity3(out *jwriter.
 		out.RawString(prefix)
 		out.Float64(float64(*in.Value))
 	}
+	if in.Histogram != nil {
+		const prefix string = ",\"histogram\":"
+		out.RawString(prefix)
+		(*in.Histogram).MarshalEasyJSON(out)
+	}
+	if in.Summary != nil {
+		const prefix string = ",\"summary\":"
+		out.RawString(prefix)
+		(*in.Summary).MarshalEasyJSON(out)
+	}
+	if len(in.Labels) != 0 {
+		const prefix string = ",\"labels\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('{')
+			v14First := true
+			for v14Name, v14Value := range in.Labels {
+				if v14First {
+					v14First = false
+				} else {
+					out.RawByte(',')
+				}
+				out.String(string(v14Name))
+				out.RawByte(':')
+				out.String(string(v14Value))
+			}
+			out.RawByte('}')
+		}
+	}
 	out.RawByte('}')
 }
 
-// MarshalJSON supports json.Marshaler interface
-func (v Metrics) MarshalJSON() ([]byte, error) {
-	w := jwriter.Writer{}
-	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity3(&w, v)
-	return w.Buffer.BuildBytes(), w.Error
-}
-
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Metrics) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity3(w, v)
+	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity7(w, v)
 }
 
-// UnmarshalJSON supports json.Unmarshaler interface
-func (v *Metrics) UnmarshalJSON(data []byte) error {
-	r := jlexer.Lexer{Data: data}
-	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity3(&r, v)
-	return r.Error()
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Metrics) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity7(l, v)
+}
+func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity8(in *jlexer.Lexer, out *HistogramValue) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "buckets":
+			if in.IsNull() {
+				in.Skip()
+				out.Buckets = nil
+			} else {
+				in.Delim('[')
+				if out.Buckets == nil {
+					if !in.IsDelim(']') {
+						out.Buckets = make([]Bucket, 0, 4)
+					} else {
+						out.Buckets = []Bucket{}
+					}
+				} else {
+					out.Buckets = (out.Buckets)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v15 Bucket
+					easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity9(in, &v15)
+					out.Buckets = append(out.Buckets, v15)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "sum":
+			out.Sum = float64(in.Float64())
+		case "count":
+			out.Count = uint64(in.Uint64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity8(out *jwriter.Writer, in HistogramValue) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"buckets\":"
+		out.RawString(prefix[1:])
+		if in.Buckets == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v16, v17 := range in.Buckets {
+				if v16 > 0 {
+					out.RawByte(',')
+				}
+				easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity9(out, v17)
+			}
+			out.RawByte(']')
+		}
+	}
+	{
+		const prefix string = ",\"sum\":"
+		out.RawString(prefix)
+		out.Float64(float64(in.Sum))
+	}
+	{
+		const prefix string = ",\"count\":"
+		out.RawString(prefix)
+		out.Uint64(uint64(in.Count))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v HistogramValue) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity8(w, v)
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *Metrics) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity3(l, v)
+func (v *HistogramValue) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity8(l, v)
+}
+func easyjson2220f231DecodeGithubComImomali1MetricsInternalEntity9(in *jlexer.Lexer, out *Bucket) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "le":
+			out.UpperBound = float64(in.Float64())
+		case "count":
+			out.Count = uint64(in.Uint64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2220f231EncodeGithubComImomali1MetricsInternalEntity9(out *jwriter.Writer, in Bucket) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"le\":"
+		out.RawString(prefix[1:])
+		out.Float64(float64(in.UpperBound))
+	}
+	{
+		const prefix string = ",\"count\":"
+		out.RawString(prefix)
+		out.Uint64(uint64(in.Count))
+	}
+	out.RawByte('}')
 }
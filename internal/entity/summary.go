@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"fmt"
+	"math"
+)
+
+// Validate checks that sum and quantile values are finite
+// and that quantile ranks are strictly increasing within [0, 1].
+func (s *SummaryValue) Validate() error {
+	if s == nil {
+		return fmt.Errorf("%w: missing value", ErrInvalidSummary)
+	}
+
+	if math.IsNaN(s.Sum) || math.IsInf(s.Sum, 0) {
+		return fmt.Errorf("%w: sum is not finite", ErrInvalidSummary)
+	}
+
+	for i, q := range s.Quantiles {
+		if math.IsNaN(q.Quantile) || q.Quantile < 0 || q.Quantile > 1 {
+			return fmt.Errorf("%w: quantile rank is not within [0, 1]", ErrInvalidSummary)
+		}
+		if math.IsNaN(q.Value) || math.IsInf(q.Value, 0) {
+			return fmt.Errorf("%w: quantile value is not finite", ErrInvalidSummary)
+		}
+		if i > 0 && q.Quantile <= s.Quantiles[i-1].Quantile {
+			return fmt.Errorf("%w: quantile ranks are not increasing", ErrInvalidSummary)
+		}
+	}
+
+	return nil
+}
+
+// Merge returns summary holding observations of both summaries. Sum and
+// count are accumulated like counters, quantiles are calculated by client
+// over its sliding window and cannot be combined, so quantiles of other,
+// the latest reported summary, replace stored ones like gauge value.
+func (s *SummaryValue) Merge(other *SummaryValue) *SummaryValue {
+	merged := other.Clone()
+	merged.Sum += s.Sum
+	merged.Count += s.Count
+	return merged
+}
+
+// Clone returns deep copy of summary, nil is cloned to nil.
+func (s *SummaryValue) Clone() *SummaryValue {
+	if s == nil {
+		return nil
+	}
+
+	clone := *s
+	if s.Quantiles != nil {
+		clone.Quantiles = append(make([]Quantile, 0, len(s.Quantiles)), s.Quantiles...)
+	}
+	return &clone
+}
@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryValue_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary *SummaryValue
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			summary: &SummaryValue{Quantiles: []Quantile{{0.5, 0.2}, {0.99, 1.5}}, Sum: 2, Count: 4},
+		},
+		{
+			name:    "without quantiles",
+			summary: &SummaryValue{Sum: 2, Count: 4},
+		},
+		{
+			name:    "missing value",
+			wantErr: true,
+		},
+		{
+			name:    "ranks are not increasing",
+			summary: &SummaryValue{Quantiles: []Quantile{{0.9, 1}, {0.5, 2}}},
+			wantErr: true,
+		},
+		{
+			name:    "rank out of range",
+			summary: &SummaryValue{Quantiles: []Quantile{{1.5, 1}}},
+			wantErr: true,
+		},
+		{
+			name:    "quantile value is not finite",
+			summary: &SummaryValue{Quantiles: []Quantile{{0.5, math.Inf(1)}}},
+			wantErr: true,
+		},
+		{
+			name:    "sum is not finite",
+			summary: &SummaryValue{Sum: math.NaN()},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.summary.Validate()
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidSummary)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSummaryValue_Merge(t *testing.T) {
+	a := &SummaryValue{Quantiles: []Quantile{{0.5, 1}, {0.9, 2}}, Sum: 10, Count: 5}
+	b := &SummaryValue{Quantiles: []Quantile{{0.5, 3}}, Sum: 6, Count: 2}
+
+	// sum and count are accumulated, the latest quantiles win
+	merged := a.Merge(b)
+	require.Equal(t, &SummaryValue{Quantiles: []Quantile{{0.5, 3}}, Sum: 16, Count: 7}, merged)
+
+	// operands are not modified
+	merged.Quantiles[0].Value = 100
+	require.Equal(t, 3.0, b.Quantiles[0].Value)
+	require.Equal(t, uint64(5), a.Count)
+}
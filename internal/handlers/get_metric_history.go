@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+func (h *MetricHandler) GetMetricHistory(ctx *gin.Context) {
+	metricType := ctx.Param("type")
+	if !entity.IsValidType(metricType) {
+		err := errors.New("invalid metric type")
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		h.log.Info().Err(err).Send()
+		return
+	}
+
+	if err := entity.ValidateName(ctx.Param("name")); err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		h.log.Info().Err(err).Send()
+		return
+	}
+
+	labels, err := labelsFromQuery(ctx, "from", "to")
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		h.log.Info().Err(err).Send()
+		return
+	}
+
+	metrics := entity.Metrics{
+		ID:     ctx.Param("name"),
+		MType:  metricType,
+		Labels: labels,
+	}
+
+	from, err := parseTimeQuery(ctx, "from", time.Time{})
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		h.log.Info().Err(err).Msg("invalid from parameter")
+		return
+	}
+
+	to, err := parseTimeQuery(ctx, "to", time.Now())
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		h.log.Info().Err(err).Msg("invalid to parameter")
+		return
+	}
+
+	c, cancel := context.WithTimeout(ctx, _timeout)
+	defer cancel()
+
+	history, err := h.uc.GetHistory(c, metrics, from, to)
+	if err != nil {
+		if errors.Is(err, entity.ErrMetricNotFound) {
+			ctx.AbortWithStatus(http.StatusNotFound)
+			h.log.Info().Err(err).Send()
+			return
+		}
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		h.log.Info().Err(err).Msgf("cannot get %s metric history", metrics.MType)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
+
+// parseTimeQuery parses RFC 3339 timestamp from query parameter,
+// defaultValue is returned when parameter is not set.
+func parseTimeQuery(ctx *gin.Context, key string, defaultValue time.Time) (time.Time, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
@@ -13,7 +13,7 @@ import (
 
 func (h *MetricHandler) GetMetricValueByName(ctx *gin.Context) {
 	metricType := ctx.Param("type")
-	if metricType != entity.Gauge && metricType != entity.Counter {
+	if !entity.IsValidType(metricType) {
 		err := errors.New("invalid metric type")
 		ctx.AbortWithStatus(http.StatusBadRequest)
 		h.log.Info().Err(err).Send()
@@ -21,10 +21,23 @@ func (h *MetricHandler) GetMetricValueByName(ctx *gin.Context) {
 	}
 
 	metricName := ctx.Param("name")
+	if err := entity.ValidateName(metricName); err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		h.log.Info().Err(err).Send()
+		return
+	}
+
+	labels, err := labelsFromQuery(ctx)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		h.log.Info().Err(err).Send()
+		return
+	}
 
 	metrics := entity.Metrics{
-		ID:    metricName,
-		MType: metricType,
+		ID:     metricName,
+		MType:  metricType,
+		Labels: labels,
 	}
 
 	c, cancel := context.WithTimeout(ctx, _timeout)
@@ -48,6 +61,13 @@ func (h *MetricHandler) GetMetricValueByName(ctx *gin.Context) {
 		metricValue = strconv.FormatInt(*result.Delta, 10)
 	case entity.Gauge:
 		metricValue = strconv.FormatFloat(*result.Value, 'f', -1, 64)
+	case entity.Histogram:
+		// histogram has no scalar value, it is rendered as JSON
+		ctx.JSON(http.StatusOK, result.Histogram)
+		return
+	case entity.Summary:
+		ctx.JSON(http.StatusOK, result.Summary)
+		return
 	}
 
 	ctx.String(http.StatusOK, metricValue)
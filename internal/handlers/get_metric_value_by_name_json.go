@@ -35,13 +35,19 @@ func (h *MetricHandler) GetMetricValueByNameJSON(ctx *gin.Context) {
 		return
 	}
 
-	if metrics.MType != entity.Gauge && metrics.MType != entity.Counter {
+	if !entity.IsValidType(metrics.MType) {
 		err = errors.New("invalid metric type")
 		ctx.AbortWithStatus(http.StatusBadRequest)
 		h.log.Info().Err(err).Send()
 		return
 	}
 
+	if err = metrics.ValidateSeries(); err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		h.log.Info().Err(err).Send()
+		return
+	}
+
 	c, cancel := context.WithTimeout(ctx, _timeout)
 	defer cancel()
 
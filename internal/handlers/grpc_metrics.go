@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+	"github.com/Imomali1/metrics/internal/pkg/pb"
+	"github.com/Imomali1/metrics/internal/usecase"
+)
+
+// MetricServer serves gRPC API of metrics server, see pb.MetricsServer.
+type MetricServer struct {
+	pb.UnimplementedMetricsServer
+	log logger.Logger
+	uc  usecase.UseCase
+}
+
+func NewMetricServer(log logger.Logger, uc usecase.UseCase) *MetricServer {
+	return &MetricServer{
+		log: log,
+		uc:  uc,
+	}
+}
+
+func (s *MetricServer) UpdateMetrics(
+	ctx context.Context,
+	req *pb.UpdateMetricsRequest,
+) (*pb.UpdateMetricsResponse, error) {
+	batch, err := s.updateMetrics(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.UpdateMetricsResponse{Metrics: pb.FromEntityList(batch)}, nil
+}
+
+func (s *MetricServer) UpdateMetricsStream(stream grpc.ClientStreamingServer[pb.UpdateMetricsRequest, pb.UpdateMetricsResponse]) error {
+	var updated entity.MetricsList
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(&pb.UpdateMetricsResponse{Metrics: pb.FromEntityList(updated)})
+		}
+
+		if err != nil {
+			s.log.Info().Err(err).Msg("cannot receive batch of metrics")
+			return err
+		}
+
+		batch, err := s.updateMetrics(stream.Context(), req)
+		if err != nil {
+			return err
+		}
+		updated = append(updated, batch...)
+	}
+}
+
+func (s *MetricServer) updateMetrics(ctx context.Context, req *pb.UpdateMetricsRequest) (entity.MetricsList, error) {
+	batch := pb.ToEntityList(req.GetMetrics())
+	for _, metrics := range batch {
+		if err := validateMetrics(metrics, true); err != nil {
+			s.log.Info().Err(err).Send()
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	c, cancel := context.WithTimeout(ctx, _timeout)
+	defer cancel()
+
+	if err := s.uc.UpdateMetrics(c, batch); err != nil {
+		s.log.Info().Err(err).Msg("cannot update batch of metric value")
+		if errors.Is(err, entity.ErrHistogramBucketsMismatch) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "cannot update metrics")
+	}
+
+	return batch, nil
+}
+
+func (s *MetricServer) GetMetric(ctx context.Context, req *pb.GetMetricRequest) (*pb.GetMetricResponse, error) {
+	metrics := entity.Metrics{
+		ID:     req.GetId(),
+		MType:  req.GetType(),
+		Labels: entity.Labels(req.GetLabels()).Clone(),
+	}
+
+	if err := validateMetrics(metrics, false); err != nil {
+		s.log.Info().Err(err).Send()
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	c, cancel := context.WithTimeout(ctx, _timeout)
+	defer cancel()
+
+	result, err := s.uc.GetMetrics(c, metrics)
+	if err != nil {
+		if errors.Is(err, entity.ErrMetricNotFound) {
+			s.log.Info().Err(err).Send()
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		s.log.Info().Err(err).Msgf("cannot get %s metric value", metrics.MType)
+		return nil, status.Error(codes.Internal, "cannot get metric value")
+	}
+
+	return &pb.GetMetricResponse{Metric: pb.FromEntity(result)}, nil
+}
+
+func (s *MetricServer) ListMetrics(ctx context.Context, _ *pb.ListMetricsRequest) (*pb.ListMetricsResponse, error) {
+	c, cancel := context.WithTimeout(ctx, _timeout)
+	defer cancel()
+
+	list, err := s.uc.ListMetrics(c)
+	if err != nil {
+		s.log.Info().Err(err).Msg("cannot get list of metrics")
+		return nil, status.Error(codes.Internal, "cannot get list of metrics")
+	}
+
+	return &pb.ListMetricsResponse{Metrics: pb.FromEntityList(list)}, nil
+}
+
+// validateMetrics checks metrics received over gRPC,
+// withValue requires value matching metric type to be set.
+func validateMetrics(metrics entity.Metrics, withValue bool) error {
+	if metrics.ID == "" {
+		return errors.New("empty metric name")
+	}
+
+	switch metrics.MType {
+	case entity.Counter:
+		if withValue && metrics.Delta == nil {
+			return fmt.Errorf("counter %s without delta", metrics.Key())
+		}
+	case entity.Gauge:
+		if withValue && metrics.Value == nil {
+			return fmt.Errorf("gauge %s without value", metrics.Key())
+		}
+	case entity.Histogram:
+		if withValue {
+			if err := metrics.Histogram.Validate(); err != nil {
+				return fmt.Errorf("histogram %s: %w", metrics.Key(), err)
+			}
+		}
+	case entity.Summary:
+		if withValue {
+			if err := metrics.Summary.Validate(); err != nil {
+				return fmt.Errorf("summary %s: %w", metrics.Key(), err)
+			}
+		}
+	default:
+		return errors.New("invalid metric type")
+	}
+
+	return metrics.ValidateSeries()
+}
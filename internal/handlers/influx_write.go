@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/influx"
+)
+
+// InfluxWrite accepts InfluxDB line protocol the way InfluxDB v2
+// POST /api/v2/write does, org and bucket are ignored.
+func (h *MetricHandler) InfluxWrite(ctx *gin.Context) {
+	precision := ctx.Query("precision")
+	h.writeMetrics(ctx, "line protocol", func(body []byte) (entity.MetricsList, error) {
+		points, err := influx.Parse(body, precision)
+		if err != nil {
+			return nil, err
+		}
+		return influx.ToMetrics(points)
+	}, influxError)
+}
+
+// influxError responds with error body InfluxDB clients, e.g. Telegraf, log.
+func influxError(ctx *gin.Context, err error) {
+	ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]string{
+		"code":    "invalid",
+		"message": err.Error(),
+	})
+}
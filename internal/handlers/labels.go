@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+// labelsFromQuery reads metrics labels from URL query parameters,
+// e.g. /value/gauge/Alloc?host=a, parameters listed in reserved are skipped.
+func labelsFromQuery(ctx *gin.Context, reserved ...string) (entity.Labels, error) {
+	var labels entity.Labels
+	for name, values := range ctx.Request.URL.Query() {
+		if slices.Contains(reserved, name) || len(values) == 0 {
+			continue
+		}
+
+		if labels == nil {
+			labels = make(entity.Labels)
+		}
+		labels[name] = values[0]
+	}
+
+	return labels, labels.Validate()
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
 	"time"
 
+	"github.com/Imomali1/metrics/internal/entity"
 	"github.com/Imomali1/metrics/internal/pkg/logger"
 	"github.com/Imomali1/metrics/internal/usecase"
 )
@@ -20,3 +23,12 @@ func NewMetricHandler(log logger.Logger, uc usecase.UseCase) *MetricHandler {
 		uc:  uc,
 	}
 }
+
+// updateErrorStatus returns response status of failed update,
+// histograms that cannot be merged with stored ones are client errors.
+func updateErrorStatus(err error) int {
+	if errors.Is(err, entity.ErrHistogramBucketsMismatch) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Imomali1/metrics/internal/pkg/prometheus"
+)
+
+func (h *MetricHandler) PrometheusMetrics(ctx *gin.Context) {
+	c, cancel := context.WithTimeout(ctx, _timeout)
+	defer cancel()
+
+	allMetrics, err := h.uc.ListMetrics(c)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		h.log.Info().Err(err).Msg("cannot list metrics")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err = prometheus.WriteText(&buf, allMetrics); err != nil {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		h.log.Info().Err(err).Msg("cannot render metrics in prometheus format")
+		return
+	}
+
+	ctx.Data(http.StatusOK, prometheus.ContentType, buf.Bytes())
+}
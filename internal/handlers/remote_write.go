@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/prompb"
+)
+
+// RemoteWrite accepts Prometheus remote write requests and stores
+// the latest sample of every series as gauge.
+func (h *MetricHandler) RemoteWrite(ctx *gin.Context) {
+	h.writeMetrics(ctx, "remote write", decodeRemoteWrite, func(ctx *gin.Context, err error) {
+		if errors.Is(err, prompb.ErrTooLarge) {
+			ctx.AbortWithStatus(http.StatusRequestEntityTooLarge)
+			return
+		}
+		ctx.AbortWithStatus(http.StatusBadRequest)
+	})
+}
+
+func decodeRemoteWrite(body []byte) (entity.MetricsList, error) {
+	req, err := prompb.DecodeWriteRequest(body)
+	if err != nil {
+		return nil, err
+	}
+	return prompb.ToEntityList(req.GetTimeseries())
+}
@@ -13,7 +13,7 @@ import (
 
 func (h *MetricHandler) UpdateMetricValue(ctx *gin.Context) {
 	metricType := ctx.Param("type")
-	if metricType != entity.Gauge && metricType != entity.Counter {
+	if !entity.IsValidType(metricType) {
 		err := errors.New("invalid metric type")
 		ctx.AbortWithStatus(http.StatusBadRequest)
 		h.log.Logger.Info().Err(err).Send()
@@ -28,11 +28,27 @@ func (h *MetricHandler) UpdateMetricValue(ctx *gin.Context) {
 		return
 	}
 
-	var err error
+	if err := entity.ValidateName(metricName); err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		h.log.Logger.Info().Err(err).Send()
+		return
+	}
+
+	labels, err := labelsFromQuery(ctx)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		h.log.Logger.Info().Err(err).Send()
+		return
+	}
+
 	delta, value := new(int64), new(float64)
+	var histogram *entity.HistogramValue
 
 	metricValue := ctx.Param("value")
 
+	c, cancel := context.WithTimeout(ctx, _timeout)
+	defer cancel()
+
 	switch metricType {
 	case entity.Gauge:
 		*value, err = strconv.ParseFloat(metricValue, 64)
@@ -48,25 +64,74 @@ func (h *MetricHandler) UpdateMetricValue(ctx *gin.Context) {
 			h.log.Logger.Info().Err(err).Msg("counter metric value is not int64")
 			return
 		}
+	case entity.Histogram:
+		var observation float64
+		observation, err = strconv.ParseFloat(metricValue, 64)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			h.log.Logger.Info().Err(err).Msg("histogram observation is not float64")
+			return
+		}
+
+		histogram, err = h.observe(c, metricName, labels, observation)
+		if err != nil {
+			if errors.Is(err, entity.ErrMetricNotFound) {
+				ctx.AbortWithStatus(http.StatusBadRequest)
+				h.log.Logger.Info().Err(err).Msg("histogram buckets are unknown, send it as JSON first")
+				return
+			}
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			h.log.Logger.Info().Err(err).Msg("cannot get histogram buckets")
+			return
+		}
+		delta, value = nil, nil
+	case entity.Summary:
+		// quantiles are calculated by client, single observation is not enough
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		h.log.Logger.Info().Msg("summary can be sent only as JSON")
+		return
 	default:
 	}
 
 	metrics := entity.Metrics{
-		ID:    metricName,
-		MType: metricType,
-		Delta: delta,
-		Value: value,
+		ID:        metricName,
+		MType:     metricType,
+		Delta:     delta,
+		Value:     value,
+		Histogram: histogram,
+		Labels:    labels,
 	}
 
-	c, cancel := context.WithTimeout(ctx, _timeout)
-	defer cancel()
-
 	err = h.uc.UpdateMetrics(c, []entity.Metrics{metrics})
 	if err != nil {
-		ctx.AbortWithStatus(http.StatusInternalServerError)
+		ctx.AbortWithStatus(updateErrorStatus(err))
 		h.log.Logger.Info().Err(err).Msgf("cannot update %s metric value", metrics.MType)
 		return
 	}
 
 	ctx.Status(http.StatusOK)
 }
+
+// observe returns histogram with single observation laid out
+// in buckets of stored histogram, URI has no room for buckets.
+func (h *MetricHandler) observe(
+	ctx context.Context,
+	name string,
+	labels entity.Labels,
+	observation float64,
+) (*entity.HistogramValue, error) {
+	stored, err := h.uc.GetMetrics(ctx, entity.Metrics{ID: name, MType: entity.Histogram, Labels: labels})
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := make([]float64, len(stored.Histogram.Buckets))
+	for i, bucket := range stored.Histogram.Buckets {
+		bounds[i] = bucket.UpperBound
+	}
+
+	histogram := entity.NewHistogram(bounds)
+	histogram.Observe(observation)
+
+	return histogram, nil
+}
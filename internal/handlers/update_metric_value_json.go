@@ -35,19 +35,41 @@ func (h *MetricHandler) UpdateMetricValueJSON(ctx *gin.Context) {
 		return
 	}
 
-	if metrics.MType != entity.Gauge && metrics.MType != entity.Counter {
+	if !entity.IsValidType(metrics.MType) {
 		err = errors.New("invalid metric type")
 		ctx.AbortWithStatus(http.StatusBadRequest)
 		h.log.Logger.Info().Err(err).Send()
 		return
 	}
 
+	if metrics.MType == entity.Histogram {
+		if err = metrics.Histogram.Validate(); err != nil {
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			h.log.Logger.Info().Err(err).Send()
+			return
+		}
+	}
+
+	if metrics.MType == entity.Summary {
+		if err = metrics.Summary.Validate(); err != nil {
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			h.log.Logger.Info().Err(err).Send()
+			return
+		}
+	}
+
+	if err = metrics.ValidateSeries(); err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		h.log.Logger.Info().Err(err).Send()
+		return
+	}
+
 	c, cancel := context.WithTimeout(ctx, _timeout)
 	defer cancel()
 
 	err = h.uc.UpdateMetrics(c, []entity.Metrics{metrics})
 	if err != nil {
-		ctx.AbortWithStatus(http.StatusInternalServerError)
+		ctx.AbortWithStatus(updateErrorStatus(err))
 		h.log.Logger.Info().Err(err).Msgf("cannot update %s metric value", metrics.MType)
 		return
 	}
@@ -38,18 +38,43 @@ func (h *MetricHandler) Updates(ctx *gin.Context) {
 	for i, metrics := range batch {
 		switch metrics.MType {
 		case entity.Counter:
-			h.log.Logger.Info().Msgf("#%d counter %s %d", i+1, metrics.ID, *metrics.Delta)
+			h.log.Logger.Info().Msgf("#%d counter %s %d", i+1, metrics.Key(), *metrics.Delta)
 		case entity.Gauge:
-			h.log.Logger.Info().Msgf("#%d gauge %s %f", i+1, metrics.ID, *metrics.Value)
+			h.log.Logger.Info().Msgf("#%d gauge %s %f", i+1, metrics.Key(), *metrics.Value)
+		case entity.Histogram:
+			h.log.Logger.Info().Msgf("#%d histogram %s", i+1, metrics.Key())
+		case entity.Summary:
+			h.log.Logger.Info().Msgf("#%d summary %s", i+1, metrics.Key())
 		}
 
-		if metrics.MType != entity.Gauge && metrics.MType != entity.Counter {
+		if !entity.IsValidType(metrics.MType) {
 			err = errors.New("invalid metric type")
 			ctx.AbortWithStatus(http.StatusBadRequest)
 			h.log.Logger.Info().Err(err).Send()
 			return
 		}
 
+		if metrics.MType == entity.Histogram {
+			if err = metrics.Histogram.Validate(); err != nil {
+				ctx.AbortWithStatus(http.StatusBadRequest)
+				h.log.Logger.Info().Err(err).Send()
+				return
+			}
+		}
+
+		if metrics.MType == entity.Summary {
+			if err = metrics.Summary.Validate(); err != nil {
+				ctx.AbortWithStatus(http.StatusBadRequest)
+				h.log.Logger.Info().Err(err).Send()
+				return
+			}
+		}
+
+		if err = metrics.ValidateSeries(); err != nil {
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			h.log.Logger.Info().Err(err).Send()
+			return
+		}
 	}
 
 	c, cancel := context.WithTimeout(ctx, _timeout)
@@ -57,7 +82,7 @@ func (h *MetricHandler) Updates(ctx *gin.Context) {
 
 	err = h.uc.UpdateMetrics(c, batch)
 	if err != nil {
-		ctx.AbortWithStatus(http.StatusInternalServerError)
+		ctx.AbortWithStatus(updateErrorStatus(err))
 		h.log.Logger.Info().Err(err).Msg("cannot update batch of metric value")
 		return
 	}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+// _maxWriteBodySize limits body of remote write and InfluxDB write requests.
+const _maxWriteBodySize = 10 << 20
+
+// writeMetrics reads body of write request, converts it to metrics with decode
+// and saves them, invalid request is rejected with reject. Both remote write
+// and InfluxDB write respond with 204 No Content, what names request in logs.
+func (h *MetricHandler) writeMetrics(
+	ctx *gin.Context,
+	what string,
+	decode func(body []byte) (entity.MetricsList, error),
+	reject func(ctx *gin.Context, err error),
+) {
+	body, err := utils.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, _maxWriteBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			ctx.AbortWithStatus(http.StatusRequestEntityTooLarge)
+		} else {
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}
+		h.log.Logger.Info().Err(err).Msg("cannot read request body")
+		return
+	}
+
+	batch, err := decode(body)
+	if err != nil {
+		reject(ctx, err)
+		h.log.Logger.Info().Err(err).Msgf("cannot decode %s request", what)
+		return
+	}
+
+	for _, metrics := range batch {
+		if err = metrics.ValidateSeries(); err != nil {
+			reject(ctx, err)
+			h.log.Logger.Info().Err(err).Send()
+			return
+		}
+	}
+
+	if len(batch) == 0 {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+
+	c, cancel := context.WithTimeout(ctx, _timeout)
+	defer cancel()
+
+	err = h.uc.UpdateMetrics(c, batch)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		h.log.Logger.Info().Err(err).Msg("cannot update batch of metric value")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,133 @@
+package cipher
+
+import (
+	"bytes"
+	"crypto/aes"
+	stdcipher "crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+// envelopeMagic prefixes every body encrypted by EncryptHybrid,
+// so that it can be told apart from legacy EncryptRSA output.
+var envelopeMagic = []byte("MENV1")
+
+const (
+	aesKeySize       = 32
+	wrappedKeyLenLen = 2
+)
+
+var ErrInvalidEnvelope = errors.New("invalid encrypted envelope")
+
+// EncryptHybrid encrypts data of any size with random AES-256-GCM key,
+// the key itself is encrypted with RSA-OAEP. Envelope layout:
+//
+//	magic | wrapped key length (uint16, big endian) | wrapped key | nonce | ciphertext
+//
+// Header up to the nonce is authenticated as additional data.
+func EncryptHybrid(publicKey *rsa.PublicKey, rawData []byte) ([]byte, error) {
+	key := make([]byte, aesKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	headerLen := len(envelopeMagic) + wrappedKeyLenLen + len(wrappedKey)
+	envelope := make([]byte, 0, headerLen+len(nonce)+len(rawData)+gcm.Overhead())
+	envelope = append(envelope, envelopeMagic...)
+	envelope = binary.BigEndian.AppendUint16(envelope, uint16(len(wrappedKey)))
+	envelope = append(envelope, wrappedKey...)
+	envelope = append(envelope, nonce...)
+
+	return gcm.Seal(envelope, nonce, rawData, envelope[:headerLen]), nil
+}
+
+// DecryptHybrid decrypts envelope produced by EncryptHybrid.
+func DecryptHybrid(privateKey *rsa.PrivateKey, envelope []byte) ([]byte, error) {
+	if !IsEnvelope(envelope) {
+		return nil, ErrInvalidEnvelope
+	}
+
+	offset := len(envelopeMagic)
+	if len(envelope) < offset+wrappedKeyLenLen {
+		return nil, ErrInvalidEnvelope
+	}
+
+	wrappedKeyLen := int(binary.BigEndian.Uint16(envelope[offset:]))
+	offset += wrappedKeyLenLen
+	if len(envelope) < offset+wrappedKeyLen {
+		return nil, ErrInvalidEnvelope
+	}
+
+	wrappedKey := envelope[offset : offset+wrappedKeyLen]
+	offset += wrappedKeyLen
+	header := envelope[:offset]
+
+	key, err := rsa.DecryptOAEP(sha256.New(), nil, privateKey, wrappedKey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(envelope) < offset+gcm.NonceSize() {
+		return nil, ErrInvalidEnvelope
+	}
+
+	nonce := envelope[offset : offset+gcm.NonceSize()]
+	ciphertext := envelope[offset+gcm.NonceSize():]
+
+	return gcm.Open(nil, nonce, ciphertext, header)
+}
+
+// IsEnvelope reports whether data looks like EncryptHybrid output.
+func IsEnvelope(data []byte) bool {
+	return bytes.HasPrefix(data, envelopeMagic)
+}
+
+// Decrypt decrypts data encrypted either by EncryptHybrid
+// or by legacy EncryptRSA.
+func Decrypt(privateKey *rsa.PrivateKey, data []byte) ([]byte, error) {
+	if !IsEnvelope(data) {
+		return DecryptRSA(privateKey, data)
+	}
+
+	decryptedData, err := DecryptHybrid(privateKey, data)
+	if err != nil && len(data) == privateKey.Size() {
+		// legacy ciphertext may start with magic bytes by chance
+		if legacyData, legacyErr := DecryptRSA(privateKey, data); legacyErr == nil {
+			return legacyData, nil
+		}
+	}
+
+	return decryptedData, err
+}
+
+func newGCM(key []byte) (stdcipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return stdcipher.NewGCM(block)
+}
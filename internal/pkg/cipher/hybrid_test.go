@@ -0,0 +1,107 @@
+package cipher
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecryptHybrid(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		rawData []byte
+	}{
+		{
+			name:    "empty data",
+			rawData: []byte{},
+		},
+		{
+			name:    "small data",
+			rawData: []byte("test data"),
+		},
+		{
+			name:    "data larger than rsa key size",
+			rawData: bytes.Repeat([]byte("x"), 64*privateKey.Size()),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envelope, err := EncryptHybrid(&privateKey.PublicKey, tt.rawData)
+			require.NoError(t, err)
+			require.True(t, IsEnvelope(envelope))
+
+			got, err := DecryptHybrid(privateKey, envelope)
+			require.NoError(t, err)
+			require.Equal(t, tt.rawData, append([]byte{}, got...))
+		})
+	}
+}
+
+func TestDecryptHybrid_InvalidEnvelope(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	envelope, err := EncryptHybrid(&privateKey.PublicKey, []byte("test data"))
+	require.NoError(t, err)
+
+	tampered := append([]byte{}, envelope...)
+	tampered[len(tampered)-1] ^= 0xff
+
+	tests := []struct {
+		name     string
+		envelope []byte
+	}{
+		{
+			name:     "without magic",
+			envelope: []byte("test data"),
+		},
+		{
+			name:     "truncated header",
+			envelope: envelopeMagic,
+		},
+		{
+			name:     "truncated wrapped key",
+			envelope: envelope[:len(envelopeMagic)+wrappedKeyLenLen+10],
+		},
+		{
+			name:     "tampered ciphertext",
+			envelope: tampered,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err = DecryptHybrid(privateKey, tt.envelope)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	rawData := []byte("test data")
+
+	legacy, err := EncryptRSA(&privateKey.PublicKey, rawData)
+	require.NoError(t, err)
+
+	envelope, err := EncryptHybrid(&privateKey.PublicKey, rawData)
+	require.NoError(t, err)
+
+	got, err := Decrypt(privateKey, legacy)
+	require.NoError(t, err)
+	require.Equal(t, rawData, got)
+
+	got, err = Decrypt(privateKey, envelope)
+	require.NoError(t, err)
+	require.Equal(t, rawData, got)
+
+	_, err = Decrypt(privateKey, []byte("invalid"))
+	require.Error(t, err)
+}
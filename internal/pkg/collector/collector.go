@@ -0,0 +1,127 @@
+// Package collector contains sources of metrics polled by agent.
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+var ErrUnknownCollector = errors.New("unknown collector")
+
+// Collector gathers group of metrics every Interval.
+type Collector interface {
+	// Name identifies collector in config, names must be unique.
+	Name() string
+	Interval() time.Duration
+	Collect(ctx context.Context) ([]entity.Metrics, error)
+}
+
+// Registry keeps the latest output of every collector in its own slot,
+// Snapshot merges slots in registration order.
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+	slots      [][]entity.Metrics
+	index      map[string]int
+}
+
+func NewRegistry(collectors ...Collector) (*Registry, error) {
+	r := &Registry{
+		collectors: make([]Collector, 0, len(collectors)),
+		slots:      make([][]entity.Metrics, 0, len(collectors)),
+		index:      make(map[string]int, len(collectors)),
+	}
+
+	for _, c := range collectors {
+		if _, exists := r.index[c.Name()]; exists {
+			return nil, fmt.Errorf("collector %q is registered twice", c.Name())
+		}
+		r.index[c.Name()] = len(r.collectors)
+		r.collectors = append(r.collectors, c)
+		r.slots = append(r.slots, nil)
+	}
+
+	return r, nil
+}
+
+// Collectors returns registered collectors in registration order.
+func (r *Registry) Collectors() []Collector {
+	return r.collectors
+}
+
+// Poll collects metrics of c and replaces its slot,
+// the previous output is kept when collector fails.
+func (r *Registry) Poll(ctx context.Context, c Collector) error {
+	i, ok := r.index[c.Name()]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownCollector, c.Name())
+	}
+
+	metrics, err := c.Collect(ctx)
+	if err != nil {
+		return fmt.Errorf("collector %s: %w", c.Name(), err)
+	}
+
+	r.mu.Lock()
+	r.slots[i] = metrics
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Snapshot returns copy of the latest outputs of all collectors.
+func (r *Registry) Snapshot() []entity.Metrics {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var size int
+	for _, slot := range r.slots {
+		size += len(slot)
+	}
+
+	snapshot := make([]entity.Metrics, 0, size)
+	for _, slot := range r.slots {
+		snapshot = append(snapshot, slot...)
+	}
+
+	return snapshot
+}
+
+// Select filters collectors by names, empty enabled list enables all of them.
+// Names of both lists must belong to given collectors.
+func Select(collectors []Collector, enabled, disabled []string) ([]Collector, error) {
+	known := make(map[string]bool, len(collectors))
+	for _, c := range collectors {
+		known[c.Name()] = true
+	}
+
+	isEnabled := make(map[string]bool, len(enabled))
+	for _, name := range enabled {
+		if !known[name] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownCollector, name)
+		}
+		isEnabled[name] = true
+	}
+
+	isDisabled := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		if !known[name] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownCollector, name)
+		}
+		isDisabled[name] = true
+	}
+
+	var selected []Collector
+	for _, c := range collectors {
+		if (len(enabled) == 0 || isEnabled[c.Name()]) && !isDisabled[c.Name()] {
+			selected = append(selected, c)
+		}
+	}
+
+	return selected, nil
+}
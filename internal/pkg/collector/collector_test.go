@@ -0,0 +1,132 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+type stubCollector struct {
+	name    string
+	metrics []entity.Metrics
+	err     error
+}
+
+func (c *stubCollector) Name() string {
+	return c.name
+}
+
+func (c *stubCollector) Interval() time.Duration {
+	return time.Second
+}
+
+func (c *stubCollector) Collect(_ context.Context) ([]entity.Metrics, error) {
+	return c.metrics, c.err
+}
+
+func TestRegistry(t *testing.T) {
+	first := &stubCollector{name: "first", metrics: []entity.Metrics{gauge("a", 1, nil), gauge("b", 2, nil)}}
+	second := &stubCollector{name: "second", metrics: []entity.Metrics{gauge("c", 3, nil)}}
+
+	r, err := NewRegistry(first, second)
+	require.NoError(t, err)
+	require.Equal(t, []Collector{first, second}, r.Collectors())
+	require.Empty(t, r.Snapshot())
+
+	ctx := context.Background()
+
+	// slots are merged in registration order regardless of polling order
+	require.NoError(t, r.Poll(ctx, second))
+	require.NoError(t, r.Poll(ctx, first))
+	require.Equal(t, []entity.Metrics{gauge("a", 1, nil), gauge("b", 2, nil), gauge("c", 3, nil)}, r.Snapshot())
+
+	// polling replaces slot instead of appending to it
+	first.metrics = []entity.Metrics{gauge("a", 10, nil)}
+	require.NoError(t, r.Poll(ctx, first))
+	require.Equal(t, []entity.Metrics{gauge("a", 10, nil), gauge("c", 3, nil)}, r.Snapshot())
+
+	// failed collector keeps its previous output
+	second.err = errors.New("collect error")
+	second.metrics = nil
+	require.Error(t, r.Poll(ctx, second))
+	require.Equal(t, []entity.Metrics{gauge("a", 10, nil), gauge("c", 3, nil)}, r.Snapshot())
+
+	err = r.Poll(ctx, &stubCollector{name: "unknown"})
+	require.ErrorIs(t, err, ErrUnknownCollector)
+
+	_, err = NewRegistry(first, &stubCollector{name: "first"})
+	require.Error(t, err)
+}
+
+func TestSelect(t *testing.T) {
+	first := &stubCollector{name: "first"}
+	second := &stubCollector{name: "second"}
+	third := &stubCollector{name: "third"}
+	all := []Collector{first, second, third}
+
+	tests := []struct {
+		name     string
+		enabled  []string
+		disabled []string
+		want     []Collector
+		wantErr  bool
+	}{
+		{
+			name: "all by default",
+			want: all,
+		},
+		{
+			name:    "only enabled in registration order",
+			enabled: []string{"third", "first"},
+			want:    []Collector{first, third},
+		},
+		{
+			name:     "disabled",
+			disabled: []string{"second"},
+			want:     []Collector{first, third},
+		},
+		{
+			name:     "disabled wins over enabled",
+			enabled:  []string{"first", "second"},
+			disabled: []string{"second"},
+			want:     []Collector{first},
+		},
+		{
+			name:    "unknown enabled",
+			enabled: []string{"unknown"},
+			wantErr: true,
+		},
+		{
+			name:     "unknown disabled",
+			disabled: []string{"unknown"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Select(all, tt.enabled, tt.disabled)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrUnknownCollector)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRuntime_Collect(t *testing.T) {
+	c := NewRuntime(time.Second)
+
+	for i := int64(1); i <= 2; i++ {
+		metrics, err := c.Collect(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "PollCount", metrics[0].ID)
+		require.Equal(t, i, *metrics[0].Delta)
+	}
+}
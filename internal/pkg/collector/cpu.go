@@ -0,0 +1,43 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+// CPU collects utilization of every CPU core labeled with core number.
+type CPU struct {
+	interval time.Duration
+}
+
+func NewCPU(interval time.Duration) *CPU {
+	return &CPU{interval: interval}
+}
+
+func (c *CPU) Name() string {
+	return "cpu"
+}
+
+func (c *CPU) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *CPU) Collect(ctx context.Context) ([]entity.Metrics, error) {
+	percents, err := cpu.PercentWithContext(ctx, 0, true)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get cpu metrics: %w", err)
+	}
+
+	metrics := make([]entity.Metrics, len(percents))
+	for i, percent := range percents {
+		metrics[i] = gauge("CPUCoreUtilization", percent, entity.Labels{"core": strconv.Itoa(i)})
+	}
+
+	return metrics, nil
+}
@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+// Disk collects usage of every mountpoint and IO counters of every device.
+// Partitions are matched by both device and mountpoint.
+type Disk struct {
+	interval time.Duration
+	filter   Filter
+}
+
+func NewDisk(interval time.Duration, filter Filter) *Disk {
+	return &Disk{interval: interval, filter: filter}
+}
+
+func (c *Disk) Name() string {
+	return "disk"
+}
+
+func (c *Disk) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *Disk) Collect(ctx context.Context) ([]entity.Metrics, error) {
+	partitions, err := disk.PartitionsWithContext(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get disk partitions: %w", err)
+	}
+
+	var metrics []entity.Metrics
+	for _, partition := range partitions {
+		if !c.filter.Match(partition.Device, partition.Mountpoint) {
+			continue
+		}
+
+		usage, errUsage := disk.UsageWithContext(ctx, partition.Mountpoint)
+		if errUsage != nil {
+			// mountpoint may be unmounted or inaccessible meanwhile
+			continue
+		}
+
+		labels := entity.Labels{"mountpoint": partition.Mountpoint}
+		metrics = append(metrics,
+			gauge("DiskTotal", float64(usage.Total), labels),
+			gauge("DiskUsed", float64(usage.Used), labels.Clone()),
+			gauge("DiskFree", float64(usage.Free), labels.Clone()),
+			gauge("DiskUsedPercent", usage.UsedPercent, labels.Clone()),
+		)
+	}
+
+	counters, err := disk.IOCountersWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get disk io counters: %w", err)
+	}
+
+	devices := make([]string, 0, len(counters))
+	for device := range counters {
+		if c.filter.Match(device, "/dev/"+device) {
+			devices = append(devices, device)
+		}
+	}
+	sort.Strings(devices)
+
+	for _, device := range devices {
+		counter := counters[device]
+		labels := entity.Labels{"device": device}
+		metrics = append(metrics,
+			gauge("DiskReadBytes", float64(counter.ReadBytes), labels),
+			gauge("DiskWriteBytes", float64(counter.WriteBytes), labels.Clone()),
+			gauge("DiskReadCount", float64(counter.ReadCount), labels.Clone()),
+			gauge("DiskWriteCount", float64(counter.WriteCount), labels.Clone()),
+		)
+	}
+
+	return metrics, nil
+}
@@ -0,0 +1,39 @@
+package collector
+
+import (
+	"path"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+// Filter selects devices, mountpoints or interfaces by name.
+// Patterns use path.Match syntax, e.g. "loop*".
+type Filter struct {
+	// Include lists allowed names, empty list allows all of them.
+	Include []string
+	// Exclude lists denied names, it wins over Include.
+	Exclude []string
+}
+
+// Match reports whether any of names passes filter.
+func (f Filter) Match(names ...string) bool {
+	if matchAny(f.Exclude, names) {
+		return false
+	}
+	return len(f.Include) == 0 || matchAny(f.Include, names)
+}
+
+func matchAny(patterns []string, names []string) bool {
+	for _, pattern := range patterns {
+		for _, name := range names {
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func gauge(id string, value float64, labels entity.Labels) entity.Metrics {
+	return entity.Metrics{ID: id, MType: entity.Gauge, Value: &value, Labels: labels}
+}
@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/mem"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+// Gopsutil collects host memory and CPU utilization.
+type Gopsutil struct {
+	interval time.Duration
+}
+
+func NewGopsutil(interval time.Duration) *Gopsutil {
+	return &Gopsutil{interval: interval}
+}
+
+func (c *Gopsutil) Name() string {
+	return "gopsutil"
+}
+
+func (c *Gopsutil) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *Gopsutil) Collect(ctx context.Context) ([]entity.Metrics, error) {
+	vm, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get memory metrics: %w", err)
+	}
+
+	total, free := float64(vm.Total), float64(vm.Free)
+
+	cpuUtil, err := cpu.PercentWithContext(ctx, 0, false)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get cpu metrics: %w", err)
+	}
+
+	if len(cpuUtil) == 0 {
+		return nil, errors.New("cannot get cpu metrics: empty result")
+	}
+
+	return []entity.Metrics{
+		{ID: "TotalMemory", MType: entity.Gauge, Value: &total},
+		{ID: "FreeMemory", MType: entity.Gauge, Value: &free},
+		{ID: "CPUutilization1", MType: entity.Gauge, Value: &cpuUtil[0]},
+	}, nil
+}
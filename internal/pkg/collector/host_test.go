@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+func TestFilter_Match(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		names  []string
+		want   bool
+	}{
+		{
+			name:   "empty filter",
+			filter: Filter{},
+			names:  []string{"sda"},
+			want:   true,
+		},
+		{
+			name:   "included",
+			filter: Filter{Include: []string{"sd*"}},
+			names:  []string{"sda"},
+			want:   true,
+		},
+		{
+			name:   "not included",
+			filter: Filter{Include: []string{"sd*"}},
+			names:  []string{"loop0"},
+			want:   false,
+		},
+		{
+			name:   "excluded",
+			filter: Filter{Exclude: []string{"loop*"}},
+			names:  []string{"loop0"},
+			want:   false,
+		},
+		{
+			name:   "exclude wins over include",
+			filter: Filter{Include: []string{"*"}, Exclude: []string{"/boot"}},
+			names:  []string{"/dev/sda1", "/boot"},
+			want:   false,
+		},
+		{
+			name:   "any of names included",
+			filter: Filter{Include: []string{"/"}},
+			names:  []string{"/dev/sda1", "/"},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.filter.Match(tt.names...))
+		})
+	}
+}
+
+func TestHostCollectors(t *testing.T) {
+	collectors := []Collector{
+		NewGopsutil(time.Second),
+		NewCPU(time.Second),
+		NewLoad(time.Second),
+		NewSwap(time.Second),
+		NewDisk(time.Second, Filter{}),
+		NewNet(time.Second, Filter{}),
+	}
+
+	for _, c := range collectors {
+		t.Run(c.Name(), func(t *testing.T) {
+			metrics, err := c.Collect(context.Background())
+			if err != nil {
+				t.Skipf("collector is not supported here: %v", err)
+			}
+
+			keys := make(map[string]bool, len(metrics))
+			for _, metric := range metrics {
+				require.Equal(t, entity.Gauge, metric.MType)
+				require.NotNil(t, metric.Value)
+				require.NoError(t, metric.Labels.Validate())
+				require.False(t, keys[metric.Key()], "duplicate series %s", metric.Key())
+				keys[metric.Key()] = true
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/load"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+// Load collects 1, 5 and 15 minutes load averages.
+type Load struct {
+	interval time.Duration
+}
+
+func NewLoad(interval time.Duration) *Load {
+	return &Load{interval: interval}
+}
+
+func (c *Load) Name() string {
+	return "load"
+}
+
+func (c *Load) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *Load) Collect(ctx context.Context) ([]entity.Metrics, error) {
+	avg, err := load.AvgWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get load average: %w", err)
+	}
+
+	return []entity.Metrics{
+		gauge("LoadAverage1", avg.Load1, nil),
+		gauge("LoadAverage5", avg.Load5, nil),
+		gauge("LoadAverage15", avg.Load15, nil),
+	}, nil
+}
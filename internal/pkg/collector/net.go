@@ -0,0 +1,55 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/net"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+// Net collects traffic and error counters of every network interface.
+type Net struct {
+	interval time.Duration
+	filter   Filter
+}
+
+func NewNet(interval time.Duration, filter Filter) *Net {
+	return &Net{interval: interval, filter: filter}
+}
+
+func (c *Net) Name() string {
+	return "net"
+}
+
+func (c *Net) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *Net) Collect(ctx context.Context) ([]entity.Metrics, error) {
+	counters, err := net.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get network io counters: %w", err)
+	}
+
+	var metrics []entity.Metrics
+	for _, counter := range counters {
+		if !c.filter.Match(counter.Name) {
+			continue
+		}
+
+		labels := entity.Labels{"interface": counter.Name}
+		metrics = append(metrics,
+			gauge("NetBytesSent", float64(counter.BytesSent), labels),
+			gauge("NetBytesRecv", float64(counter.BytesRecv), labels.Clone()),
+			gauge("NetPacketsSent", float64(counter.PacketsSent), labels.Clone()),
+			gauge("NetPacketsRecv", float64(counter.PacketsRecv), labels.Clone()),
+			gauge("NetErrIn", float64(counter.Errin), labels.Clone()),
+			gauge("NetErrOut", float64(counter.Errout), labels.Clone()),
+		)
+	}
+
+	return metrics, nil
+}
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+// Runtime collects memory statistics of agent process,
+// PollCount and RandomValue.
+type Runtime struct {
+	interval  time.Duration
+	pollCount atomic.Int64
+}
+
+func NewRuntime(interval time.Duration) *Runtime {
+	return &Runtime{interval: interval}
+}
+
+func (c *Runtime) Name() string {
+	return "runtime"
+}
+
+func (c *Runtime) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *Runtime) Collect(_ context.Context) ([]entity.Metrics, error) {
+	var memStat runtime.MemStats
+	runtime.ReadMemStats(&memStat)
+
+	pollCount := c.pollCount.Add(1)
+	randomValue := rand.NormFloat64()
+
+	return []entity.Metrics{
+		{MType: entity.Counter, ID: "PollCount", Delta: &pollCount},
+		{MType: entity.Gauge, ID: "RandomValue", Value: utils.Ptr(randomValue)},
+		{MType: entity.Gauge, ID: "Alloc", Value: utils.Ptr(float64(memStat.Alloc))},
+		{MType: entity.Gauge, ID: "BuckHashSys", Value: utils.Ptr(float64(memStat.BuckHashSys))},
+		{MType: entity.Gauge, ID: "Frees", Value: utils.Ptr(float64(memStat.Frees))},
+		{MType: entity.Gauge, ID: "GCCPUFraction", Value: utils.Ptr(memStat.GCCPUFraction)},
+		{MType: entity.Gauge, ID: "GCSys", Value: utils.Ptr(float64(memStat.GCSys))},
+		{MType: entity.Gauge, ID: "HeapAlloc", Value: utils.Ptr(float64(memStat.HeapAlloc))},
+		{MType: entity.Gauge, ID: "HeapIdle", Value: utils.Ptr(float64(memStat.HeapIdle))},
+		{MType: entity.Gauge, ID: "HeapInuse", Value: utils.Ptr(float64(memStat.HeapInuse))},
+		{MType: entity.Gauge, ID: "HeapObjects", Value: utils.Ptr(float64(memStat.HeapObjects))},
+		{MType: entity.Gauge, ID: "HeapReleased", Value: utils.Ptr(float64(memStat.HeapReleased))},
+		{MType: entity.Gauge, ID: "HeapSys", Value: utils.Ptr(float64(memStat.HeapSys))},
+		{MType: entity.Gauge, ID: "LastGC", Value: utils.Ptr(float64(memStat.LastGC))},
+		{MType: entity.Gauge, ID: "Lookups", Value: utils.Ptr(float64(memStat.Lookups))},
+		{MType: entity.Gauge, ID: "MCacheInuse", Value: utils.Ptr(float64(memStat.MCacheInuse))},
+		{MType: entity.Gauge, ID: "MCacheSys", Value: utils.Ptr(float64(memStat.MCacheSys))},
+		{MType: entity.Gauge, ID: "MSpanInuse", Value: utils.Ptr(float64(memStat.MSpanInuse))},
+		{MType: entity.Gauge, ID: "MSpanSys", Value: utils.Ptr(float64(memStat.MSpanSys))},
+		{MType: entity.Gauge, ID: "Mallocs", Value: utils.Ptr(float64(memStat.Mallocs))},
+		{MType: entity.Gauge, ID: "NextGC", Value: utils.Ptr(float64(memStat.NextGC))},
+		{MType: entity.Gauge, ID: "NumForcedGC", Value: utils.Ptr(float64(memStat.NumForcedGC))},
+		{MType: entity.Gauge, ID: "NumGC", Value: utils.Ptr(float64(memStat.NumGC))},
+		{MType: entity.Gauge, ID: "OtherSys", Value: utils.Ptr(float64(memStat.OtherSys))},
+		{MType: entity.Gauge, ID: "PauseTotalNs", Value: utils.Ptr(float64(memStat.PauseTotalNs))},
+		{MType: entity.Gauge, ID: "StackInuse", Value: utils.Ptr(float64(memStat.StackInuse))},
+		{MType: entity.Gauge, ID: "StackSys", Value: utils.Ptr(float64(memStat.StackSys))},
+		{MType: entity.Gauge, ID: "Sys", Value: utils.Ptr(float64(memStat.Sys))},
+		{MType: entity.Gauge, ID: "TotalAlloc", Value: utils.Ptr(float64(memStat.TotalAlloc))},
+	}, nil
+}
@@ -0,0 +1,42 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/mem"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+// Swap collects swap memory usage.
+type Swap struct {
+	interval time.Duration
+}
+
+func NewSwap(interval time.Duration) *Swap {
+	return &Swap{interval: interval}
+}
+
+func (c *Swap) Name() string {
+	return "swap"
+}
+
+func (c *Swap) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *Swap) Collect(ctx context.Context) ([]entity.Metrics, error) {
+	swap, err := mem.SwapMemoryWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get swap metrics: %w", err)
+	}
+
+	return []entity.Metrics{
+		gauge("SwapTotal", float64(swap.Total), nil),
+		gauge("SwapUsed", float64(swap.Used), nil),
+		gauge("SwapFree", float64(swap.Free), nil),
+		gauge("SwapUsedPercent", swap.UsedPercent, nil),
+	}, nil
+}
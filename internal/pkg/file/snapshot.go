@@ -0,0 +1,217 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mailru/easyjson"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+const (
+	// SnapshotFormat identifies header line of snapshot file.
+	SnapshotFormat = "metrics-snapshot"
+	// SnapshotVersion is version of snapshot format written by WriteSnapshot.
+	SnapshotVersion = 1
+
+	tempSuffix = ".tmp-"
+)
+
+var (
+	ErrCorruptedSnapshot          = errors.New("corrupted snapshot")
+	ErrUnsupportedSnapshotVersion = errors.New("unsupported snapshot version")
+)
+
+// SnapshotHeader is the first line of snapshot file, it is followed
+// by Count metrics, one JSON object per line. Checksum is CRC-32 of
+// everything after the header line. WALSeq is sequence number of
+// the last WAL record included into snapshot.
+type SnapshotHeader struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+	Count     int       `json:"count"`
+	Checksum  uint32    `json:"checksum"`
+	WALSeq    uint64    `json:"wal_seq,omitempty"`
+}
+
+// WriteSnapshot replaces filename with snapshot of metrics. Snapshot is
+// written to a temporary file in the same directory, synced to disk and
+// renamed over filename, so readers see either old or new snapshot.
+func WriteSnapshot(filename string, metrics entity.MetricsList) error {
+	return writeSnapshot(filename, metrics, 0)
+}
+
+func writeSnapshot(filename string, metrics entity.MetricsList, walSeq uint64) error {
+	var body bytes.Buffer
+	for _, metric := range metrics {
+		data, err := easyjson.Marshal(metric)
+		if err != nil {
+			return err
+		}
+
+		body.Write(data)
+		body.WriteByte('\n')
+	}
+
+	header, err := json.Marshal(SnapshotHeader{
+		Format:    SnapshotFormat,
+		Version:   SnapshotVersion,
+		Timestamp: time.Now().UTC(),
+		Count:     len(metrics),
+		Checksum:  crc32.ChecksumIEEE(body.Bytes()),
+		WALSeq:    walSeq,
+	})
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(filename)
+	tmp, err := os.CreateTemp(dir, filepath.Base(filename)+tempSuffix+"*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(tmp)
+	if _, err = w.Write(header); err != nil {
+		return err
+	}
+	if err = w.WriteByte('\n'); err != nil {
+		return err
+	}
+	if _, err = w.Write(body.Bytes()); err != nil {
+		return err
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), filename); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// syncDir makes rename durable by syncing parent directory.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// ReadSnapshot reads snapshot written by WriteSnapshot. Files without
+// header written by older versions are read line by line, the last
+// line of every metric wins.
+func ReadSnapshot(filename string) (SnapshotHeader, entity.MetricsList, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return SnapshotHeader{}, nil, err
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return SnapshotHeader{}, nil, nil
+	}
+
+	first, body, _ := bytes.Cut(data, []byte{'\n'})
+
+	var header SnapshotHeader
+	if json.Unmarshal(first, &header) != nil || header.Format != SnapshotFormat {
+		metrics, err := readLegacy(data)
+		return SnapshotHeader{}, metrics, err
+	}
+
+	if header.Version != SnapshotVersion {
+		return SnapshotHeader{}, nil, fmt.Errorf("%w: %d", ErrUnsupportedSnapshotVersion, header.Version)
+	}
+
+	if crc32.ChecksumIEEE(body) != header.Checksum {
+		return SnapshotHeader{}, nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptedSnapshot)
+	}
+
+	metrics := make(entity.MetricsList, 0, header.Count)
+	for _, line := range bytes.Split(body, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+
+		var metric entity.Metrics
+		if err = easyjson.Unmarshal(line, &metric); err != nil {
+			return SnapshotHeader{}, nil, fmt.Errorf("%w: %w", ErrCorruptedSnapshot, err)
+		}
+		metrics = append(metrics, metric)
+	}
+
+	if len(metrics) != header.Count {
+		return SnapshotHeader{}, nil, fmt.Errorf("%w: expected %d metrics, got %d",
+			ErrCorruptedSnapshot, header.Count, len(metrics))
+	}
+
+	return header, metrics, nil
+}
+
+func readLegacy(data []byte) (entity.MetricsList, error) {
+	var metrics entity.MetricsList
+	m := make(map[string]int)
+
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+
+		var metric entity.Metrics
+		if err := easyjson.Unmarshal(line, &metric); err != nil {
+			return nil, err
+		}
+
+		idx, ok := m[metric.Key()]
+		if !ok {
+			m[metric.Key()] = len(metrics)
+			metrics = append(metrics, metric)
+		} else {
+			metrics[idx] = metric
+		}
+	}
+
+	return metrics, nil
+}
+
+// removeTempFiles removes temporary files left by interrupted WriteSnapshot.
+func removeTempFiles(filename string) error {
+	matches, err := filepath.Glob(filename + tempSuffix + "*")
+	if err != nil {
+		return err
+	}
+
+	for _, name := range matches {
+		if err = os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/storage"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+func TestWriteSnapshot(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "metrics.json")
+
+	first := entity.MetricsList{
+		{ID: "Counter1", MType: entity.Counter, Delta: utils.Ptr(int64(5))},
+	}
+	second := entity.MetricsList{
+		{ID: "Counter1", MType: entity.Counter, Delta: utils.Ptr(int64(7))},
+		{ID: "Gauge1", MType: entity.Gauge, Value: utils.Ptr(1.5)},
+	}
+
+	require.NoError(t, WriteSnapshot(filename, first))
+	require.NoError(t, WriteSnapshot(filename, second))
+
+	header, got, err := ReadSnapshot(filename)
+	require.NoError(t, err)
+	assert.Equal(t, SnapshotFormat, header.Format)
+	assert.Equal(t, SnapshotVersion, header.Version)
+	assert.False(t, header.Timestamp.IsZero())
+	assert.Equal(t, second, got)
+
+	matches, err := filepath.Glob(filename + tempSuffix + "*")
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestReadSnapshot(t *testing.T) {
+	valid := filepath.Join(t.TempDir(), "metrics.json")
+	require.NoError(t, WriteSnapshot(valid, entity.MetricsList{
+		{ID: "Counter1", MType: entity.Counter, Delta: utils.Ptr(int64(5))},
+		{ID: "Gauge1", MType: entity.Gauge, Value: utils.Ptr(1.5)},
+	}))
+	data, err := os.ReadFile(valid)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		data    string
+		want    entity.MetricsList
+		wantErr error
+	}{
+		{
+			name: "empty file",
+			data: "",
+		},
+		{
+			name:    "truncated",
+			data:    string(data[:len(data)-5]),
+			wantErr: ErrCorruptedSnapshot,
+		},
+		{
+			name:    "unsupported version",
+			data:    `{"format":"metrics-snapshot","version":99,"count":0,"checksum":0}` + "\n",
+			wantErr: ErrUnsupportedSnapshotVersion,
+		},
+		{
+			name: "legacy format",
+			data: `{"id":"Counter1","type":"counter","delta":1}` + "\n" +
+				`{"id":"Counter1","type":"counter","delta":3}` + "\n",
+			want: entity.MetricsList{
+				{ID: "Counter1", MType: entity.Counter, Delta: utils.Ptr(int64(3))},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "metrics.json")
+			require.NoError(t, os.WriteFile(filename, []byte(tt.data), 0666))
+
+			_, got, err := ReadSnapshot(filename)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRestoreMetrics(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "metrics.json")
+
+	store, err := storage.New(context.Background(), "")
+	require.NoError(t, err)
+
+	// Missing file is not an error.
+	require.NoError(t, RestoreMetrics(context.Background(), filename, store))
+
+	snapshot := entity.MetricsList{
+		{ID: "Counter1", MType: entity.Counter, Delta: utils.Ptr(int64(5))},
+	}
+	require.NoError(t, WriteSnapshot(filename, snapshot))
+
+	// Leftover of interrupted write must be ignored and removed.
+	stale := filename + tempSuffix + "123"
+	require.NoError(t, os.WriteFile(stale, []byte("garbage"), 0666))
+
+	require.NoError(t, RestoreMetrics(context.Background(), filename, store))
+
+	got, err := store.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, snapshot, got)
+
+	_, err = os.Stat(stale)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -1,59 +1,44 @@
 package file
 
 import (
-	"bufio"
 	"os"
-
-	"github.com/mailru/easyjson"
+	"path/filepath"
+	"sync"
 
 	"github.com/Imomali1/metrics/internal/entity"
 )
 
 type SyncFileWriter interface {
-	Write(batch entity.MetricsList) error
+	// Write replaces snapshot with metrics returned by snapshot.
+	Write(snapshot func() (entity.MetricsList, error)) error
 }
 
 type fileWriter struct {
-	file   *os.File
-	writer *bufio.Writer
+	mu       sync.Mutex
+	filename string
 }
 
 func NewSyncMetricsWriter(filename string) (SyncFileWriter, error) {
-	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
-	if err != nil {
+	if _, err := os.Stat(filepath.Dir(filename)); err != nil {
 		return nil, err
 	}
 
 	return &fileWriter{
-		file:   file,
-		writer: bufio.NewWriter(file),
+		filename: filename,
 	}, nil
 }
 
-func (f *fileWriter) Write(batch entity.MetricsList) error {
-	if err := f.file.Truncate(0); err != nil {
-		return err
-	}
+// Write replaces snapshot with metrics returned by snapshot. Metrics are
+// taken and written under the same lock, so that older snapshot never
+// replaces newer one.
+func (f *fileWriter) Write(snapshot func() (entity.MetricsList, error)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
-	if _, err := f.file.Seek(0, 0); err != nil {
+	metrics, err := snapshot()
+	if err != nil {
 		return err
 	}
 
-	for _, one := range batch {
-		data, err := easyjson.Marshal(one)
-		if err != nil {
-			return err
-		}
-
-		_, err = f.writer.Write(data)
-		if err != nil {
-			return err
-		}
-
-		if err = f.writer.WriteByte('\n'); err != nil {
-			return err
-		}
-	}
-
-	return f.writer.Flush()
+	return WriteSnapshot(f.filename, metrics)
 }
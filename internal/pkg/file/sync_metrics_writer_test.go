@@ -0,0 +1,66 @@
+package file
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/storage"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+func TestFileWriter_Write_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "metrics.json")
+
+	store, err := storage.NewMemory()
+	require.NoError(t, err)
+
+	writer, err := NewSyncMetricsWriter(filename)
+	require.NoError(t, err)
+
+	// every update is followed by sync write, the last write must see all updates
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := store.Update(ctx, entity.MetricsList{
+				{ID: "Counter1", MType: entity.Counter, Delta: utils.Ptr(int64(1))},
+				{ID: "Gauge1", MType: entity.Gauge, Value: utils.Ptr(float64(i))},
+			})
+			assert.NoError(t, err)
+
+			assert.NoError(t, writer.Write(func() (entity.MetricsList, error) {
+				return store.GetAll(ctx)
+			}))
+		}()
+	}
+	wg.Wait()
+
+	want, err := store.GetAll(ctx)
+	require.NoError(t, err)
+
+	_, got, err := ReadSnapshot(filename)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Equal(t, int64(50), *got[0].Delta)
+}
+
+func TestFileWriter_Write_Error(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "metrics.json")
+
+	writer, err := NewSyncMetricsWriter(filename)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, writer.Write(func() (entity.MetricsList, error) {
+		return nil, storage.ErrClosed
+	}), storage.ErrClosed)
+	assert.NoFileExists(t, filename)
+}
@@ -0,0 +1,164 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+const (
+	walOpUpdate    = "update"
+	walOpDelete    = "delete"
+	walOpDeleteAll = "delete_all"
+)
+
+// walRecord is a single change of storage. Records are stored one per
+// line prefixed with hex CRC-32 of the JSON payload.
+type walRecord struct {
+	Seq     uint64             `json:"seq"`
+	Op      string             `json:"op"`
+	Metrics entity.MetricsList `json:"metrics,omitempty"`
+	ID      string             `json:"id,omitempty"`
+	MType   string             `json:"type,omitempty"`
+	Labels  entity.Labels      `json:"labels,omitempty"`
+}
+
+// wal is append-only log of storage changes, callers serialize access.
+type wal struct {
+	file *os.File
+	size int64
+	seq  uint64
+}
+
+// readWAL returns records of WAL file and size of its consistent part.
+// Reading stops at the first torn or corrupted record, since it and
+// anything after it was never acknowledged.
+func readWAL(filename string) ([]walRecord, int64, error) {
+	f, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var (
+		records []walRecord
+		size    int64
+	)
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return records, size, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		rec, ok := decodeWALRecord(line[:len(line)-1])
+		if !ok {
+			return records, size, nil
+		}
+
+		records = append(records, rec)
+		size += int64(len(line))
+	}
+}
+
+func decodeWALRecord(line []byte) (walRecord, bool) {
+	sum, payload, ok := bytes.Cut(line, []byte{' '})
+	if !ok {
+		return walRecord{}, false
+	}
+
+	checksum, err := strconv.ParseUint(string(sum), 16, 32)
+	if err != nil || uint32(checksum) != crc32.ChecksumIEEE(payload) {
+		return walRecord{}, false
+	}
+
+	var rec walRecord
+	if err = json.Unmarshal(payload, &rec); err != nil {
+		return walRecord{}, false
+	}
+
+	return rec, true
+}
+
+// openWAL opens WAL file for appending, everything after size is discarded.
+// Records appended next get sequence numbers after seq.
+func openWAL(filename string, size int64, seq uint64) (*wal, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = f.Truncate(size); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if _, err = f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &wal{file: f, size: size, seq: seq}, nil
+}
+
+// append writes rec with the next sequence number and syncs it to disk.
+func (w *wal) append(rec walRecord) error {
+	rec.Seq = w.seq + 1
+
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	line := fmt.Appendf(nil, "%08x %s\n", crc32.ChecksumIEEE(payload), payload)
+	if _, err = w.file.Write(line); err == nil {
+		err = w.file.Sync()
+	}
+	if err != nil {
+		// Drop partially written record, otherwise it would hide
+		// records appended after it on replay.
+		return errors.Join(err, w.rewind())
+	}
+
+	w.size += int64(len(line))
+	w.seq = rec.Seq
+	return nil
+}
+
+func (w *wal) rewind() error {
+	if err := w.file.Truncate(w.size); err != nil {
+		return err
+	}
+
+	_, err := w.file.Seek(w.size, io.SeekStart)
+	return err
+}
+
+// truncate discards all records, sequence numbers keep growing.
+func (w *wal) truncate() error {
+	w.size = 0
+	if err := w.rewind(); err != nil {
+		return err
+	}
+
+	return w.file.Sync()
+}
+
+func (w *wal) close() error {
+	return w.file.Close()
+}
@@ -0,0 +1,160 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/storage"
+)
+
+// WALStorage logs every change to write-ahead log before applying it
+// to the underlying storage. Checkpoint writes snapshot of the storage
+// and truncates the log, so the state is snapshot plus log records
+// written after it.
+type WALStorage struct {
+	storage.Storage
+
+	mu       sync.Mutex
+	wal      *wal
+	snapshot string
+}
+
+// OpenWALStorage wraps store with write-ahead log in walPath, snapshotPath
+// keeps checkpoints. With restore the last checkpoint and the log are
+// replayed into store, otherwise both are reset.
+func OpenWALStorage(
+	ctx context.Context,
+	store storage.Storage,
+	snapshotPath, walPath string,
+	restore bool,
+) (*WALStorage, error) {
+	if err := removeTempFiles(snapshotPath); err != nil {
+		return nil, err
+	}
+
+	if !restore {
+		w, err := openWAL(walPath, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		s := &WALStorage{Storage: store, wal: w, snapshot: snapshotPath}
+		if err = s.Checkpoint(ctx); err != nil {
+			w.close()
+			return nil, err
+		}
+
+		return s, nil
+	}
+
+	header, metrics, err := ReadSnapshot(snapshotPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if len(metrics) != 0 {
+		if err = store.Update(ctx, metrics); err != nil {
+			return nil, err
+		}
+	}
+
+	records, size, err := readWAL(walPath)
+	if err != nil {
+		return nil, err
+	}
+
+	seq := header.WALSeq
+	for _, rec := range records {
+		// Records up to the checkpoint are already in the snapshot,
+		// they remain when the server stops before truncating the log.
+		if rec.Seq <= seq {
+			continue
+		}
+
+		replay(ctx, store, rec)
+		seq = rec.Seq
+	}
+
+	w, err := openWAL(walPath, size, seq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WALStorage{Storage: store, wal: w, snapshot: snapshotPath}, nil
+}
+
+// replay applies rec to store. A record is logged before it is applied,
+// so a change rejected by storage is in the log as well; replaying it on
+// the same state rejects it again, thus the error is ignored.
+func replay(ctx context.Context, store storage.Storage, rec walRecord) {
+	switch rec.Op {
+	case walOpUpdate:
+		_ = store.Update(ctx, rec.Metrics)
+	case walOpDelete:
+		_ = store.DeleteOne(ctx, rec.ID, rec.MType, rec.Labels)
+	case walOpDeleteAll:
+		_ = store.DeleteAll(ctx)
+	}
+}
+
+func (s *WALStorage) Update(ctx context.Context, batch entity.MetricsList) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.wal.append(walRecord{Op: walOpUpdate, Metrics: batch}); err != nil {
+		return err
+	}
+
+	return s.Storage.Update(ctx, batch)
+}
+
+func (s *WALStorage) DeleteOne(ctx context.Context, id, mType string, labels entity.Labels) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := walRecord{Op: walOpDelete, ID: id, MType: mType, Labels: labels}
+	if err := s.wal.append(rec); err != nil {
+		return err
+	}
+
+	return s.Storage.DeleteOne(ctx, id, mType, labels)
+}
+
+func (s *WALStorage) DeleteAll(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.wal.append(walRecord{Op: walOpDeleteAll}); err != nil {
+		return err
+	}
+
+	return s.Storage.DeleteAll(ctx)
+}
+
+// Checkpoint writes snapshot of the storage and truncates the log.
+func (s *WALStorage) Checkpoint(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metrics, err := s.Storage.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = writeSnapshot(s.snapshot, metrics, s.wal.seq); err != nil {
+		return err
+	}
+
+	return s.wal.truncate()
+}
+
+func (s *WALStorage) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.wal.close()
+	s.Storage.Close()
+}
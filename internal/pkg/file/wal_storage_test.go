@@ -0,0 +1,125 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/storage"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+func openTestWALStorage(t *testing.T, dir string, restore bool) *WALStorage {
+	t.Helper()
+
+	store, err := storage.NewMemory()
+	require.NoError(t, err)
+
+	s, err := OpenWALStorage(context.Background(), store,
+		filepath.Join(dir, "metrics.json"),
+		filepath.Join(dir, "metrics.wal"),
+		restore,
+	)
+	require.NoError(t, err)
+
+	return s
+}
+
+func counter(id string, delta int64) entity.Metrics {
+	return entity.Metrics{ID: id, MType: entity.Counter, Delta: utils.Ptr(delta)}
+}
+
+func gauge(id string, value float64) entity.Metrics {
+	return entity.Metrics{ID: id, MType: entity.Gauge, Value: utils.Ptr(value)}
+}
+
+func TestWALStorage_Replay(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	s := openTestWALStorage(t, dir, true)
+	require.NoError(t, s.Update(ctx, entity.MetricsList{counter("Counter1", 1), gauge("Gauge1", 1)}))
+	require.NoError(t, s.Checkpoint(ctx))
+	require.NoError(t, s.Update(ctx, entity.MetricsList{counter("Counter1", 2), gauge("Gauge2", 2)}))
+	require.NoError(t, s.DeleteOne(ctx, "Gauge1", entity.Gauge, nil))
+	// Not closed: simulate crash after the last acknowledged update.
+
+	restored := openTestWALStorage(t, dir, true)
+	got, err := restored.GetAll(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, entity.MetricsList{counter("Counter1", 3), gauge("Gauge2", 2)}, got)
+}
+
+func TestWALStorage_CheckpointNotTruncated(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	walPath := filepath.Join(dir, "metrics.wal")
+
+	s := openTestWALStorage(t, dir, true)
+	require.NoError(t, s.Update(ctx, entity.MetricsList{counter("Counter1", 5)}))
+
+	data, err := os.ReadFile(walPath)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Checkpoint(ctx))
+
+	// Server stopped after writing snapshot but before truncating the log.
+	require.NoError(t, os.WriteFile(walPath, data, 0666))
+
+	restored := openTestWALStorage(t, dir, true)
+	got, err := restored.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entity.MetricsList{counter("Counter1", 5)}, got)
+
+	// Sequence continues after the checkpoint, so new records are replayed.
+	require.NoError(t, restored.Update(ctx, entity.MetricsList{counter("Counter1", 1)}))
+
+	again := openTestWALStorage(t, dir, true)
+	got, err = again.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entity.MetricsList{counter("Counter1", 6)}, got)
+}
+
+func TestWALStorage_TornRecord(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	walPath := filepath.Join(dir, "metrics.wal")
+
+	s := openTestWALStorage(t, dir, true)
+	require.NoError(t, s.Update(ctx, entity.MetricsList{counter("Counter1", 1)}))
+
+	f, err := os.OpenFile(walPath, os.O_WRONLY|os.O_APPEND, 0666)
+	require.NoError(t, err)
+	_, err = f.WriteString(`0badc0de {"seq":2,"op":"upd`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	restored := openTestWALStorage(t, dir, true)
+	require.NoError(t, restored.Update(ctx, entity.MetricsList{counter("Counter1", 10)}))
+
+	again := openTestWALStorage(t, dir, true)
+	got, err := again.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entity.MetricsList{counter("Counter1", 11)}, got)
+}
+
+func TestWALStorage_WithoutRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	s := openTestWALStorage(t, dir, true)
+	require.NoError(t, s.Update(ctx, entity.MetricsList{counter("Counter1", 1)}))
+
+	fresh := openTestWALStorage(t, dir, false)
+	require.NoError(t, fresh.Update(ctx, entity.MetricsList{gauge("Gauge1", 1)}))
+
+	restored := openTestWALStorage(t, dir, true)
+	got, err := restored.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entity.MetricsList{gauge("Gauge1", 1)}, got)
+}
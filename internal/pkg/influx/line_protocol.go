@@ -0,0 +1,247 @@
+// Package influx parses InfluxDB line protocol.
+package influx
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+var (
+	ErrInvalidLine      = errors.New("invalid line protocol")
+	ErrInvalidPrecision = errors.New("invalid precision")
+)
+
+// FieldType is type of field value.
+type FieldType int
+
+const (
+	FieldFloat FieldType = iota
+	FieldInteger
+	FieldUnsigned
+	FieldBoolean
+	FieldString
+)
+
+type Field struct {
+	Key   string
+	Type  FieldType
+	Value string
+}
+
+// Point is single line of line protocol:
+// <measurement>[,<tag>=<value>...] <field>=<value>[,<field>=<value>...] [<timestamp>]
+type Point struct {
+	Measurement string
+	Tags        entity.Labels
+	Fields      []Field
+	// Time is zero when timestamp is omitted.
+	Time time.Time
+}
+
+// Parse parses points from data, empty lines and comments are skipped.
+// Precision is one of ns, us, ms, s, empty precision means ns.
+func Parse(data []byte, precision string) ([]Point, error) {
+	unit, err := precisionUnit(precision)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []Point
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		point, err := parseLine(line, unit)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidLine, n, err)
+		}
+
+		points = append(points, point)
+	}
+
+	return points, scanner.Err()
+}
+
+func precisionUnit(precision string) (time.Duration, error) {
+	switch precision {
+	case "", "ns":
+		return time.Nanosecond, nil
+	case "us":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrInvalidPrecision, precision)
+	}
+}
+
+func parseLine(line string, unit time.Duration) (Point, error) {
+	// series ends at the first unescaped space, quotes are not special there
+	seriesEnd := index(line, ' ')
+	if seriesEnd < 0 {
+		return Point{}, errors.New("missing fields")
+	}
+
+	sections := split(line[seriesEnd+1:], ' ', true)
+	if len(sections) > 2 {
+		return Point{}, errors.New("unexpected data after timestamp")
+	}
+
+	var point Point
+
+	series := split(line[:seriesEnd], ',', false)
+	point.Measurement = unescape(series[0])
+	if point.Measurement == "" {
+		return Point{}, errors.New("missing measurement")
+	}
+
+	for _, tag := range series[1:] {
+		key, value, err := keyValue(tag)
+		if err != nil {
+			return Point{}, fmt.Errorf("tag %q: %w", tag, err)
+		}
+
+		if point.Tags == nil {
+			point.Tags = make(entity.Labels)
+		}
+		point.Tags[key] = unescape(value)
+	}
+
+	for _, field := range split(sections[0], ',', true) {
+		key, value, err := keyValue(field)
+		if err != nil {
+			return Point{}, fmt.Errorf("field %q: %w", field, err)
+		}
+
+		parsed, err := parseField(key, value)
+		if err != nil {
+			return Point{}, fmt.Errorf("field %q: %w", field, err)
+		}
+
+		point.Fields = append(point.Fields, parsed)
+	}
+
+	if len(sections) == 2 {
+		ts, err := strconv.ParseInt(sections[1], 10, 64)
+		if err != nil {
+			return Point{}, fmt.Errorf("invalid timestamp %q", sections[1])
+		}
+		point.Time = time.Unix(0, ts*int64(unit))
+	}
+
+	return point, nil
+}
+
+func keyValue(s string) (string, string, error) {
+	i := index(s, '=')
+	if i < 0 {
+		return "", "", errors.New("missing '='")
+	}
+
+	key := unescape(s[:i])
+	if key == "" {
+		return "", "", errors.New("missing key")
+	}
+
+	return key, s[i+1:], nil
+}
+
+func parseField(key, value string) (Field, error) {
+	field := Field{Key: key}
+
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		field.Type = FieldString
+		field.Value = strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+		return field, nil
+	case strings.HasSuffix(value, "i"):
+		field.Type, field.Value = FieldInteger, strings.TrimSuffix(value, "i")
+		_, err := strconv.ParseInt(field.Value, 10, 64)
+		if err != nil {
+			return Field{}, errors.New("invalid integer")
+		}
+	case strings.HasSuffix(value, "u"):
+		field.Type, field.Value = FieldUnsigned, strings.TrimSuffix(value, "u")
+		_, err := strconv.ParseUint(field.Value, 10, 64)
+		if err != nil {
+			return Field{}, errors.New("invalid unsigned integer")
+		}
+	default:
+		switch value {
+		case "t", "T", "true", "True", "TRUE":
+			field.Type, field.Value = FieldBoolean, "true"
+			return field, nil
+		case "f", "F", "false", "False", "FALSE":
+			field.Type, field.Value = FieldBoolean, "false"
+			return field, nil
+		}
+
+		field.Type, field.Value = FieldFloat, value
+		_, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return Field{}, errors.New("invalid float")
+		}
+	}
+
+	return field, nil
+}
+
+// index returns index of the first unescaped sep in s or -1.
+func index(s string, sep byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			return i
+		}
+	}
+	return -1
+}
+
+// split splits s by unescaped sep, separators inside double-quoted
+// strings are skipped when quotes is set.
+func split(s string, sep byte, quotes bool) []string {
+	var (
+		parts  []string
+		start  int
+		quoted bool
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			quoted = quotes && !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+var unescaper = strings.NewReplacer(`\,`, ",", `\=`, "=", `\ `, " ", `\"`, `"`, `\\`, `\`)
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
@@ -0,0 +1,119 @@
+package influx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		precision string
+		want      []Point
+		wantErr   error
+	}{
+		{
+			name: "fields of every type",
+			data: `cpu usage=1.5,count=2i,total=3u,busy=t,note="a b"`,
+			want: []Point{{
+				Measurement: "cpu",
+				Fields: []Field{
+					{Key: "usage", Type: FieldFloat, Value: "1.5"},
+					{Key: "count", Type: FieldInteger, Value: "2"},
+					{Key: "total", Type: FieldUnsigned, Value: "3"},
+					{Key: "busy", Type: FieldBoolean, Value: "true"},
+					{Key: "note", Type: FieldString, Value: "a b"},
+				},
+			}},
+		},
+		{
+			name:      "tags and timestamp",
+			data:      "cpu,host=a,core=0 usage=1 1700000000000",
+			precision: "ms",
+			want: []Point{{
+				Measurement: "cpu",
+				Tags:        entity.Labels{"host": "a", "core": "0"},
+				Fields:      []Field{{Key: "usage", Type: FieldFloat, Value: "1"}},
+				Time:        time.UnixMilli(1700000000000),
+			}},
+		},
+		{
+			name: "escaped characters",
+			data: `disk\ io,path=/var\,log,name=a\=b value=1,msg="say \"hi\""`,
+			want: []Point{{
+				Measurement: "disk io",
+				Tags:        entity.Labels{"path": "/var,log", "name": "a=b"},
+				Fields: []Field{
+					{Key: "value", Type: FieldFloat, Value: "1"},
+					{Key: "msg", Type: FieldString, Value: `say "hi"`},
+				},
+			}},
+		},
+		{
+			name: "comments and empty lines",
+			data: "# comment\n\nmem used=1i\n",
+			want: []Point{{
+				Measurement: "mem",
+				Fields:      []Field{{Key: "used", Type: FieldInteger, Value: "1"}},
+			}},
+		},
+		{name: "missing fields", data: "cpu,host=a", wantErr: ErrInvalidLine},
+		{name: "missing field value", data: "cpu usage", wantErr: ErrInvalidLine},
+		{name: "invalid integer", data: "cpu count=1.5i", wantErr: ErrInvalidLine},
+		{name: "invalid float", data: "cpu usage=abc", wantErr: ErrInvalidLine},
+		{name: "invalid timestamp", data: "cpu usage=1 now", wantErr: ErrInvalidLine},
+		{name: "invalid tag", data: "cpu,host usage=1", wantErr: ErrInvalidLine},
+		{name: "invalid precision", data: "cpu usage=1", precision: "h", wantErr: ErrInvalidPrecision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.data), tt.precision)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestToMetrics(t *testing.T) {
+	points := []Point{{
+		Measurement: "cpu",
+		Tags:        entity.Labels{"host": "a"},
+		Fields: []Field{
+			{Key: "usage", Type: FieldFloat, Value: "1.5"},
+			{Key: "count", Type: FieldInteger, Value: "-2"},
+			{Key: "total", Type: FieldUnsigned, Value: "3"},
+			{Key: "busy", Type: FieldBoolean, Value: "true"},
+			{Key: "note", Type: FieldString, Value: "skipped"},
+		},
+	}}
+
+	got, err := ToMetrics(points)
+	require.NoError(t, err)
+
+	labels := entity.Labels{"host": "a"}
+	assert.Equal(t, entity.MetricsList{
+		{ID: "cpu_usage", MType: entity.Gauge, Value: utils.Ptr(1.5), Labels: labels},
+		{ID: "cpu_count", MType: entity.Counter, Delta: utils.Ptr(int64(-2)), Labels: labels},
+		{ID: "cpu_total", MType: entity.Counter, Delta: utils.Ptr(int64(3)), Labels: labels},
+		{ID: "cpu_busy", MType: entity.Gauge, Value: utils.Ptr(1.0), Labels: labels},
+	}, got)
+
+	_, err = ToMetrics([]Point{{
+		Measurement: "cpu",
+		Fields:      []Field{{Key: "total", Type: FieldUnsigned, Value: "18446744073709551615"}},
+	}})
+	require.Error(t, err)
+}
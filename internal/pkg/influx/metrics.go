@@ -0,0 +1,60 @@
+package influx
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+// ToMetrics converts points to metrics named <measurement>_<field> with tags
+// as labels. Integer fields become counters, float fields become gauges,
+// booleans become gauges with value 0 or 1, string fields are skipped.
+// Timestamps are dropped, storages record the time metrics are received.
+func ToMetrics(points []Point) (entity.MetricsList, error) {
+	var list entity.MetricsList
+
+	for _, point := range points {
+		for _, field := range point.Fields {
+			metric := entity.Metrics{
+				ID:     point.Measurement + "_" + field.Key,
+				Labels: point.Tags.Clone(),
+			}
+
+			switch field.Type {
+			case FieldInteger:
+				delta, err := strconv.ParseInt(field.Value, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("field %s: %w", metric.ID, err)
+				}
+				metric.MType, metric.Delta = entity.Counter, &delta
+			case FieldUnsigned:
+				delta, err := strconv.ParseUint(field.Value, 10, 64)
+				if err != nil || delta > math.MaxInt64 {
+					return nil, fmt.Errorf("field %s: unsigned value %s overflows counter", metric.ID, field.Value)
+				}
+				metric.MType, metric.Delta = entity.Counter, utils.Ptr(int64(delta))
+			case FieldFloat:
+				value, err := strconv.ParseFloat(field.Value, 64)
+				if err != nil {
+					return nil, fmt.Errorf("field %s: %w", metric.ID, err)
+				}
+				metric.MType, metric.Value = entity.Gauge, &value
+			case FieldBoolean:
+				var value float64
+				if field.Value == "true" {
+					value = 1
+				}
+				metric.MType, metric.Value = entity.Gauge, &value
+			default:
+				continue
+			}
+
+			list = append(list, metric)
+		}
+	}
+
+	return list, nil
+}
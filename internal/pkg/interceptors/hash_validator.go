@@ -0,0 +1,151 @@
+package interceptors
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+// ValidateHash checks hash of signed requests like middlewares.ValidateHash does,
+// responses of unary calls are signed with hash sent in header metadata.
+func ValidateHash(l logger.Logger, key string) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		_ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if key == "" {
+			return handler(ctx, req)
+		}
+
+		if err := validateHash(l, req, key); err != nil {
+			return nil, err
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		if msg, ok := resp.(proto.Message); ok {
+			data, errMarshal := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+			if errMarshal == nil {
+				_ = grpc.SetHeader(ctx, metadata.Pairs(hashMetadataKey, utils.GenerateHash(data, key)))
+			}
+		}
+
+		return resp, nil
+	}
+}
+
+// ValidateHashStream checks hash of every signed message received from stream.
+func ValidateHashStream(l logger.Logger, key string) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		_ *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if key == "" {
+			return handler(srv, ss)
+		}
+
+		return handler(srv, &recvStream{
+			ServerStream: ss,
+			onRecv: func(msg any) error {
+				return validateHash(l, msg, key)
+			},
+		})
+	}
+}
+
+func validateHash(l logger.Logger, req any, key string) error {
+	msg, ok := req.(signedMessage)
+	if !ok || msg.GetHash() == "" {
+		return nil
+	}
+
+	data, err := marshalWithoutHash(msg)
+	if err != nil {
+		l.Logger.Info().Err(err).Msg("could not marshal request")
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	if utils.GenerateHash(data, key) != msg.GetHash() {
+		l.Logger.Info().Msg("request data not validated")
+		return status.Error(codes.InvalidArgument, "request data not validated")
+	}
+
+	return nil
+}
+
+// SignRequest sets hash of outgoing signed requests, it is client side pair of ValidateHash.
+func SignRequest(key string) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if key == "" {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		signed, err := sign(req, key)
+		if err != nil {
+			return err
+		}
+
+		return invoker(ctx, method, signed, reply, cc, opts...)
+	}
+}
+
+// SignRequestStream sets hash of every signed message sent to stream.
+func SignRequestStream(key string) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil || key == "" {
+			return cs, err
+		}
+
+		return &sendStream{
+			ClientStream: cs,
+			onSend: func(msg any) (any, error) {
+				return sign(msg, key)
+			},
+		}, nil
+	}
+}
+
+func sign(req any, key string) (any, error) {
+	msg, ok := req.(signedMessage)
+	if !ok {
+		return req, nil
+	}
+
+	data, err := marshalWithoutHash(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := proto.Clone(msg)
+	setField(signed, hashField, utils.GenerateHash(data, key))
+	return signed, nil
+}
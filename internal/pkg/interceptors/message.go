@@ -0,0 +1,50 @@
+package interceptors
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// hashField holds hash of the message serialized with empty hashField.
+	hashField protoreflect.Name = "hash"
+	// encryptedField holds serialized message encrypted with cipher.EncryptHybrid.
+	encryptedField protoreflect.Name = "encrypted"
+
+	hashMetadataKey = "hashsha256"
+)
+
+// signedMessage is implemented by requests that can carry hash, see pb.UpdateMetricsRequest.
+type signedMessage interface {
+	proto.Message
+	GetHash() string
+}
+
+// encryptedMessage is implemented by requests that can be sent encrypted, see pb.UpdateMetricsRequest.
+type encryptedMessage interface {
+	proto.Message
+	GetEncrypted() []byte
+}
+
+// marshalWithoutHash serializes message as it was before signing.
+// Serialization is deterministic, so both sides get the same bytes.
+func marshalWithoutHash(msg proto.Message) ([]byte, error) {
+	unsigned := proto.Clone(msg)
+	setField(unsigned, hashField, nil)
+	return proto.MarshalOptions{Deterministic: true}.Marshal(unsigned)
+}
+
+// setField sets or clears (when value is nil) message field by name.
+func setField(msg proto.Message, name protoreflect.Name, value any) {
+	m := msg.ProtoReflect()
+	fd := m.Descriptor().Fields().ByName(name)
+	if fd == nil {
+		return
+	}
+
+	if value == nil {
+		m.Clear(fd)
+		return
+	}
+	m.Set(fd, protoreflect.ValueOf(value))
+}
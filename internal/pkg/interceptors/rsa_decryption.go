@@ -0,0 +1,146 @@
+package interceptors
+
+import (
+	"context"
+	"crypto/rsa"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Imomali1/metrics/internal/pkg/cipher"
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+)
+
+// RSADecrypt replaces encrypted requests with their decrypted content
+// like middlewares.RSADecrypt does, plain requests that could be encrypted are rejected.
+func RSADecrypt(l logger.Logger, privateKey *rsa.PrivateKey) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		_ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if privateKey == nil {
+			return handler(ctx, req)
+		}
+
+		if err := decrypt(l, req, privateKey); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// RSADecryptStream decrypts every encrypted message received from stream.
+func RSADecryptStream(l logger.Logger, privateKey *rsa.PrivateKey) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		_ *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if privateKey == nil {
+			return handler(srv, ss)
+		}
+
+		return handler(srv, &recvStream{
+			ServerStream: ss,
+			onRecv: func(msg any) error {
+				return decrypt(l, msg, privateKey)
+			},
+		})
+	}
+}
+
+func decrypt(l logger.Logger, req any, privateKey *rsa.PrivateKey) error {
+	msg, ok := req.(encryptedMessage)
+	if !ok {
+		return nil
+	}
+
+	decryptedData, err := cipher.Decrypt(privateKey, msg.GetEncrypted())
+	if err != nil {
+		l.Logger.Info().Err(err).Msg("failed to decrypt data")
+		return status.Error(codes.InvalidArgument, "invalid body")
+	}
+
+	proto.Reset(msg)
+	if err = proto.Unmarshal(decryptedData, msg); err != nil {
+		l.Logger.Info().Err(err).Msg("failed to unmarshal decrypted data")
+		return status.Error(codes.InvalidArgument, "invalid body")
+	}
+
+	return nil
+}
+
+// EncryptRequest encrypts outgoing requests that can be sent encrypted,
+// it is client side pair of RSADecrypt.
+func EncryptRequest(publicKey *rsa.PublicKey) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if publicKey == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		encrypted, err := encrypt(req, publicKey)
+		if err != nil {
+			return err
+		}
+
+		return invoker(ctx, method, encrypted, reply, cc, opts...)
+	}
+}
+
+// EncryptRequestStream encrypts every message sent to stream.
+func EncryptRequestStream(publicKey *rsa.PublicKey) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil || publicKey == nil {
+			return cs, err
+		}
+
+		return &sendStream{
+			ClientStream: cs,
+			onSend: func(msg any) (any, error) {
+				return encrypt(msg, publicKey)
+			},
+		}, nil
+	}
+}
+
+func encrypt(req any, publicKey *rsa.PublicKey) (any, error) {
+	msg, ok := req.(encryptedMessage)
+	if !ok {
+		return req, nil
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope, err := cipher.EncryptHybrid(publicKey, data)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := msg.ProtoReflect().New().Interface()
+	setField(encrypted, encryptedField, envelope)
+	return encrypted, nil
+}
@@ -0,0 +1,30 @@
+package interceptors
+
+import "google.golang.org/grpc"
+
+// recvStream calls onRecv for every message received by server.
+type recvStream struct {
+	grpc.ServerStream
+	onRecv func(msg any) error
+}
+
+func (s *recvStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.onRecv(m)
+}
+
+// sendStream replaces every message sent by client with result of onSend.
+type sendStream struct {
+	grpc.ClientStream
+	onSend func(msg any) (any, error)
+}
+
+func (s *sendStream) SendMsg(m any) error {
+	msg, err := s.onSend(m)
+	if err != nil {
+		return err
+	}
+	return s.ClientStream.SendMsg(msg)
+}
@@ -0,0 +1,102 @@
+package interceptors
+
+import (
+	"context"
+	"net"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+)
+
+const realIPMetadataKey = "x-real-ip"
+
+// TrustedSubnet rejects calls of methods whose x-real-ip metadata is missing
+// or outside of subnet like middlewares.TrustedSubnet does, nil subnet allows every call.
+func TrustedSubnet(l logger.Logger, subnet *net.IPNet, methods ...string) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if subnet != nil && slices.Contains(methods, info.FullMethod) {
+			if err := checkRealIP(ctx, l, subnet); err != nil {
+				return nil, err
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// TrustedSubnetStream is stream version of TrustedSubnet.
+func TrustedSubnetStream(l logger.Logger, subnet *net.IPNet, methods ...string) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if subnet != nil && slices.Contains(methods, info.FullMethod) {
+			if err := checkRealIP(ss.Context(), l, subnet); err != nil {
+				return err
+			}
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+func checkRealIP(ctx context.Context, l logger.Logger, subnet *net.IPNet) error {
+	var realIP string
+	if values := metadata.ValueFromIncomingContext(ctx, realIPMetadataKey); len(values) != 0 {
+		realIP = values[0]
+	}
+
+	ip := net.ParseIP(realIP)
+	if ip == nil || !subnet.Contains(ip) {
+		l.Logger.Info().Msgf("request from untrusted ip %q", realIP)
+		return status.Error(codes.PermissionDenied, "untrusted ip")
+	}
+
+	return nil
+}
+
+// RealIP adds x-real-ip metadata to every outgoing call, empty ip adds nothing.
+func RealIP(ip string) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if ip != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, realIPMetadataKey, ip)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// RealIPStream is stream version of RealIP.
+func RealIPStream(ip string) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		if ip != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, realIPMetadataKey, ip)
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
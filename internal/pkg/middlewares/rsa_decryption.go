@@ -13,6 +13,8 @@ import (
 	"github.com/Imomali1/metrics/internal/pkg/utils"
 )
 
+// RSADecrypt decrypts request body encrypted either with hybrid
+// envelope (see cipher.EncryptHybrid) or with legacy cipher.EncryptRSA.
 func RSADecrypt(l logger.Logger, privateKey *rsa.PrivateKey) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		if privateKey == nil {
@@ -26,7 +28,13 @@ func RSADecrypt(l logger.Logger, privateKey *rsa.PrivateKey) gin.HandlerFunc {
 			return
 		}
 
-		decryptedData, err := cipher.DecryptRSA(privateKey, data)
+		if len(data) == 0 {
+			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(data))
+			ctx.Next()
+			return
+		}
+
+		decryptedData, err := cipher.Decrypt(privateKey, data)
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]string{"error": "invalid body"})
 			l.Logger.Info().Err(err).Msg("failed to decrypt data")
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+)
+
+// TrustedSubnet rejects requests whose X-Real-IP header
+// is missing or outside of subnet, nil subnet allows every request.
+func TrustedSubnet(l logger.Logger, subnet *net.IPNet) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if subnet == nil {
+			return
+		}
+
+		realIP := ctx.GetHeader("X-Real-IP")
+
+		ip := net.ParseIP(realIP)
+		if ip == nil || !subnet.Contains(ip) {
+			ctx.AbortWithStatus(http.StatusForbidden)
+			l.Logger.Info().Msgf("request from untrusted ip %q", realIP)
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,103 @@
+package pb
+
+import "github.com/Imomali1/metrics/internal/entity"
+
+// FromEntity converts entity.Metrics to its protobuf representation.
+func FromEntity(metric entity.Metrics) *Metric {
+	return &Metric{
+		Id:        metric.ID,
+		Type:      metric.MType,
+		Delta:     metric.Delta,
+		Value:     metric.Value,
+		Histogram: FromEntityHistogram(metric.Histogram),
+		Summary:   FromEntitySummary(metric.Summary),
+		Labels:    metric.Labels.Clone(),
+	}
+}
+
+// ToEntity converts protobuf metric to entity.Metrics.
+func ToEntity(metric *Metric) entity.Metrics {
+	return entity.Metrics{
+		ID:        metric.GetId(),
+		MType:     metric.GetType(),
+		Delta:     metric.Delta,
+		Value:     metric.Value,
+		Histogram: ToEntityHistogram(metric.GetHistogram()),
+		Summary:   ToEntitySummary(metric.GetSummary()),
+		Labels:    entity.Labels(metric.GetLabels()).Clone(),
+	}
+}
+
+// FromEntityHistogram converts entity.HistogramValue to its protobuf representation.
+func FromEntityHistogram(histogram *entity.HistogramValue) *Histogram {
+	if histogram == nil {
+		return nil
+	}
+
+	buckets := make([]*Bucket, len(histogram.Buckets))
+	for i, bucket := range histogram.Buckets {
+		buckets[i] = &Bucket{UpperBound: bucket.UpperBound, Count: bucket.Count}
+	}
+
+	return &Histogram{Buckets: buckets, Sum: histogram.Sum, Count: histogram.Count}
+}
+
+// ToEntityHistogram converts protobuf histogram to entity.HistogramValue.
+func ToEntityHistogram(histogram *Histogram) *entity.HistogramValue {
+	if histogram == nil {
+		return nil
+	}
+
+	var buckets []entity.Bucket
+	for _, bucket := range histogram.GetBuckets() {
+		buckets = append(buckets, entity.Bucket{UpperBound: bucket.GetUpperBound(), Count: bucket.GetCount()})
+	}
+
+	return &entity.HistogramValue{Buckets: buckets, Sum: histogram.GetSum(), Count: histogram.GetCount()}
+}
+
+// FromEntitySummary converts entity.SummaryValue to its protobuf representation.
+func FromEntitySummary(summary *entity.SummaryValue) *Summary {
+	if summary == nil {
+		return nil
+	}
+
+	quantiles := make([]*Quantile, len(summary.Quantiles))
+	for i, quantile := range summary.Quantiles {
+		quantiles[i] = &Quantile{Quantile: quantile.Quantile, Value: quantile.Value}
+	}
+
+	return &Summary{Quantiles: quantiles, Sum: summary.Sum, Count: summary.Count}
+}
+
+// ToEntitySummary converts protobuf summary to entity.SummaryValue.
+func ToEntitySummary(summary *Summary) *entity.SummaryValue {
+	if summary == nil {
+		return nil
+	}
+
+	var quantiles []entity.Quantile
+	for _, quantile := range summary.GetQuantiles() {
+		quantiles = append(quantiles, entity.Quantile{Quantile: quantile.GetQuantile(), Value: quantile.GetValue()})
+	}
+
+	return &entity.SummaryValue{Quantiles: quantiles, Sum: summary.GetSum(), Count: summary.GetCount()}
+}
+
+// FromEntityList converts list of metrics to protobuf representation.
+func FromEntityList(list entity.MetricsList) []*Metric {
+	metrics := make([]*Metric, len(list))
+	for i, metric := range list {
+		metrics[i] = FromEntity(metric)
+	}
+	return metrics
+}
+
+// ToEntityList converts protobuf metrics to entity.MetricsList.
+func ToEntityList(metrics []*Metric) entity.MetricsList {
+	list := make(entity.MetricsList, len(metrics))
+	for i, metric := range metrics {
+		list[i] = ToEntity(metric)
+	}
+	return list
+}
@@ -0,0 +1,244 @@
+package prometheus
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+// ContentType is content type of Prometheus text exposition format 0.0.4.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type family struct {
+	name    string
+	mType   string
+	metrics entity.MetricsList
+}
+
+// WriteText writes metrics in Prometheus text exposition format.
+// Families are sorted by name and series inside family by labels,
+// metrics whose sanitized name is already taken by a family
+// of another type are skipped.
+func WriteText(w io.Writer, list entity.MetricsList) error {
+	families := make(map[string]*family)
+	for _, metric := range list {
+		if !entity.IsValidType(metric.MType) {
+			continue
+		}
+
+		name := SanitizeName(metric.ID)
+		f, ok := families[name]
+		if !ok {
+			f = &family{name: name, mType: metric.MType}
+			families[name] = f
+		}
+
+		if f.mType != metric.MType {
+			continue
+		}
+
+		f.metrics = append(f.metrics, metric)
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		f := families[name]
+		sort.SliceStable(f.metrics, func(i, j int) bool {
+			return f.metrics[i].Labels.String() < f.metrics[j].Labels.String()
+		})
+
+		bw.WriteString("# TYPE ")
+		bw.WriteString(f.name)
+		bw.WriteByte(' ')
+		bw.WriteString(f.mType)
+		bw.WriteByte('\n')
+
+		for _, metric := range f.metrics {
+			if f.mType == entity.Histogram {
+				writeHistogram(bw, f.name, metric)
+				continue
+			}
+			if f.mType == entity.Summary {
+				writeSummary(bw, f.name, metric)
+				continue
+			}
+
+			bw.WriteString(f.name)
+			writeLabels(bw, metric.Labels)
+			bw.WriteByte(' ')
+			bw.WriteString(formatValue(metric))
+			bw.WriteByte('\n')
+		}
+	}
+
+	return bw.Flush()
+}
+
+// writeHistogram writes series of histogram:
+// cumulative <name>_bucket with le label, <name>_sum and <name>_count.
+func writeHistogram(bw *bufio.Writer, name string, metric entity.Metrics) {
+	histogram := metric.Histogram
+	if histogram == nil {
+		histogram = &entity.HistogramValue{}
+	}
+
+	bucketLabels := metric.Labels.Clone()
+	if bucketLabels == nil {
+		bucketLabels = make(entity.Labels, 1)
+	}
+
+	writeBucket := func(le string, count uint64) {
+		bucketLabels[entity.BucketLabel] = le
+		bw.WriteString(name)
+		bw.WriteString("_bucket")
+		writeLabels(bw, bucketLabels)
+		bw.WriteByte(' ')
+		bw.WriteString(strconv.FormatUint(count, 10))
+		bw.WriteByte('\n')
+	}
+
+	for _, bucket := range histogram.Buckets {
+		writeBucket(strconv.FormatFloat(bucket.UpperBound, 'g', -1, 64), bucket.Count)
+	}
+	writeBucket("+Inf", histogram.Count)
+
+	writeSumCount(bw, name, metric.Labels, histogram.Sum, histogram.Count)
+}
+
+// writeSummary writes series of summary:
+// <name> with quantile label, <name>_sum and <name>_count.
+func writeSummary(bw *bufio.Writer, name string, metric entity.Metrics) {
+	summary := metric.Summary
+	if summary == nil {
+		summary = &entity.SummaryValue{}
+	}
+
+	quantileLabels := metric.Labels.Clone()
+	if quantileLabels == nil {
+		quantileLabels = make(entity.Labels, 1)
+	}
+
+	for _, quantile := range summary.Quantiles {
+		quantileLabels[entity.QuantileLabel] = strconv.FormatFloat(quantile.Quantile, 'g', -1, 64)
+		bw.WriteString(name)
+		writeLabels(bw, quantileLabels)
+		bw.WriteByte(' ')
+		bw.WriteString(strconv.FormatFloat(quantile.Value, 'g', -1, 64))
+		bw.WriteByte('\n')
+	}
+
+	writeSumCount(bw, name, metric.Labels, summary.Sum, summary.Count)
+}
+
+// writeSumCount writes <name>_sum and <name>_count of histogram or summary.
+func writeSumCount(bw *bufio.Writer, name string, labels entity.Labels, sum float64, count uint64) {
+	bw.WriteString(name)
+	bw.WriteString("_sum")
+	writeLabels(bw, labels)
+	bw.WriteByte(' ')
+	bw.WriteString(strconv.FormatFloat(sum, 'g', -1, 64))
+	bw.WriteByte('\n')
+
+	bw.WriteString(name)
+	bw.WriteString("_count")
+	writeLabels(bw, labels)
+	bw.WriteByte(' ')
+	bw.WriteString(strconv.FormatUint(count, 10))
+	bw.WriteByte('\n')
+}
+
+// SanitizeName converts metric name to match Prometheus
+// metric name pattern [a-zA-Z_:][a-zA-Z0-9_:]*.
+func SanitizeName(name string) string {
+	return sanitize(name, true)
+}
+
+// SanitizeLabelName converts label name to match Prometheus
+// label name pattern [a-zA-Z_][a-zA-Z0-9_]*.
+func SanitizeLabelName(name string) string {
+	return sanitize(name, false)
+}
+
+// sanitize replaces invalid characters with underscore,
+// colon is valid only in metric names.
+func sanitize(name string, allowColon bool) string {
+	if name == "" {
+		return "_"
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(name) + 1)
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':' && allowColon:
+			sb.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+
+	return sb.String()
+}
+
+// writeLabels writes labels sorted by name. Labels whose sanitized
+// name collides with already written one are skipped, so that every
+// label name occurs in series once.
+func writeLabels(bw *bufio.Writer, labels entity.Labels) {
+	if len(labels) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	written := make(map[string]struct{}, len(names))
+	bw.WriteByte('{')
+	for _, name := range names {
+		sanitized := SanitizeLabelName(name)
+		if _, ok := written[sanitized]; ok {
+			continue
+		}
+
+		if len(written) > 0 {
+			bw.WriteByte(',')
+		}
+		written[sanitized] = struct{}{}
+
+		bw.WriteString(sanitized)
+		bw.WriteString(`="`)
+		bw.WriteString(labelValueReplacer.Replace(labels[name]))
+		bw.WriteByte('"')
+	}
+	bw.WriteByte('}')
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatValue(metric entity.Metrics) string {
+	switch {
+	case metric.MType == entity.Counter && metric.Delta != nil:
+		return strconv.FormatInt(*metric.Delta, 10)
+	case metric.MType == entity.Gauge && metric.Value != nil:
+		return strconv.FormatFloat(*metric.Value, 'g', -1, 64)
+	default:
+		return "0"
+	}
+}
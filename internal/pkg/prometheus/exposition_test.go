@@ -0,0 +1,196 @@
+package prometheus
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "valid name",
+			input: "HeapAlloc",
+			want:  "HeapAlloc",
+		},
+		{
+			name:  "name with colon and underscore",
+			input: "http:requests_total",
+			want:  "http:requests_total",
+		},
+		{
+			name:  "name with invalid characters",
+			input: "cpu.util-1 %",
+			want:  "cpu_util_1__",
+		},
+		{
+			name:  "name starting with digit",
+			input: "1minute",
+			want:  "_1minute",
+		},
+		{
+			name:  "empty name",
+			input: "",
+			want:  "_",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, SanitizeName(tt.input))
+		})
+	}
+}
+
+func TestSanitizeLabelName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "valid name",
+			input: "host_name",
+			want:  "host_name",
+		},
+		{
+			name:  "name with colon",
+			input: "k8s:pod",
+			want:  "k8s_pod",
+		},
+		{
+			name:  "name starting with digit",
+			input: "1zone",
+			want:  "_1zone",
+		},
+		{
+			name:  "empty name",
+			input: "",
+			want:  "_",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, SanitizeLabelName(tt.input))
+		})
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	tests := []struct {
+		name string
+		list entity.MetricsList
+		want string
+	}{
+		{
+			name: "empty list",
+			list: entity.MetricsList{},
+			want: "",
+		},
+		{
+			name: "sorted families",
+			list: entity.MetricsList{
+				{ID: "PollCount", MType: entity.Counter, Delta: utils.Ptr(int64(5))},
+				{ID: "Alloc", MType: entity.Gauge, Value: utils.Ptr(123.5)},
+			},
+			want: "# TYPE Alloc gauge\nAlloc 123.5\n# TYPE PollCount counter\nPollCount 5\n",
+		},
+		{
+			name: "series with labels",
+			list: entity.MetricsList{
+				{ID: "Alloc", MType: entity.Gauge, Value: utils.Ptr(2.0), Labels: entity.Labels{"host": "b"}},
+				{ID: "Alloc", MType: entity.Gauge, Value: utils.Ptr(1.0), Labels: entity.Labels{"host": "a", "service": "s"}},
+				{ID: "Alloc", MType: entity.Gauge, Value: utils.Ptr(3.0)},
+				{ID: "Escaped", MType: entity.Gauge, Value: utils.Ptr(4.0), Labels: entity.Labels{"path": "C:\\\"x\"\n"}},
+			},
+			want: "# TYPE Alloc gauge\nAlloc 3\nAlloc{host=\"a\",service=\"s\"} 1\nAlloc{host=\"b\"} 2\n" +
+				"# TYPE Escaped gauge\nEscaped{path=\"C:\\\\\\\"x\\\"\\n\"} 4\n",
+		},
+		{
+			name: "colliding label names",
+			list: entity.MetricsList{
+				{
+					ID:     "Alloc",
+					MType:  entity.Gauge,
+					Value:  utils.Ptr(1.0),
+					Labels: entity.Labels{"k8s:pod": "a", "k8s_pod": "b", "host": "h"},
+				},
+			},
+			want: "# TYPE Alloc gauge\nAlloc{host=\"h\",k8s_pod=\"a\"} 1\n",
+		},
+		{
+			name: "special float values",
+			list: entity.MetricsList{
+				{ID: "inf", MType: entity.Gauge, Value: utils.Ptr(math.Inf(1))},
+				{ID: "nan", MType: entity.Gauge, Value: utils.Ptr(math.NaN())},
+			},
+			want: "# TYPE inf gauge\ninf +Inf\n# TYPE nan gauge\nnan NaN\n",
+		},
+		{
+			name: "histogram",
+			list: entity.MetricsList{
+				{
+					ID:    "latency",
+					MType: entity.Histogram,
+					Histogram: &entity.HistogramValue{
+						Buckets: []entity.Bucket{{UpperBound: 0.1, Count: 1}, {UpperBound: 1, Count: 3}},
+						Sum:     2.5,
+						Count:   4,
+					},
+					Labels: entity.Labels{"host": "a"},
+				},
+			},
+			want: "# TYPE latency histogram\n" +
+				"latency_bucket{host=\"a\",le=\"0.1\"} 1\n" +
+				"latency_bucket{host=\"a\",le=\"1\"} 3\n" +
+				"latency_bucket{host=\"a\",le=\"+Inf\"} 4\n" +
+				"latency_sum{host=\"a\"} 2.5\n" +
+				"latency_count{host=\"a\"} 4\n",
+		},
+		{
+			name: "summary",
+			list: entity.MetricsList{
+				{
+					ID:    "rpc",
+					MType: entity.Summary,
+					Summary: &entity.SummaryValue{
+						Quantiles: []entity.Quantile{{Quantile: 0.5, Value: 0.2}, {Quantile: 0.99, Value: 1.5}},
+						Sum:       7.5,
+						Count:     10,
+					},
+					Labels: entity.Labels{"host": "a"},
+				},
+			},
+			want: "# TYPE rpc summary\n" +
+				"rpc{host=\"a\",quantile=\"0.5\"} 0.2\n" +
+				"rpc{host=\"a\",quantile=\"0.99\"} 1.5\n" +
+				"rpc_sum{host=\"a\"} 7.5\n" +
+				"rpc_count{host=\"a\"} 10\n",
+		},
+		{
+			name: "conflicting and invalid types",
+			list: entity.MetricsList{
+				{ID: "metric.one", MType: entity.Counter, Delta: utils.Ptr(int64(1))},
+				{ID: "metric_one", MType: entity.Gauge, Value: utils.Ptr(1.0)},
+				{ID: "invalid", MType: "invalid"},
+			},
+			want: "# TYPE metric_one counter\nmetric_one 1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteText(&buf, tt.list)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, buf.String())
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package prompb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+// NameLabel is label holding metric name.
+const NameLabel = "__name__"
+
+// MaxDecodedSize limits decompressed size of WriteRequest,
+// snappy allocates the size claimed by compressed data.
+const MaxDecodedSize = 32 << 20
+
+var (
+	ErrMissingName = errors.New("series without " + NameLabel + " label")
+	ErrTooLarge    = errors.New("write request is too large")
+)
+
+// DecodeWriteRequest decodes snappy-compressed WriteRequest.
+func DecodeWriteRequest(data []byte) (*WriteRequest, error) {
+	size, err := snappy.DecodedLen(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress snappy: %w", err)
+	}
+	if size > MaxDecodedSize {
+		return nil, fmt.Errorf("%w: %d bytes decompressed", ErrTooLarge, size)
+	}
+
+	decoded, err := snappy.Decode(nil, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress snappy: %w", err)
+	}
+
+	var req WriteRequest
+	if err = proto.Unmarshal(decoded, &req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal write request: %w", err)
+	}
+
+	return &req, nil
+}
+
+// ToEntityList converts time series to gauges keeping the latest sample
+// of every series. Non-finite values, including staleness markers,
+// are skipped since they cannot be stored.
+func ToEntityList(series []*TimeSeries) (entity.MetricsList, error) {
+	type latest struct {
+		metrics   entity.Metrics
+		timestamp int64
+	}
+
+	bySeries := make(map[string]*latest)
+
+	for _, ts := range series {
+		var (
+			name   string
+			labels entity.Labels
+		)
+
+		for _, label := range ts.GetLabels() {
+			if label.GetName() == NameLabel {
+				name = label.GetValue()
+				continue
+			}
+
+			if labels == nil {
+				labels = make(entity.Labels, len(ts.GetLabels()))
+			}
+			labels[label.GetName()] = label.GetValue()
+		}
+
+		if name == "" {
+			return nil, ErrMissingName
+		}
+
+		// series are deduplicated by key, so name must not make keys ambiguous
+		if err := entity.ValidateName(name); err != nil {
+			return nil, err
+		}
+
+		key := entity.SeriesKey(name, labels)
+		for _, sample := range ts.GetSamples() {
+			value := sample.GetValue()
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				continue
+			}
+
+			l, ok := bySeries[key]
+			if ok && l.timestamp > sample.GetTimestamp() {
+				continue
+			}
+
+			if !ok {
+				l = &latest{metrics: entity.Metrics{ID: name, MType: entity.Gauge, Labels: labels}}
+				bySeries[key] = l
+			}
+			l.metrics.Value = &value
+			l.timestamp = sample.GetTimestamp()
+		}
+	}
+
+	keys := make([]string, 0, len(bySeries))
+	for key := range bySeries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	list := make(entity.MetricsList, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, bySeries[key].metrics)
+	}
+
+	return list, nil
+}
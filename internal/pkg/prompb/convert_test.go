@@ -0,0 +1,120 @@
+package prompb
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+func TestDecodeWriteRequest(t *testing.T) {
+	req := &WriteRequest{Timeseries: []*TimeSeries{{
+		Labels:  []*Label{{Name: NameLabel, Value: "up"}},
+		Samples: []*Sample{{Value: 1, Timestamp: 1000}},
+	}}}
+
+	data, err := proto.Marshal(req)
+	require.NoError(t, err)
+
+	got, err := DecodeWriteRequest(snappy.Encode(nil, data))
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(req, got))
+
+	_, err = DecodeWriteRequest(data)
+	require.Error(t, err)
+
+	// size is checked before decompressed data is allocated
+	_, err = DecodeWriteRequest(snappy.Encode(nil, make([]byte, MaxDecodedSize+1)))
+	require.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestToEntityList(t *testing.T) {
+	tests := []struct {
+		name    string
+		series  []*TimeSeries
+		want    entity.MetricsList
+		wantErr error
+	}{
+		{
+			name: "latest sample wins",
+			series: []*TimeSeries{{
+				Labels:  []*Label{{Name: NameLabel, Value: "up"}, {Name: "job", Value: "node"}},
+				Samples: []*Sample{{Value: 1, Timestamp: 2000}, {Value: 0, Timestamp: 1000}},
+			}},
+			want: entity.MetricsList{
+				{ID: "up", MType: entity.Gauge, Value: utils.Ptr(1.0), Labels: entity.Labels{"job": "node"}},
+			},
+		},
+		{
+			name: "same series in several entries",
+			series: []*TimeSeries{
+				{
+					Labels:  []*Label{{Name: NameLabel, Value: "temp"}},
+					Samples: []*Sample{{Value: 20, Timestamp: 1000}},
+				},
+				{
+					Labels:  []*Label{{Name: NameLabel, Value: "temp"}},
+					Samples: []*Sample{{Value: 21, Timestamp: 3000}},
+				},
+				{
+					Labels:  []*Label{{Name: NameLabel, Value: "temp"}},
+					Samples: []*Sample{{Value: 19, Timestamp: 2000}},
+				},
+			},
+			want: entity.MetricsList{
+				{ID: "temp", MType: entity.Gauge, Value: utils.Ptr(21.0)},
+			},
+		},
+		{
+			name: "stale and infinite samples are skipped",
+			series: []*TimeSeries{
+				{
+					Labels:  []*Label{{Name: NameLabel, Value: "a"}},
+					Samples: []*Sample{{Value: 5, Timestamp: 1000}, {Value: math.NaN(), Timestamp: 2000}},
+				},
+				{
+					Labels:  []*Label{{Name: NameLabel, Value: "b"}},
+					Samples: []*Sample{{Value: math.Inf(1), Timestamp: 1000}},
+				},
+			},
+			want: entity.MetricsList{
+				{ID: "a", MType: entity.Gauge, Value: utils.Ptr(5.0)},
+			},
+		},
+		{
+			name: "missing name",
+			series: []*TimeSeries{{
+				Labels:  []*Label{{Name: "job", Value: "node"}},
+				Samples: []*Sample{{Value: 1}},
+			}},
+			wantErr: ErrMissingName,
+		},
+		{
+			name: "braces in name",
+			series: []*TimeSeries{{
+				Labels:  []*Label{{Name: "__name__", Value: `up{job="node"}`}},
+				Samples: []*Sample{{Value: 1}},
+			}},
+			wantErr: entity.ErrInvalidName,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToEntityList(tt.series)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,4 @@
+// Package prompb contains Prometheus remote write messages generated from remote.proto.
+package prompb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative remote.proto
@@ -0,0 +1,93 @@
+// Package retry repeats failed operations with exponential backoff.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy describes how failed operation is repeated. Delay before n-th
+// retry is random between zero and min(MaxDelay, BaseDelay*2^n), which
+// is known as full jitter.
+type Policy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Retryable classifies errors, IsRetryable is used when nil.
+	Retryable func(err error) bool
+}
+
+// DefaultPolicy makes up to 4 attempts within about 10 seconds.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 4,
+		BaseDelay:   time.Second,
+		MaxDelay:    5 * time.Second,
+	}
+}
+
+func (p Policy) Validate() error {
+	if p.MaxAttempts < 1 {
+		return errors.New("retry max attempts must be positive")
+	}
+	if p.BaseDelay < 0 || p.MaxDelay < p.BaseDelay {
+		return fmt.Errorf("invalid retry delays: base %s, max %s", p.BaseDelay, p.MaxDelay)
+	}
+	return nil
+}
+
+// IsRetryable reports whether operation failed with err is worth repeating.
+func (p Policy) IsRetryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// Do calls fn until it succeeds, fails with not retryable error or
+// attempts are over. Waiting for the next attempt stops when ctx is done,
+// the error of the last attempt is returned then.
+func (p Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 0; attempt < max(p.MaxAttempts, 1); attempt++ {
+		if attempt > 0 {
+			if Sleep(ctx, p.Delay(attempt-1)) != nil {
+				return err
+			}
+		}
+
+		err = fn(ctx)
+		if err == nil || !p.IsRetryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// Delay returns random delay before retry number n counted from zero.
+func (p Policy) Delay(n int) time.Duration {
+	ceiling := p.MaxDelay
+	if n < 62 && p.BaseDelay <= p.MaxDelay>>n {
+		ceiling = p.BaseDelay << n
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling + 1)
+}
+
+// Sleep waits for d or until ctx is done, in the latter case ctx error is returned.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
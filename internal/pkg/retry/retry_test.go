@@ -0,0 +1,179 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errTransient = &StatusError{Code: http.StatusServiceUnavailable}
+
+func TestPolicy_Do(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	tests := []struct {
+		name         string
+		errs         []error
+		wantErr      error
+		wantAttempts int
+	}{
+		{
+			name:         "success",
+			errs:         []error{nil},
+			wantAttempts: 1,
+		},
+		{
+			name:         "success after transient errors",
+			errs:         []error{errTransient, errTransient, nil},
+			wantAttempts: 3,
+		},
+		{
+			name:         "attempts are over",
+			errs:         []error{errTransient, errTransient, errTransient, nil},
+			wantErr:      errTransient,
+			wantAttempts: 3,
+		},
+		{
+			name:         "not retryable error",
+			errs:         []error{&StatusError{Code: http.StatusBadRequest}, nil},
+			wantErr:      &StatusError{Code: http.StatusBadRequest},
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := policy.Do(context.Background(), func(context.Context) error {
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			})
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantAttempts, attempts)
+		})
+	}
+}
+
+func TestPolicy_Do_Canceled(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	err := policy.Do(ctx, func(context.Context) error {
+		attempts++
+		return errTransient
+	})
+
+	assert.Equal(t, errTransient, err, "error of last attempt is returned")
+	assert.Equal(t, 1, attempts)
+}
+
+func TestPolicy_Do_Retryable(t *testing.T) {
+	errCustom := errors.New("custom")
+	policy := Policy{
+		MaxAttempts: 3,
+		Retryable:   func(err error) bool { return errors.Is(err, errCustom) },
+	}
+
+	attempts := 0
+	err := policy.Do(context.Background(), func(context.Context) error {
+		attempts++
+		return errCustom
+	})
+
+	assert.ErrorIs(t, err, errCustom)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestPolicy_Delay(t *testing.T) {
+	policy := Policy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{n: 0, want: 100 * time.Millisecond},
+		{n: 2, want: 400 * time.Millisecond},
+		{n: 4, want: time.Second},
+		{n: 100, want: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.n), func(t *testing.T) {
+			for range 100 {
+				d := policy.Delay(tt.n)
+				require.GreaterOrEqual(t, d, time.Duration(0))
+				require.LessOrEqual(t, d, tt.want)
+			}
+		})
+	}
+
+	assert.Zero(t, Policy{}.Delay(3))
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{name: "default", policy: DefaultPolicy()},
+		{name: "no delays", policy: Policy{MaxAttempts: 1}},
+		{name: "no attempts", policy: Policy{BaseDelay: time.Second, MaxDelay: time.Second}, wantErr: true},
+		{name: "negative delay", policy: Policy{MaxAttempts: 1, BaseDelay: -time.Second}, wantErr: true},
+		{name: "max below base", policy: Policy{MaxAttempts: 1, BaseDelay: time.Second}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "deadline", err: context.DeadlineExceeded, want: true},
+		{name: "server error", err: &StatusError{Code: http.StatusBadGateway}, want: true},
+		{name: "too many requests", err: &StatusError{Code: http.StatusTooManyRequests}, want: true},
+		{name: "bad request", err: fmt.Errorf("send: %w", &StatusError{Code: http.StatusBadRequest}), want: false},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: true},
+		{name: "connection reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), want: true},
+		{name: "eof", err: io.ErrUnexpectedEOF, want: true},
+		{name: "grpc unavailable", err: status.Error(codes.Unavailable, "down"), want: true},
+		{name: "grpc invalid argument", err: status.Error(codes.InvalidArgument, "bad"), want: false},
+		{name: "other", err: errors.New("other"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRetryable(tt.err))
+		})
+	}
+}
@@ -0,0 +1,259 @@
+// Package spool implements bounded on-disk FIFO queue of opaque entries.
+package spool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	entryExt = ".entry"
+	tmpExt   = ".tmp"
+)
+
+var ErrEntryTooLarge = errors.New("spool entry is larger than spool max size")
+
+// Spool keeps entries in files of single directory, one file per entry.
+// File names consist of sequence number and creation time,
+// so lexicographical order of names is order of entries.
+type Spool struct {
+	mu      sync.Mutex
+	dir     string
+	maxSize int64
+	maxAge  time.Duration
+	seq     uint64
+	size    int64
+	entries []entry
+	dropped atomic.Int64
+	// onEvict receives data of entries dropped due to limits, see OnEvict.
+	onEvict func(data []byte)
+	// leased is name of entry passed to fn of Drain, it is not evicted,
+	// so that its data is either delivered or evicted, never both.
+	leased string
+}
+
+type entry struct {
+	name      string
+	size      int64
+	createdAt time.Time
+}
+
+// New opens spool in dir, entries left by previous run are kept.
+// Zero maxSize or maxAge disables corresponding limit.
+func New(dir string, maxSize int64, maxAge time.Duration) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	s := &Spool{
+		dir:     dir,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+	}
+
+	for _, file := range files {
+		name := file.Name()
+		if strings.HasSuffix(name, tmpExt) {
+			// unfinished write of previous run
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+
+		createdAt, seq, ok := parseName(name)
+		if !ok {
+			continue
+		}
+
+		info, errInfo := file.Info()
+		if errInfo != nil {
+			return nil, fmt.Errorf("failed to stat spool entry: %w", errInfo)
+		}
+
+		s.entries = append(s.entries, entry{name: name, size: info.Size(), createdAt: createdAt})
+		s.size += info.Size()
+		s.seq = max(s.seq, seq)
+	}
+
+	sort.Slice(s.entries, func(i, j int) bool {
+		return s.entries[i].name < s.entries[j].name
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict(0)
+
+	return s, nil
+}
+
+// Put appends entry to the end of spool, the oldest entries
+// are dropped when spool does not fit max size.
+func (s *Spool) Put(data []byte) error {
+	if s.maxSize > 0 && int64(len(data)) > s.maxSize {
+		return ErrEntryTooLarge
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evict(int64(len(data)))
+
+	s.seq++
+	now := time.Now()
+	name := fmt.Sprintf("%020d-%020d%s", s.seq, now.UnixNano(), entryExt)
+
+	if err := writeFile(filepath.Join(s.dir, name), data); err != nil {
+		return err
+	}
+
+	s.entries = append(s.entries, entry{name: name, size: int64(len(data)), createdAt: now})
+	s.size += int64(len(data))
+
+	return nil
+}
+
+// Drain passes entries to fn in order, entry is removed when fn succeeds.
+// Draining stops on the first error of fn, the entry is kept for next time.
+func (s *Spool) Drain(fn func(data []byte) error) error {
+	for {
+		s.mu.Lock()
+		s.evict(0)
+		if len(s.entries) == 0 {
+			s.mu.Unlock()
+			return nil
+		}
+		head := s.entries[0]
+		s.leased = head.name
+		s.mu.Unlock()
+
+		data, err := os.ReadFile(filepath.Join(s.dir, head.name))
+		if err == nil {
+			err = fn(data)
+		} else if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		} else {
+			err = fmt.Errorf("failed to read spool entry: %w", err)
+		}
+
+		s.mu.Lock()
+		s.leased = ""
+		if err == nil && len(s.entries) != 0 && s.entries[0].name == head.name {
+			s.remove(0)
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// OnEvict sets fn receiving data of every entry dropped due to size and
+// age limits. fn is called with spool locked, so it must not use spool.
+func (s *Spool) OnEvict(fn func(data []byte)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onEvict = fn
+}
+
+// Len returns number of entries in spool.
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// Dropped returns number of stored entries dropped due to size and age limits.
+func (s *Spool) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// evict drops expired entries and the oldest entries until incoming
+// bytes fit max size, caller must hold the lock. Entry being drained
+// is skipped, spool exceeds max size by its size until it is drained.
+func (s *Spool) evict(incoming int64) {
+	i := 0
+	for i < len(s.entries) {
+		oldest := s.entries[i]
+		if oldest.name == s.leased {
+			i++
+			continue
+		}
+
+		expired := s.maxAge > 0 && time.Since(oldest.createdAt) > s.maxAge
+		overflow := s.maxSize > 0 && s.size+incoming > s.maxSize
+		if !expired && !overflow {
+			return
+		}
+
+		if s.onEvict != nil {
+			if data, err := os.ReadFile(filepath.Join(s.dir, oldest.name)); err == nil {
+				s.onEvict(data)
+			}
+		}
+
+		s.remove(i)
+		s.dropped.Add(1)
+	}
+}
+
+// remove deletes i-th entry, caller must hold the lock.
+func (s *Spool) remove(i int) {
+	e := s.entries[i]
+	_ = os.Remove(filepath.Join(s.dir, e.name))
+	s.entries = append(s.entries[:i], s.entries[i+1:]...)
+	s.size -= e.size
+}
+
+// writeFile writes entry to temporary file and renames it,
+// so that partially written entries are never seen as complete.
+func writeFile(path string, data []byte) error {
+	tmpPath := path + tmpExt
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write spool entry: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write spool entry: %w", err)
+	}
+
+	return nil
+}
+
+func parseName(name string) (time.Time, uint64, bool) {
+	base, found := strings.CutSuffix(name, entryExt)
+	if !found {
+		return time.Time{}, 0, false
+	}
+
+	rawSeq, rawTime, found := strings.Cut(base, "-")
+	if !found {
+		return time.Time{}, 0, false
+	}
+
+	nanos, err := strconv.ParseInt(rawTime, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+
+	seq, err := strconv.ParseUint(rawSeq, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+
+	return time.Unix(0, nanos), seq, true
+}
@@ -0,0 +1,175 @@
+package spool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func drainAll(t *testing.T, s *Spool) []string {
+	var got []string
+	err := s.Drain(func(data []byte) error {
+		got = append(got, string(data))
+		return nil
+	})
+	require.NoError(t, err)
+	return got
+}
+
+func TestSpool_PutDrain(t *testing.T) {
+	s, err := New(t.TempDir(), 0, 0)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.Put([]byte(fmt.Sprintf("entry%d", i))))
+	}
+	require.Equal(t, 3, s.Len())
+
+	require.Equal(t, []string{"entry0", "entry1", "entry2"}, drainAll(t, s))
+	require.Equal(t, 0, s.Len())
+	require.Equal(t, int64(0), s.Dropped())
+}
+
+func TestSpool_DrainStopsOnError(t *testing.T) {
+	s, err := New(t.TempDir(), 0, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Put([]byte("entry0")))
+	require.NoError(t, s.Put([]byte("entry1")))
+
+	errSend := errors.New("server is down")
+	var calls int
+	err = s.Drain(func(data []byte) error {
+		calls++
+		return errSend
+	})
+	require.ErrorIs(t, err, errSend)
+	require.Equal(t, 1, calls)
+	require.Equal(t, 2, s.Len())
+
+	require.Equal(t, []string{"entry0", "entry1"}, drainAll(t, s))
+}
+
+func TestSpool_Limits(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxSize     int64
+		maxAge      time.Duration
+		entries     []string
+		wait        time.Duration
+		wantPutErr  error
+		wantEntries []string
+		wantEvicted []string
+		wantDropped int64
+	}{
+		{
+			name:        "oldest entries dropped by size",
+			maxSize:     12,
+			entries:     []string{"entry0", "entry1", "entry2"},
+			wantEntries: []string{"entry1", "entry2"},
+			wantEvicted: []string{"entry0"},
+			wantDropped: 1,
+		},
+		{
+			name:        "entry larger than max size",
+			maxSize:     4,
+			entries:     []string{"entry0"},
+			wantPutErr:  ErrEntryTooLarge,
+			wantEntries: nil,
+			wantDropped: 0,
+		},
+		{
+			name:        "expired entries dropped",
+			maxAge:      time.Millisecond,
+			entries:     []string{"entry0", "entry1"},
+			wait:        10 * time.Millisecond,
+			wantEntries: nil,
+			wantEvicted: []string{"entry0", "entry1"},
+			wantDropped: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(t.TempDir(), tt.maxSize, tt.maxAge)
+			require.NoError(t, err)
+
+			var evicted []string
+			s.OnEvict(func(data []byte) {
+				evicted = append(evicted, string(data))
+			})
+
+			for _, e := range tt.entries {
+				err = s.Put([]byte(e))
+				require.ErrorIs(t, err, tt.wantPutErr)
+			}
+
+			time.Sleep(tt.wait)
+
+			require.Equal(t, tt.wantEntries, drainAll(t, s))
+			require.Equal(t, tt.wantEvicted, evicted)
+			require.Equal(t, tt.wantDropped, s.Dropped())
+		})
+	}
+}
+
+func TestSpool_PutWhileDraining(t *testing.T) {
+	s, err := New(t.TempDir(), 12, 0)
+	require.NoError(t, err)
+
+	var evicted []string
+	s.OnEvict(func(data []byte) {
+		evicted = append(evicted, string(data))
+	})
+
+	require.NoError(t, s.Put([]byte("entry0")))
+	require.NoError(t, s.Put([]byte("entry1")))
+
+	var delivered []string
+	err = s.Drain(func(data []byte) error {
+		if len(delivered) == 0 {
+			// spool overflows while the first entry is being sent
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				require.NoError(t, s.Put([]byte("entry2")))
+			}()
+			wg.Wait()
+		}
+		delivered = append(delivered, string(data))
+		return nil
+	})
+	require.NoError(t, err)
+
+	// every entry is either delivered or evicted, never both
+	require.Equal(t, []string{"entry0", "entry2"}, delivered)
+	require.Equal(t, []string{"entry1"}, evicted)
+	require.Zero(t, s.Len())
+}
+
+func TestSpool_Reopen(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := New(dir, 0, 0)
+	require.NoError(t, err)
+	require.NoError(t, s.Put([]byte("entry0")))
+	require.NoError(t, s.Put([]byte("entry1")))
+
+	// unfinished write and unrelated files are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "entry2"+entryExt+tmpExt), []byte("entry2"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("readme"), 0o644))
+
+	s, err = New(dir, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, s.Len())
+	require.NoError(t, s.Put([]byte("entry3")))
+
+	require.Equal(t, []string{"entry0", "entry1", "entry3"}, drainAll(t, s))
+	require.NoFileExists(t, filepath.Join(dir, "entry2"+entryExt+tmpExt))
+}
@@ -0,0 +1,224 @@
+package statsd
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+// Updater is implemented by usecase.UseCase.
+type Updater interface {
+	UpdateMetrics(context.Context, entity.MetricsList) error
+	GetMetrics(context.Context, entity.Metrics) (entity.Metrics, error)
+}
+
+// Aggregator accumulates samples between flushes.
+// Counters are summed, the last gauge wins, timers and histograms
+// are reported as <name>_count counter and <name>_min, <name>_max,
+// <name>_mean gauges.
+type Aggregator struct {
+	mu       sync.Mutex
+	counters map[string]*counter
+	gauges   map[string]*gauge
+	timers   map[string]*timer
+	// remainders keeps fractions of sampled counts lost to rounding
+	// by series key of counter, they are carried to the next flush.
+	remainders map[string]float64
+}
+
+type counter struct {
+	name   string
+	labels entity.Labels
+	delta  float64
+}
+
+type gauge struct {
+	name   string
+	labels entity.Labels
+	value  float64
+	// relative is set while value is only sum of deltas
+	// to unknown current value.
+	relative bool
+}
+
+type timer struct {
+	name     string
+	labels   entity.Labels
+	count    float64
+	sum      float64
+	min, max float64
+}
+
+func NewAggregator() *Aggregator {
+	return &Aggregator{
+		counters: make(map[string]*counter),
+		gauges:   make(map[string]*gauge),
+		timers:   make(map[string]*timer),
+
+		remainders: make(map[string]float64),
+	}
+}
+
+func (a *Aggregator) Add(sample Sample) {
+	key := entity.SeriesKey(sample.Name, sample.Labels)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch sample.Type {
+	case TypeCounter:
+		c, ok := a.counters[key]
+		if !ok {
+			c = &counter{name: sample.Name, labels: sample.Labels}
+			a.counters[key] = c
+		}
+		c.delta += sample.Value / sample.SampleRate
+	case TypeGauge:
+		g, ok := a.gauges[key]
+		if !ok {
+			g = &gauge{name: sample.Name, labels: sample.Labels, relative: sample.Relative}
+			a.gauges[key] = g
+		}
+		if sample.Relative {
+			g.value += sample.Value
+		} else {
+			g.value, g.relative = sample.Value, false
+		}
+	case TypeTimer, TypeHistogram:
+		t, ok := a.timers[key]
+		if !ok {
+			t = &timer{name: sample.Name, labels: sample.Labels, min: sample.Value, max: sample.Value}
+			a.timers[key] = t
+		}
+		t.count += 1 / sample.SampleRate
+		t.sum += sample.Value / sample.SampleRate
+		t.min = math.Min(t.min, sample.Value)
+		t.max = math.Max(t.max, sample.Value)
+	}
+}
+
+// Flush returns metrics accumulated since the previous flush sorted by
+// series key. Gauge deltas are applied to the current value read from
+// updater, when it fails samples are kept until the next flush. Counters
+// whose rounded delta is zero are not reported.
+func (a *Aggregator) Flush(ctx context.Context, updater Updater) (entity.MetricsList, error) {
+	a.mu.Lock()
+	counters, gauges, timers := a.counters, a.gauges, a.timers
+	a.counters = make(map[string]*counter)
+	a.gauges = make(map[string]*gauge)
+	a.timers = make(map[string]*timer)
+	a.mu.Unlock()
+
+	// gauges are read first, so that nothing is changed on error
+	gaugeList := make(entity.MetricsList, 0, len(gauges))
+	for _, key := range sortedKeys(gauges) {
+		g := gauges[key]
+		value := g.value
+		if g.relative {
+			current, err := updater.GetMetrics(ctx, entity.Metrics{ID: g.name, MType: entity.Gauge, Labels: g.labels})
+			switch {
+			case err == nil:
+				value += *current.Value
+			case !errors.Is(err, entity.ErrMetricNotFound):
+				a.restore(counters, gauges, timers)
+				return nil, err
+			}
+		}
+		gaugeList = append(gaugeList, entity.Metrics{ID: g.name, MType: entity.Gauge, Value: &value, Labels: g.labels})
+	}
+
+	var list entity.MetricsList
+
+	for _, key := range sortedKeys(counters) {
+		c := counters[key]
+		delta := a.round(key, c.delta)
+		if delta == 0 {
+			continue
+		}
+		list = append(list, entity.Metrics{ID: c.name, MType: entity.Counter, Delta: &delta, Labels: c.labels})
+	}
+
+	list = append(list, gaugeList...)
+
+	for _, key := range sortedKeys(timers) {
+		t := timers[key]
+		count := a.round(entity.SeriesKey(t.name+"_count", t.labels), t.count)
+		if count != 0 {
+			list = append(list,
+				entity.Metrics{ID: t.name + "_count", MType: entity.Counter, Delta: &count, Labels: t.labels})
+		}
+		list = append(list,
+			entity.Metrics{ID: t.name + "_min", MType: entity.Gauge, Value: &t.min, Labels: t.labels.Clone()},
+			entity.Metrics{ID: t.name + "_max", MType: entity.Gauge, Value: &t.max, Labels: t.labels.Clone()},
+			entity.Metrics{ID: t.name + "_mean", MType: entity.Gauge, Value: utils.Ptr(t.sum / t.count), Labels: t.labels.Clone()},
+		)
+	}
+
+	return list, nil
+}
+
+// restore merges samples taken by failed flush into samples added since then,
+// taken ones are older, so gauges set since then win over them.
+func (a *Aggregator) restore(counters map[string]*counter, gauges map[string]*gauge, timers map[string]*timer) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for key, c := range counters {
+		if newer, ok := a.counters[key]; ok {
+			c.delta += newer.delta
+		}
+		a.counters[key] = c
+	}
+
+	for key, g := range gauges {
+		newer, ok := a.gauges[key]
+		if ok && !newer.relative {
+			continue
+		}
+		if ok {
+			g.value += newer.value
+		}
+		a.gauges[key] = g
+	}
+
+	for key, t := range timers {
+		if newer, ok := a.timers[key]; ok {
+			t.count += newer.count
+			t.sum += newer.sum
+			t.min = math.Min(t.min, newer.min)
+			t.max = math.Max(t.max, newer.max)
+		}
+		a.timers[key] = t
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// round rounds count of counter with given series key to integer delta,
+// the remainder of previous flush is added to count and the new one is kept.
+func (a *Aggregator) round(key string, count float64) int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	count += a.remainders[key]
+	delta := math.Round(count)
+	if remainder := count - delta; remainder != 0 {
+		a.remainders[key] = remainder
+	} else {
+		delete(a.remainders, key)
+	}
+
+	return int64(delta)
+}
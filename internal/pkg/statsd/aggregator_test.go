@@ -0,0 +1,176 @@
+package statsd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+type fakeUpdater struct {
+	gauges map[string]float64
+	err    error
+}
+
+func (f fakeUpdater) UpdateMetrics(context.Context, entity.MetricsList) error {
+	return nil
+}
+
+func (f fakeUpdater) GetMetrics(_ context.Context, m entity.Metrics) (entity.Metrics, error) {
+	if f.err != nil {
+		return entity.Metrics{}, f.err
+	}
+	value, ok := f.gauges[m.ID]
+	if !ok {
+		return entity.Metrics{}, entity.ErrMetricNotFound
+	}
+	m.Value = &value
+	return m, nil
+}
+
+func TestAggregator_Flush(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		current map[string]float64
+		want    entity.MetricsList
+	}{
+		{
+			name:  "counters are summed and scaled",
+			lines: []string{"requests:1|c", "requests:2|c", "requests:1|c|@0.5"},
+			want: entity.MetricsList{
+				{ID: "requests", MType: entity.Counter, Delta: utils.Ptr(int64(5))},
+			},
+		},
+		{
+			name:  "zero delta counters are skipped",
+			lines: []string{"requests:1|c", "requests:-1|c", "temperature:20|g"},
+			want: entity.MetricsList{
+				{ID: "temperature", MType: entity.Gauge, Value: utils.Ptr(20.0)},
+			},
+		},
+		{
+			name:  "last gauge wins",
+			lines: []string{"temperature:20|g", "temperature:21|g"},
+			want: entity.MetricsList{
+				{ID: "temperature", MType: entity.Gauge, Value: utils.Ptr(21.0)},
+			},
+		},
+		{
+			name:  "gauge delta after absolute value",
+			lines: []string{"queue:10|g", "queue:-3|g"},
+			want: entity.MetricsList{
+				{ID: "queue", MType: entity.Gauge, Value: utils.Ptr(7.0)},
+			},
+		},
+		{
+			name:    "gauge delta to stored value",
+			lines:   []string{"queue:+2|g", "queue:+3|g"},
+			current: map[string]float64{"queue": 5},
+			want: entity.MetricsList{
+				{ID: "queue", MType: entity.Gauge, Value: utils.Ptr(10.0)},
+			},
+		},
+		{
+			name:  "gauge delta to unknown value",
+			lines: []string{"queue:-1|g"},
+			want: entity.MetricsList{
+				{ID: "queue", MType: entity.Gauge, Value: utils.Ptr(-1.0)},
+			},
+		},
+		{
+			name:  "timers",
+			lines: []string{"latency:10|ms", "latency:30|ms"},
+			want: entity.MetricsList{
+				{ID: "latency_count", MType: entity.Counter, Delta: utils.Ptr(int64(2))},
+				{ID: "latency_min", MType: entity.Gauge, Value: utils.Ptr(10.0)},
+				{ID: "latency_max", MType: entity.Gauge, Value: utils.Ptr(30.0)},
+				{ID: "latency_mean", MType: entity.Gauge, Value: utils.Ptr(20.0)},
+			},
+		},
+		{
+			name:  "series with labels are separate",
+			lines: []string{"requests:1|c|#code:200", "requests:1|c|#code:500", "requests:1|c|#code:200"},
+			want: entity.MetricsList{
+				{ID: "requests", MType: entity.Counter, Delta: utils.Ptr(int64(2)), Labels: entity.Labels{"code": "200"}},
+				{ID: "requests", MType: entity.Counter, Delta: utils.Ptr(int64(1)), Labels: entity.Labels{"code": "500"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAggregator()
+			for _, line := range tt.lines {
+				sample, err := Parse(line)
+				require.NoError(t, err)
+				a.Add(sample)
+			}
+
+			got, err := a.Flush(context.Background(), fakeUpdater{gauges: tt.current})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			got, err = a.Flush(context.Background(), fakeUpdater{gauges: tt.current})
+			require.NoError(t, err)
+			assert.Empty(t, got)
+		})
+	}
+}
+
+func TestAggregator_Flush_Remainder(t *testing.T) {
+	a := NewAggregator()
+
+	// every sample counts for 1.25 requests, rounded separately
+	// they would be reported as 4 requests instead of 5
+	var total int64
+	for range 4 {
+		sample, err := Parse("requests:1|c|@0.8")
+		require.NoError(t, err)
+		a.Add(sample)
+
+		list, err := a.Flush(context.Background(), fakeUpdater{})
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		total += *list[0].Delta
+	}
+
+	assert.Equal(t, int64(5), total, "fractions are carried between flushes")
+}
+
+func TestAggregator_Flush_Error(t *testing.T) {
+	a := NewAggregator()
+
+	add := func(lines ...string) {
+		for _, line := range lines {
+			sample, err := Parse(line)
+			require.NoError(t, err)
+			a.Add(sample)
+		}
+	}
+
+	add("requests:1|c", "latency:10|ms", "queue:+2|g", "temperature:20|g")
+
+	_, err := a.Flush(context.Background(), fakeUpdater{err: errors.New("storage is down")})
+	require.Error(t, err)
+
+	// samples of failed flush are merged with samples added since then
+	add("requests:2|c", "latency:30|ms", "queue:+3|g", "temperature:21|g")
+
+	got, err := a.Flush(context.Background(), fakeUpdater{gauges: map[string]float64{"queue": 5}})
+	require.NoError(t, err)
+	assert.Equal(t, entity.MetricsList{
+		{ID: "requests", MType: entity.Counter, Delta: utils.Ptr(int64(3))},
+		{ID: "queue", MType: entity.Gauge, Value: utils.Ptr(10.0)},
+		{ID: "temperature", MType: entity.Gauge, Value: utils.Ptr(21.0)},
+		{ID: "latency_count", MType: entity.Counter, Delta: utils.Ptr(int64(2))},
+		{ID: "latency_min", MType: entity.Gauge, Value: utils.Ptr(10.0)},
+		{ID: "latency_max", MType: entity.Gauge, Value: utils.Ptr(30.0)},
+		{ID: "latency_mean", MType: entity.Gauge, Value: utils.Ptr(20.0)},
+	}, got)
+}
@@ -0,0 +1,110 @@
+// Package statsd receives metrics in StatsD line protocol.
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+// StatsD metric types.
+const (
+	TypeCounter   = "c"
+	TypeGauge     = "g"
+	TypeTimer     = "ms"
+	TypeHistogram = "h"
+)
+
+var ErrInvalidLine = errors.New("invalid statsd line")
+
+// Sample is single parsed StatsD line.
+type Sample struct {
+	Name  string
+	Value float64
+	Type  string
+	// SampleRate is in (0, 1], counters and timers are scaled by 1/SampleRate.
+	SampleRate float64
+	// Relative is set for gauges written with explicit sign, e.g. "name:-1|g",
+	// Value is added to the current gauge value then.
+	Relative bool
+	// Labels are parsed from DogStatsD tags, e.g. "|#host:a,env:prod".
+	Labels entity.Labels
+}
+
+// Parse parses line in format <name>:<value>|<type>[|@<sample rate>][|#<tags>].
+func Parse(line string) (Sample, error) {
+	name, rest, found := strings.Cut(line, ":")
+	if !found || name == "" {
+		return Sample{}, fmt.Errorf("%w: %q", ErrInvalidLine, line)
+	}
+
+	if err := entity.ValidateName(name); err != nil {
+		return Sample{}, fmt.Errorf("%w: %w", ErrInvalidLine, err)
+	}
+
+	fields := strings.Split(rest, "|")
+	if len(fields) < 2 {
+		return Sample{}, fmt.Errorf("%w: %q", ErrInvalidLine, line)
+	}
+
+	rawValue, mType := fields[0], fields[1]
+
+	value, err := strconv.ParseFloat(rawValue, 64)
+	if err != nil {
+		return Sample{}, fmt.Errorf("%w: invalid value in %q", ErrInvalidLine, line)
+	}
+
+	sample := Sample{
+		Name:       name,
+		Value:      value,
+		Type:       mType,
+		SampleRate: 1,
+	}
+
+	switch mType {
+	case TypeCounter, TypeTimer, TypeHistogram:
+	case TypeGauge:
+		sample.Relative = strings.HasPrefix(rawValue, "+") || strings.HasPrefix(rawValue, "-")
+	default:
+		return Sample{}, fmt.Errorf("%w: unsupported type in %q", ErrInvalidLine, line)
+	}
+
+	for _, field := range fields[2:] {
+		switch {
+		case strings.HasPrefix(field, "@"):
+			sample.SampleRate, err = strconv.ParseFloat(field[1:], 64)
+			if err != nil || sample.SampleRate <= 0 || sample.SampleRate > 1 {
+				return Sample{}, fmt.Errorf("%w: invalid sample rate in %q", ErrInvalidLine, line)
+			}
+		case strings.HasPrefix(field, "#"):
+			sample.Labels, err = parseTags(field[1:])
+			if err != nil {
+				return Sample{}, fmt.Errorf("%w: %w", ErrInvalidLine, err)
+			}
+		default:
+			return Sample{}, fmt.Errorf("%w: unknown field in %q", ErrInvalidLine, line)
+		}
+	}
+
+	return sample, nil
+}
+
+func parseTags(s string) (entity.Labels, error) {
+	labels := make(entity.Labels)
+	for _, tag := range strings.Split(s, ",") {
+		if tag == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(tag, ":")
+		labels[name] = value
+	}
+
+	if len(labels) == 0 {
+		return nil, nil
+	}
+
+	return labels, labels.Validate()
+}
@@ -0,0 +1,78 @@
+package statsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    Sample
+		wantErr bool
+	}{
+		{
+			name: "counter",
+			line: "requests:3|c",
+			want: Sample{Name: "requests", Value: 3, Type: TypeCounter, SampleRate: 1},
+		},
+		{
+			name: "counter with sample rate",
+			line: "requests:1|c|@0.1",
+			want: Sample{Name: "requests", Value: 1, Type: TypeCounter, SampleRate: 0.1},
+		},
+		{
+			name: "gauge",
+			line: "temperature:21.5|g",
+			want: Sample{Name: "temperature", Value: 21.5, Type: TypeGauge, SampleRate: 1},
+		},
+		{
+			name: "gauge increment",
+			line: "queue:+4|g",
+			want: Sample{Name: "queue", Value: 4, Type: TypeGauge, SampleRate: 1, Relative: true},
+		},
+		{
+			name: "gauge decrement",
+			line: "queue:-2|g",
+			want: Sample{Name: "queue", Value: -2, Type: TypeGauge, SampleRate: 1, Relative: true},
+		},
+		{
+			name: "timer",
+			line: "latency:320|ms|@0.5",
+			want: Sample{Name: "latency", Value: 320, Type: TypeTimer, SampleRate: 0.5},
+		},
+		{
+			name: "histogram with tags",
+			line: "size:12|h|#host:a,env:prod",
+			want: Sample{
+				Name: "size", Value: 12, Type: TypeHistogram, SampleRate: 1,
+				Labels: entity.Labels{"host": "a", "env": "prod"},
+			},
+		},
+		{name: "missing value", line: "requests|c", wantErr: true},
+		{name: "missing type", line: "requests:1", wantErr: true},
+		{name: "invalid value", line: "requests:abc|c", wantErr: true},
+		{name: "unsupported type", line: "users:42|s", wantErr: true},
+		{name: "braces in name", line: `requests{a="b"}:1|c`, wantErr: true},
+		{name: "invalid sample rate", line: "requests:1|c|@2", wantErr: true},
+		{name: "unknown field", line: "requests:1|c|x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.line)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidLine)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
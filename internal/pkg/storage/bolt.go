@@ -0,0 +1,376 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mailru/easyjson"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/Imomali1/metrics/internal/entity"
+)
+
+// BoltScheme is DSN prefix selecting embedded storage,
+// the rest of DSN is path to database file.
+const BoltScheme = "bolt://"
+
+const historySuffix = "_history"
+
+// metricTypes have a bucket of values and a bucket of history each.
+var metricTypes = []string{entity.Counter, entity.Gauge, entity.Histogram, entity.Summary}
+
+// Bolt keeps metrics in embedded bbolt database. Every metric type has
+// a bucket of current values keyed by series key and a bucket of history,
+// where every series has nested bucket of samples keyed by timestamp.
+type Bolt struct {
+	db *bolt.DB
+}
+
+func NewBolt(path string) (Storage, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, mType := range metricTypes {
+			if _, err := tx.CreateBucketIfNotExists([]byte(mType)); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucketIfNotExists([]byte(mType + historySuffix)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Bolt{db: db}, nil
+}
+
+// Update applies batch in a single transaction, so batch with
+// mismatching histogram buckets is rejected as a whole.
+func (s *Bolt) Update(_ context.Context, batch entity.MetricsList) error {
+	now := time.Now()
+
+	return s.update(func(tx *bolt.Tx) error {
+		// history of every updated series is trimmed once per batch
+		updated := make(map[[2]string]struct{})
+		for _, one := range batch {
+			if !entity.IsValidType(one.MType) {
+				continue
+			}
+
+			bucket := tx.Bucket([]byte(one.MType))
+			key := []byte(one.Key())
+
+			stored := entity.Metrics{ID: one.ID, MType: one.MType, Labels: one.Labels.Clone()}
+			current, err := getMetrics(bucket, key)
+			if err != nil {
+				return err
+			}
+
+			sample := entity.Sample{Timestamp: now}
+			switch one.MType {
+			case entity.Counter:
+				delta := *one.Delta
+				if current != nil {
+					delta += *current.Delta
+				}
+				stored.Delta = &delta
+				sample.Delta = &delta
+			case entity.Gauge:
+				value := *one.Value
+				stored.Value = &value
+				sample.Value = &value
+			case entity.Histogram:
+				histogram := one.Histogram.Clone()
+				if current != nil {
+					histogram, err = current.Histogram.Merge(one.Histogram)
+					if err != nil {
+						return fmt.Errorf("%s: %w", key, err)
+					}
+				}
+				stored.Histogram = histogram
+				sample.Histogram = histogram
+			case entity.Summary:
+				summary := one.Summary.Clone()
+				if current != nil {
+					summary = current.Summary.Merge(one.Summary)
+				}
+				stored.Summary = summary
+				sample.Summary = summary
+			}
+
+			if err = putJSON(bucket, key, stored); err != nil {
+				return err
+			}
+
+			if err = appendHistory(tx, one.MType, key, sample); err != nil {
+				return err
+			}
+			updated[[2]string{one.MType, string(key)}] = struct{}{}
+		}
+
+		for series := range updated {
+			if err := trimHistory(tx, series[0], []byte(series[1])); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func getMetrics(bucket *bolt.Bucket, key []byte) (*entity.Metrics, error) {
+	data := bucket.Get(key)
+	if data == nil {
+		return nil, nil
+	}
+
+	var metric entity.Metrics
+	if err := easyjson.Unmarshal(data, &metric); err != nil {
+		return nil, err
+	}
+
+	return &metric, nil
+}
+
+func putJSON(bucket *bolt.Bucket, key []byte, v easyjson.Marshaler) error {
+	data, err := easyjson.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return bucket.Put(key, data)
+}
+
+// appendHistory stores sample under big-endian timestamp followed by
+// sequence number, so samples are ordered even with equal timestamps.
+func appendHistory(tx *bolt.Tx, mType string, key []byte, sample entity.Sample) error {
+	history, err := tx.Bucket([]byte(mType + historySuffix)).CreateBucketIfNotExists(key)
+	if err != nil {
+		return err
+	}
+
+	seq, err := history.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	sampleKey := make([]byte, 16)
+	binary.BigEndian.PutUint64(sampleKey, timeKey(sample.Timestamp))
+	binary.BigEndian.PutUint64(sampleKey[8:], seq)
+
+	return putJSON(history, sampleKey, sample)
+}
+
+// trimHistory deletes the oldest samples of series over MaxHistorySize.
+func trimHistory(tx *bolt.Tx, mType string, key []byte) error {
+	history := tx.Bucket([]byte(mType + historySuffix)).Bucket(key)
+	if history == nil {
+		return nil
+	}
+
+	// the oldest kept sample is found from the end,
+	// bucket stats do not count changes of open transaction
+	c := history.Cursor()
+	oldest, _ := c.Last()
+	for i := 1; i < MaxHistorySize && oldest != nil; i++ {
+		oldest, _ = c.Prev()
+	}
+	if oldest == nil {
+		return nil
+	}
+	oldest = bytes.Clone(oldest)
+
+	for k, _ := c.First(); k != nil && bytes.Compare(k, oldest) < 0; k, _ = c.First() {
+		if err := c.Delete(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var (
+	minTimeKey = time.Unix(0, math.MinInt64)
+	maxTimeKey = time.Unix(0, math.MaxInt64)
+)
+
+// timeKey maps t to unsigned number preserving order, times out of
+// UnixNano range are clamped.
+func timeKey(t time.Time) uint64 {
+	switch {
+	case t.Before(minTimeKey):
+		return 0
+	case t.After(maxTimeKey):
+		return math.MaxUint64
+	}
+	return uint64(t.UnixNano()) ^ 1<<63
+}
+
+func (s *Bolt) GetOne(_ context.Context, id, mType string, labels entity.Labels) (entity.Metrics, error) {
+	if !entity.IsValidType(mType) {
+		return entity.Metrics{}, entity.ErrInvalidMetricType
+	}
+
+	var metric = entity.Metrics{ID: id, MType: mType, Labels: labels.Clone()}
+
+	err := s.view(func(tx *bolt.Tx) error {
+		stored, err := getMetrics(tx.Bucket([]byte(mType)), []byte(entity.SeriesKey(id, labels)))
+		if err != nil {
+			return err
+		}
+		if stored == nil {
+			return entity.ErrMetricNotFound
+		}
+
+		metric.Delta = stored.Delta
+		metric.Value = stored.Value
+		metric.Histogram = stored.Histogram
+		metric.Summary = stored.Summary
+		return nil
+	})
+	if err != nil {
+		return entity.Metrics{}, err
+	}
+
+	return metric, nil
+}
+
+func (s *Bolt) GetAll(_ context.Context) (entity.MetricsList, error) {
+	var list entity.MetricsList
+
+	err := s.view(func(tx *bolt.Tx) error {
+		for _, mType := range metricTypes {
+			err := tx.Bucket([]byte(mType)).ForEach(func(_, data []byte) error {
+				var metric entity.Metrics
+				if err := easyjson.Unmarshal(data, &metric); err != nil {
+					return err
+				}
+				list = append(list, metric)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (s *Bolt) GetRange(
+	_ context.Context,
+	id, mType string,
+	labels entity.Labels,
+	from, to time.Time,
+) (entity.SampleList, error) {
+	if !entity.IsValidType(mType) {
+		return nil, entity.ErrInvalidMetricType
+	}
+
+	list := entity.SampleList{}
+	err := s.view(func(tx *bolt.Tx) error {
+		history := tx.Bucket([]byte(mType + historySuffix)).Bucket([]byte(entity.SeriesKey(id, labels)))
+		if history == nil {
+			return entity.ErrMetricNotFound
+		}
+
+		start := make([]byte, 8)
+		binary.BigEndian.PutUint64(start, timeKey(from))
+		end := timeKey(to)
+
+		c := history.Cursor()
+		for k, v := c.Seek(start); k != nil; k, v = c.Next() {
+			if binary.BigEndian.Uint64(k) > end {
+				break
+			}
+
+			var sample entity.Sample
+			if err := easyjson.Unmarshal(v, &sample); err != nil {
+				return err
+			}
+			list = append(list, sample)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (s *Bolt) DeleteOne(_ context.Context, id, mType string, labels entity.Labels) error {
+	if !entity.IsValidType(mType) {
+		return entity.ErrInvalidMetricType
+	}
+
+	key := []byte(entity.SeriesKey(id, labels))
+	return s.update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket([]byte(mType)).Delete(key); err != nil {
+			return err
+		}
+
+		err := tx.Bucket([]byte(mType + historySuffix)).DeleteBucket(key)
+		if err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		return nil
+	})
+}
+
+func (s *Bolt) DeleteAll(_ context.Context) error {
+	return s.update(func(tx *bolt.Tx) error {
+		for _, mType := range metricTypes {
+			for _, name := range []string{mType, mType + historySuffix} {
+				if err := tx.DeleteBucket([]byte(name)); err != nil {
+					return err
+				}
+				if _, err := tx.CreateBucket([]byte(name)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// view and update run transactions of bbolt, storage used after
+// Close returns ErrClosed like other storages.
+func (s *Bolt) view(fn func(*bolt.Tx) error) error {
+	return closedErr(s.db.View(fn))
+}
+
+func (s *Bolt) update(fn func(*bolt.Tx) error) error {
+	return closedErr(s.db.Update(fn))
+}
+
+func closedErr(err error) error {
+	if errors.Is(err, bolt.ErrDatabaseNotOpen) {
+		return ErrClosed
+	}
+	return err
+}
+
+func (s *Bolt) Ping(_ context.Context) error {
+	return s.view(func(*bolt.Tx) error { return nil })
+}
+
+func (s *Bolt) Close() {
+	s.db.Close()
+}
@@ -0,0 +1,438 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+func newTestBolt(t *testing.T) Storage {
+	t.Helper()
+
+	s, err := NewBolt(filepath.Join(t.TempDir(), "metrics.db"))
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func Test_boltStorage_Reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.db")
+
+	s, err := New(ctx, BoltScheme+path)
+	require.NoError(t, err)
+	require.IsType(t, &Bolt{}, s)
+
+	metrics := entity.MetricsList{
+		{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(123.0)},
+		{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(123)), Labels: entity.Labels{"host": "a"}},
+	}
+	require.NoError(t, s.Update(ctx, metrics))
+	s.Close()
+
+	s, err = NewBolt(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	got, err := s.GetAll(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, metrics, got)
+}
+
+func Test_boltStorage_GetAll(t *testing.T) {
+	ctx := context.Background()
+
+	s := newTestBolt(t)
+
+	metrics := entity.MetricsList{
+		{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(123.0)},
+		{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(123))},
+		{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(321.0), Labels: entity.Labels{"host": "a"}},
+		{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(321)), Labels: entity.Labels{"host": "a"}},
+	}
+
+	_ = s.Update(ctx, metrics)
+
+	got, err := s.GetAll(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, metrics, got)
+}
+
+func Test_boltStorage_GetOne(t *testing.T) {
+	ctx := context.Background()
+
+	s := newTestBolt(t)
+
+	metrics := entity.MetricsList{
+		{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(123.0)},
+		{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(123))},
+		{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(321.0), Labels: entity.Labels{"host": "a"}},
+	}
+
+	_ = s.Update(ctx, metrics)
+
+	type args struct {
+		id     string
+		mType  string
+		labels entity.Labels
+	}
+	tests := []struct {
+		name    string
+		idx     int
+		args    args
+		want    entity.Metrics
+		wantErr bool
+	}{
+		{
+			name: "get valid gauge metrics",
+			args: args{
+				id:    "gauge1",
+				mType: entity.Gauge,
+			},
+			want: entity.Metrics{
+				ID:    "gauge1",
+				MType: entity.Gauge,
+				Value: utils.Ptr(123.0),
+			},
+		},
+		{
+			name: "get valid counter metrics",
+			args: args{
+				id:    "counter1",
+				mType: entity.Counter,
+			},
+			want: entity.Metrics{
+				ID:    "counter1",
+				MType: entity.Counter,
+				Delta: utils.Ptr(int64(123)),
+			},
+		},
+		{
+			name: "get valid labeled gauge metrics",
+			args: args{
+				id:     "gauge1",
+				mType:  entity.Gauge,
+				labels: entity.Labels{"host": "a"},
+			},
+			want: entity.Metrics{
+				ID:     "gauge1",
+				MType:  entity.Gauge,
+				Value:  utils.Ptr(321.0),
+				Labels: entity.Labels{"host": "a"},
+			},
+		},
+		{
+			name: "gauge with non-existing labels",
+			args: args{
+				id:     "gauge1",
+				mType:  entity.Gauge,
+				labels: entity.Labels{"host": "b"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "gauge type but non-existing metrics",
+			args: args{
+				id:    "non-existing",
+				mType: entity.Gauge,
+			},
+			wantErr: true,
+		},
+		{
+			name: "counter type but non-existing metrics",
+			args: args{
+				id:    "non-existing",
+				mType: entity.Counter,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid type metrics",
+			args: args{
+				id:    "counter1",
+				mType: "invalid",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetOne(ctx, tt.args.id, tt.args.mType, tt.args.labels)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, got, tt.want)
+		})
+	}
+
+}
+
+func Test_boltStorage_Ping(t *testing.T) {
+	s := newTestBolt(t)
+	err := s.Ping(context.Background())
+	require.NoError(t, err)
+}
+
+func Test_boltStorage_Update(t *testing.T) {
+	s := newTestBolt(t)
+
+	ctx := context.Background()
+	type args struct {
+		batch entity.MetricsList
+		want  entity.MetricsList
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "proper work of update",
+			args: args{
+				batch: entity.MetricsList{
+					{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(123.0), Delta: utils.Ptr(int64(123))},
+					{ID: "counter1", MType: entity.Counter, Value: utils.Ptr(123.0), Delta: utils.Ptr(int64(123))},
+					{ID: "invalid-type", MType: "invalid", Value: utils.Ptr(123.0), Delta: utils.Ptr(int64(123))},
+				},
+				want: entity.MetricsList{
+					{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(123.0)},
+					{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(123))},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Update(ctx, tt.args.batch)
+			require.NoError(t, err)
+			batch, _ := s.GetAll(ctx)
+			require.ElementsMatch(t, batch, tt.args.want)
+		})
+	}
+}
+
+func Test_boltStorage_UpdateHistogram(t *testing.T) {
+	ctx := context.Background()
+
+	histogram := func(sum float64, count uint64, buckets ...entity.Bucket) *entity.HistogramValue {
+		return &entity.HistogramValue{Buckets: buckets, Sum: sum, Count: count}
+	}
+
+	s := newTestBolt(t)
+
+	err := s.Update(ctx, entity.MetricsList{
+		{ID: "latency", MType: entity.Histogram, Histogram: histogram(0.3, 2, entity.Bucket{UpperBound: 0.1, Count: 1}, entity.Bucket{UpperBound: 1, Count: 2})},
+		{ID: "latency", MType: entity.Histogram, Histogram: histogram(5, 1, entity.Bucket{UpperBound: 0.1}, entity.Bucket{UpperBound: 1})},
+	})
+	require.NoError(t, err)
+
+	err = s.Update(ctx, entity.MetricsList{
+		{ID: "latency", MType: entity.Histogram, Histogram: histogram(0.05, 1, entity.Bucket{UpperBound: 0.1, Count: 1}, entity.Bucket{UpperBound: 1, Count: 1})},
+	})
+	require.NoError(t, err)
+
+	want := histogram(5.35, 4, entity.Bucket{UpperBound: 0.1, Count: 2}, entity.Bucket{UpperBound: 1, Count: 3})
+
+	got, err := s.GetOne(ctx, "latency", entity.Histogram, nil)
+	require.NoError(t, err)
+	require.Equal(t, want.Buckets, got.Histogram.Buckets)
+	require.Equal(t, want.Count, got.Histogram.Count)
+	require.InDelta(t, want.Sum, got.Histogram.Sum, 1e-9)
+
+	// batch with mismatching buckets is rejected as a whole
+	err = s.Update(ctx, entity.MetricsList{
+		{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(1))},
+		{ID: "latency", MType: entity.Histogram, Histogram: histogram(1, 1, entity.Bucket{UpperBound: 2, Count: 1})},
+	})
+	require.ErrorIs(t, err, entity.ErrHistogramBucketsMismatch)
+
+	_, err = s.GetOne(ctx, "counter1", entity.Counter, nil)
+	require.ErrorIs(t, err, entity.ErrMetricNotFound)
+
+	history, err := s.GetRange(ctx, "latency", entity.Histogram, nil, time.Time{}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	require.Equal(t, uint64(4), history[2].Histogram.Count)
+}
+
+func Test_boltStorage_DeleteOne(t *testing.T) {
+	ctx := context.Background()
+
+	s := newTestBolt(t)
+
+	_ = s.Update(ctx, entity.MetricsList{
+		{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(123.0)},
+		{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(123))},
+	})
+
+	type args struct {
+		id     string
+		mType  string
+		labels entity.Labels
+	}
+	tests := []struct {
+		name    string
+		idx     int
+		args    args
+		want    entity.Metrics
+		wantErr bool
+	}{
+		{
+			name: "delete existing gauge metrics",
+			args: args{
+				id:    "gauge1",
+				mType: entity.Gauge,
+			},
+		},
+		{
+			name: "delete non-existing gauge metrics",
+			args: args{
+				id:    "non-existing",
+				mType: entity.Gauge,
+			},
+		},
+		{
+			name: "delete valid counter metrics",
+			args: args{
+				id:    "counter1",
+				mType: entity.Counter,
+			},
+		},
+		{
+			name: "delete non-existing counter metrics",
+			args: args{
+				id:    "non-existing",
+				mType: entity.Counter,
+			},
+		},
+		{
+			name: "delete invalid type metrics",
+			args: args{
+				id:    "gauge1",
+				mType: "invalid",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var exists bool
+			_, err := s.GetOne(ctx, tt.args.id, tt.args.mType, tt.args.labels)
+			if err != nil {
+				exists = true
+			}
+
+			err = s.DeleteOne(ctx, tt.args.id, tt.args.mType, tt.args.labels)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			if exists {
+				_, err = s.GetOne(ctx, tt.args.id, tt.args.mType, tt.args.labels)
+				require.Equal(t, err, entity.ErrMetricNotFound)
+			}
+		})
+	}
+
+}
+
+func Test_boltStorage_DeleteAll(t *testing.T) {
+	ctx := context.Background()
+
+	s := newTestBolt(t)
+
+	_ = s.Update(ctx, entity.MetricsList{
+		{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(123.0)},
+		{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(123))},
+	})
+
+	err := s.DeleteAll(ctx)
+	require.NoError(t, err)
+
+	list, _ := s.GetAll(ctx)
+	require.Empty(t, list)
+}
+
+func Test_boltStorage_GetRange(t *testing.T) {
+	ctx := context.Background()
+
+	s := newTestBolt(t)
+
+	start := time.Now()
+	for i := 1; i <= 3; i++ {
+		_ = s.Update(ctx, entity.MetricsList{
+			{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(float64(i))},
+			{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(i))},
+		})
+	}
+	end := time.Now()
+
+	type args struct {
+		id       string
+		mType    string
+		labels   entity.Labels
+		from, to time.Time
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []float64
+		wantErr error
+	}{
+		{
+			name: "gauge history",
+			args: args{id: "gauge1", mType: entity.Gauge, from: start, to: end},
+			want: []float64{1, 2, 3},
+		},
+		{
+			name: "counter history holds accumulated values",
+			args: args{id: "counter1", mType: entity.Counter, from: start, to: end},
+			want: []float64{1, 3, 6},
+		},
+		{
+			name: "empty range",
+			args: args{id: "gauge1", mType: entity.Gauge, from: end.Add(time.Hour), to: end.Add(2 * time.Hour)},
+			want: []float64{},
+		},
+		{
+			name:    "non-existing metrics",
+			args:    args{id: "non-existing", mType: entity.Gauge, from: start, to: end},
+			wantErr: entity.ErrMetricNotFound,
+		},
+		{
+			name:    "invalid type metrics",
+			args:    args{id: "gauge1", mType: "invalid", from: start, to: end},
+			wantErr: entity.ErrInvalidMetricType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetRange(ctx, tt.args.id, tt.args.mType, tt.args.labels, tt.args.from, tt.args.to)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			values := make([]float64, 0, len(got))
+			for _, sample := range got {
+				if sample.Delta != nil {
+					values = append(values, float64(*sample.Delta))
+				} else {
+					values = append(values, *sample.Value)
+				}
+			}
+			require.Equal(t, tt.want, values)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package storage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/pkg/storage"
+	"github.com/Imomali1/metrics/internal/pkg/storage/storagetest"
+)
+
+func TestMemory_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		s, err := storage.NewMemory()
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestBolt_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		s, err := storage.NewBolt(filepath.Join(t.TempDir(), "metrics.db"))
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestDB_Conformance(t *testing.T) {
+	skipWithoutDocker(t)
+
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		s, err := storage.NewDB(context.Background(), DSN)
+		require.NoError(t, err)
+		require.NoError(t, s.DeleteAll(context.Background()))
+		return s
+	})
+}
@@ -2,15 +2,17 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/Imomali1/metrics/internal/entity"
 )
 
 type Storage interface {
 	Update(ctx context.Context, batch entity.MetricsList) error
-	GetOne(ctx context.Context, id string, mType string) (entity.Metrics, error)
+	GetOne(ctx context.Context, id string, mType string, labels entity.Labels) (entity.Metrics, error)
 	GetAll(ctx context.Context) (entity.MetricsList, error)
-	DeleteOne(ctx context.Context, id string, mType string) error
+	GetRange(ctx context.Context, id string, mType string, labels entity.Labels, from, to time.Time) (entity.SampleList, error)
+	DeleteOne(ctx context.Context, id string, mType string, labels entity.Labels) error
 	DeleteAll(ctx context.Context) error
 	Ping(ctx context.Context) error
 	Close()
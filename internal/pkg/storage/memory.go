@@ -3,34 +3,80 @@ package storage
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/Imomali1/metrics/internal/entity"
 )
 
+// MaxHistorySize limits the number of samples every storage returns
+// per series, the oldest samples are discarded first.
+const MaxHistorySize = 10000
+
+// Memory keeps metrics in maps keyed by series key, see entity.SeriesKey.
 type Memory struct {
-	mu             sync.RWMutex
-	CounterStorage map[string]int64
-	GaugeStorage   map[string]float64
+	mu               sync.RWMutex
+	CounterStorage   map[string]int64
+	GaugeStorage     map[string]float64
+	HistogramStorage map[string]*entity.HistogramValue
+	SummaryStorage   map[string]*entity.SummaryValue
+	CounterHistory   map[string]entity.SampleList
+	GaugeHistory     map[string]entity.SampleList
+	HistogramHistory map[string]entity.SampleList
+	SummaryHistory   map[string]entity.SampleList
+	Labels           map[string]entity.Labels
+	closed           bool
 }
 
 func NewMemory() (Storage, error) {
 	return &Memory{
-		CounterStorage: make(map[string]int64),
-		GaugeStorage:   make(map[string]float64),
+		CounterStorage:   make(map[string]int64),
+		GaugeStorage:     make(map[string]float64),
+		HistogramStorage: make(map[string]*entity.HistogramValue),
+		SummaryStorage:   make(map[string]*entity.SummaryValue),
+		CounterHistory:   make(map[string]entity.SampleList),
+		GaugeHistory:     make(map[string]entity.SampleList),
+		HistogramHistory: make(map[string]entity.SampleList),
+		SummaryHistory:   make(map[string]entity.SampleList),
+		Labels:           make(map[string]entity.Labels),
 	}, nil
 }
 
-func (s *Memory) DeleteOne(_ context.Context, id string, mType string) error {
+func (s *Memory) DeleteOne(_ context.Context, id string, mType string, labels entity.Labels) error {
+	if !entity.IsValidType(mType) {
+		return entity.ErrInvalidMetricType
+	}
+
+	key := entity.SeriesKey(id, labels)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+
 	switch mType {
 	case entity.Gauge:
-		delete(s.GaugeStorage, id)
+		delete(s.GaugeStorage, key)
+		delete(s.GaugeHistory, key)
 	case entity.Counter:
-		delete(s.CounterStorage, id)
-	default:
-		return fmt.Errorf("unknown type: %s", mType)
+		delete(s.CounterStorage, key)
+		delete(s.CounterHistory, key)
+	case entity.Histogram:
+		delete(s.HistogramStorage, key)
+		delete(s.HistogramHistory, key)
+	case entity.Summary:
+		delete(s.SummaryStorage, key)
+		delete(s.SummaryHistory, key)
+	}
+
+	_, counterExists := s.CounterStorage[key]
+	_, gaugeExists := s.GaugeStorage[key]
+	_, histogramExists := s.HistogramStorage[key]
+	_, summaryExists := s.SummaryStorage[key]
+	if !counterExists && !gaugeExists && !histogramExists && !summaryExists {
+		delete(s.Labels, key)
 	}
 	return nil
 }
@@ -38,71 +84,207 @@ func (s *Memory) DeleteOne(_ context.Context, id string, mType string) error {
 func (s *Memory) DeleteAll(_ context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+
 	s.CounterStorage = make(map[string]int64)
 	s.GaugeStorage = make(map[string]float64)
+	s.HistogramStorage = make(map[string]*entity.HistogramValue)
+	s.SummaryStorage = make(map[string]*entity.SummaryValue)
+	s.CounterHistory = make(map[string]entity.SampleList)
+	s.GaugeHistory = make(map[string]entity.SampleList)
+	s.HistogramHistory = make(map[string]entity.SampleList)
+	s.SummaryHistory = make(map[string]entity.SampleList)
+	s.Labels = make(map[string]entity.Labels)
 	return nil
 }
 
 func (s *Memory) Update(_ context.Context, batch entity.MetricsList) error {
+	now := time.Now()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+
+	histograms, err := s.mergeHistograms(batch)
+	if err != nil {
+		return err
+	}
+
 	for _, one := range batch {
+		key := one.Key()
 		if one.MType == entity.Counter {
 			delta := *one.Delta
-			s.CounterStorage[one.ID] += delta
+			s.CounterStorage[key] += delta
+			total := s.CounterStorage[key]
+			s.CounterHistory[key] = appendSample(s.CounterHistory[key],
+				entity.Sample{Timestamp: now, Delta: &total})
 		} else if one.MType == entity.Gauge {
 			value := *one.Value
-			s.GaugeStorage[one.ID] = value
+			s.GaugeStorage[key] = value
+			s.GaugeHistory[key] = appendSample(s.GaugeHistory[key],
+				entity.Sample{Timestamp: now, Value: &value})
+		} else if one.MType == entity.Histogram {
+			s.HistogramStorage[key] = histograms[key]
+			s.HistogramHistory[key] = appendSample(s.HistogramHistory[key],
+				entity.Sample{Timestamp: now, Histogram: histograms[key].Clone()})
+		} else if one.MType == entity.Summary {
+			summary := one.Summary.Clone()
+			if current, ok := s.SummaryStorage[key]; ok {
+				summary = current.Merge(one.Summary)
+			}
+			s.SummaryStorage[key] = summary
+			s.SummaryHistory[key] = appendSample(s.SummaryHistory[key],
+				entity.Sample{Timestamp: now, Summary: summary.Clone()})
+		} else {
+			continue
+		}
+
+		if _, ok := s.Labels[key]; !ok && len(one.Labels) != 0 {
+			s.Labels[key] = one.Labels.Clone()
 		}
 	}
 
 	return nil
 }
 
-func (s *Memory) GetOne(_ context.Context, id string, mType string) (entity.Metrics, error) {
-	var metric = entity.Metrics{ID: id, MType: mType}
+// mergeHistograms merges histograms of batch with stored ones
+// before anything is updated, so batch with mismatching buckets
+// is rejected as a whole. Caller must hold the lock.
+func (s *Memory) mergeHistograms(batch entity.MetricsList) (map[string]*entity.HistogramValue, error) {
+	var merged map[string]*entity.HistogramValue
+	for _, one := range batch {
+		if one.MType != entity.Histogram {
+			continue
+		}
+
+		if merged == nil {
+			merged = make(map[string]*entity.HistogramValue)
+		}
+
+		key := one.Key()
+		current, ok := merged[key]
+		if !ok {
+			current, ok = s.HistogramStorage[key]
+		}
+
+		if !ok {
+			merged[key] = one.Histogram.Clone()
+			continue
+		}
+
+		next, err := current.Merge(one.Histogram)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		merged[key] = next
+	}
+
+	return merged, nil
+}
+
+func appendSample(history entity.SampleList, sample entity.Sample) entity.SampleList {
+	history = append(history, sample)
+	if len(history) > MaxHistorySize {
+		history = history[len(history)-MaxHistorySize:]
+	}
+	return history
+}
+
+func (s *Memory) GetOne(_ context.Context, id string, mType string, labels entity.Labels) (entity.Metrics, error) {
+	if !entity.IsValidType(mType) {
+		return entity.Metrics{}, entity.ErrInvalidMetricType
+	}
+
+	var metric = entity.Metrics{ID: id, MType: mType, Labels: labels.Clone()}
+	key := entity.SeriesKey(id, labels)
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	if s.closed {
+		return entity.Metrics{}, ErrClosed
+	}
+
 	if mType == entity.Counter {
-		delta, ok := s.CounterStorage[id]
+		delta, ok := s.CounterStorage[key]
 		if !ok {
 			return entity.Metrics{}, entity.ErrMetricNotFound
 		}
 		metric.Delta = &delta
 	} else if mType == entity.Gauge {
-		value, ok := s.GaugeStorage[id]
+		value, ok := s.GaugeStorage[key]
 		if !ok {
 			return entity.Metrics{}, entity.ErrMetricNotFound
 		}
 		metric.Value = &value
+	} else if mType == entity.Histogram {
+		histogram, ok := s.HistogramStorage[key]
+		if !ok {
+			return entity.Metrics{}, entity.ErrMetricNotFound
+		}
+		metric.Histogram = histogram.Clone()
+	} else if mType == entity.Summary {
+		summary, ok := s.SummaryStorage[key]
+		if !ok {
+			return entity.Metrics{}, entity.ErrMetricNotFound
+		}
+		metric.Summary = summary.Clone()
 	}
 	return metric, nil
 }
 
 func (s *Memory) GetAll(_ context.Context) (entity.MetricsList, error) {
-	allMetrics := make(entity.MetricsList, len(s.CounterStorage)+len(s.GaugeStorage))
-	idx := 0
-
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	allMetrics := make(entity.MetricsList,
+		len(s.CounterStorage)+len(s.GaugeStorage)+len(s.HistogramStorage)+len(s.SummaryStorage))
+	idx := 0
 
-	for name, delta := range s.CounterStorage {
+	for key, delta := range s.CounterStorage {
 		tmp := delta
 		allMetrics[idx] = entity.Metrics{
-			MType: entity.Counter,
-			ID:    name,
-			Delta: &tmp,
+			MType:  entity.Counter,
+			ID:     s.nameOf(key),
+			Delta:  &tmp,
+			Labels: s.Labels[key].Clone(),
 		}
 		idx++
 	}
 
-	for name, value := range s.GaugeStorage {
+	for key, value := range s.GaugeStorage {
 		tmp := value
 		allMetrics[idx] = entity.Metrics{
-			MType: entity.Gauge,
-			ID:    name,
-			Value: &tmp,
+			MType:  entity.Gauge,
+			ID:     s.nameOf(key),
+			Value:  &tmp,
+			Labels: s.Labels[key].Clone(),
+		}
+		idx++
+	}
+
+	for key, histogram := range s.HistogramStorage {
+		allMetrics[idx] = entity.Metrics{
+			MType:     entity.Histogram,
+			ID:        s.nameOf(key),
+			Histogram: histogram.Clone(),
+			Labels:    s.Labels[key].Clone(),
+		}
+		idx++
+	}
+
+	for key, summary := range s.SummaryStorage {
+		allMetrics[idx] = entity.Metrics{
+			MType:   entity.Summary,
+			ID:      s.nameOf(key),
+			Summary: summary.Clone(),
+			Labels:  s.Labels[key].Clone(),
 		}
 		idx++
 	}
@@ -110,11 +292,91 @@ func (s *Memory) GetAll(_ context.Context) (entity.MetricsList, error) {
 	return allMetrics, nil
 }
 
+// nameOf returns metrics name of series key, caller must hold the lock.
+func (s *Memory) nameOf(key string) string {
+	labels, ok := s.Labels[key]
+	if !ok {
+		return key
+	}
+	return key[:len(key)-len(labels.String())-2]
+}
+
+func (s *Memory) GetRange(
+	_ context.Context,
+	id, mType string,
+	labels entity.Labels,
+	from, to time.Time,
+) (entity.SampleList, error) {
+	key := entity.SeriesKey(id, labels)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	var history entity.SampleList
+	var ok bool
+	switch mType {
+	case entity.Counter:
+		history, ok = s.CounterHistory[key]
+	case entity.Gauge:
+		history, ok = s.GaugeHistory[key]
+	case entity.Histogram:
+		history, ok = s.HistogramHistory[key]
+	case entity.Summary:
+		history, ok = s.SummaryHistory[key]
+	default:
+		return nil, entity.ErrInvalidMetricType
+	}
+
+	if !ok {
+		return nil, entity.ErrMetricNotFound
+	}
+
+	// samples are appended in chronological order
+	start := sort.Search(len(history), func(i int) bool {
+		return !history[i].Timestamp.Before(from)
+	})
+	end := sort.Search(len(history), func(i int) bool {
+		return history[i].Timestamp.After(to)
+	})
+
+	if end < start {
+		end = start
+	}
+
+	samples := append(entity.SampleList{}, history[start:end]...)
+	for i := range samples {
+		samples[i].Histogram = samples[i].Histogram.Clone()
+		samples[i].Summary = samples[i].Summary.Clone()
+	}
+	return samples, nil
+}
+
 func (s *Memory) Ping(_ context.Context) error {
-	return fmt.Errorf("storage instance is not database, it is memory based")
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrClosed
+	}
+
+	return nil
 }
 
+// Close releases stored metrics, the storage cannot be used after that.
 func (s *Memory) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
 	s.GaugeStorage = nil
 	s.CounterStorage = nil
+	s.HistogramStorage = nil
+	s.SummaryStorage = nil
+	s.GaugeHistory = nil
+	s.CounterHistory = nil
+	s.HistogramHistory = nil
+	s.SummaryHistory = nil
+	s.Labels = nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -26,6 +27,8 @@ func Test_memoryStorage_GetAll(t *testing.T) {
 	metrics := entity.MetricsList{
 		{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(123.0)},
 		{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(123))},
+		{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(321.0), Labels: entity.Labels{"host": "a"}},
+		{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(321)), Labels: entity.Labels{"host": "a"}},
 	}
 
 	_ = s.Update(ctx, metrics)
@@ -43,13 +46,15 @@ func Test_memoryStorage_GetOne(t *testing.T) {
 	metrics := entity.MetricsList{
 		{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(123.0)},
 		{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(123))},
+		{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(321.0), Labels: entity.Labels{"host": "a"}},
 	}
 
 	_ = s.Update(ctx, metrics)
 
 	type args struct {
-		id    string
-		mType string
+		id     string
+		mType  string
+		labels entity.Labels
 	}
 	tests := []struct {
 		name    string
@@ -82,6 +87,29 @@ func Test_memoryStorage_GetOne(t *testing.T) {
 				Delta: utils.Ptr(int64(123)),
 			},
 		},
+		{
+			name: "get valid labeled gauge metrics",
+			args: args{
+				id:     "gauge1",
+				mType:  entity.Gauge,
+				labels: entity.Labels{"host": "a"},
+			},
+			want: entity.Metrics{
+				ID:     "gauge1",
+				MType:  entity.Gauge,
+				Value:  utils.Ptr(321.0),
+				Labels: entity.Labels{"host": "a"},
+			},
+		},
+		{
+			name: "gauge with non-existing labels",
+			args: args{
+				id:     "gauge1",
+				mType:  entity.Gauge,
+				labels: entity.Labels{"host": "b"},
+			},
+			wantErr: true,
+		},
 		{
 			name: "gauge type but non-existing metrics",
 			args: args{
@@ -104,12 +132,12 @@ func Test_memoryStorage_GetOne(t *testing.T) {
 				id:    "counter1",
 				mType: "invalid",
 			},
-			want: entity.Metrics{ID: "counter1", MType: "invalid"},
+			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := s.GetOne(ctx, tt.args.id, tt.args.mType)
+			got, err := s.GetOne(ctx, tt.args.id, tt.args.mType, tt.args.labels)
 			if tt.wantErr {
 				require.Error(t, err)
 				return
@@ -124,7 +152,7 @@ func Test_memoryStorage_GetOne(t *testing.T) {
 func Test_memoryStorage_Ping(t *testing.T) {
 	s, _ := NewMemory()
 	err := s.Ping(context.Background())
-	require.Error(t, err)
+	require.NoError(t, err)
 }
 
 func Test_memoryStorage_Update(t *testing.T) {
@@ -164,11 +192,72 @@ func Test_memoryStorage_Update(t *testing.T) {
 	}
 }
 
+func Test_memoryStorage_UpdateHistogram(t *testing.T) {
+	ctx := context.Background()
+
+	histogram := func(sum float64, count uint64, buckets ...entity.Bucket) *entity.HistogramValue {
+		return &entity.HistogramValue{Buckets: buckets, Sum: sum, Count: count}
+	}
+
+	s, _ := NewMemory()
+
+	err := s.Update(ctx, entity.MetricsList{
+		{ID: "latency", MType: entity.Histogram, Histogram: histogram(0.3, 2, entity.Bucket{UpperBound: 0.1, Count: 1}, entity.Bucket{UpperBound: 1, Count: 2})},
+		{ID: "latency", MType: entity.Histogram, Histogram: histogram(5, 1, entity.Bucket{UpperBound: 0.1}, entity.Bucket{UpperBound: 1})},
+	})
+	require.NoError(t, err)
+
+	err = s.Update(ctx, entity.MetricsList{
+		{ID: "latency", MType: entity.Histogram, Histogram: histogram(0.05, 1, entity.Bucket{UpperBound: 0.1, Count: 1}, entity.Bucket{UpperBound: 1, Count: 1})},
+	})
+	require.NoError(t, err)
+
+	want := histogram(5.35, 4, entity.Bucket{UpperBound: 0.1, Count: 2}, entity.Bucket{UpperBound: 1, Count: 3})
+
+	got, err := s.GetOne(ctx, "latency", entity.Histogram, nil)
+	require.NoError(t, err)
+	require.Equal(t, want.Buckets, got.Histogram.Buckets)
+	require.Equal(t, want.Count, got.Histogram.Count)
+	require.InDelta(t, want.Sum, got.Histogram.Sum, 1e-9)
+
+	// batch with mismatching buckets is rejected as a whole
+	err = s.Update(ctx, entity.MetricsList{
+		{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(1))},
+		{ID: "latency", MType: entity.Histogram, Histogram: histogram(1, 1, entity.Bucket{UpperBound: 2, Count: 1})},
+	})
+	require.ErrorIs(t, err, entity.ErrHistogramBucketsMismatch)
+
+	_, err = s.GetOne(ctx, "counter1", entity.Counter, nil)
+	require.ErrorIs(t, err, entity.ErrMetricNotFound)
+
+	history, err := s.GetRange(ctx, "latency", entity.Histogram, nil, time.Time{}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	require.Equal(t, uint64(4), history[2].Histogram.Count)
+
+	// returned histograms are copies of stored ones
+	history[2].Histogram.Buckets[0].Count = 100
+	got, err = s.GetOne(ctx, "latency", entity.Histogram, nil)
+	require.NoError(t, err)
+	require.Equal(t, want.Buckets, got.Histogram.Buckets)
+
+	history, err = s.GetRange(ctx, "latency", entity.Histogram, nil, time.Time{}, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, want.Buckets, history[2].Histogram.Buckets)
+}
+
 func Test_newMemoryStorage(t *testing.T) {
 	want := &Memory{
-		mu:             sync.RWMutex{},
-		CounterStorage: make(map[string]int64),
-		GaugeStorage:   make(map[string]float64),
+		mu:               sync.RWMutex{},
+		CounterStorage:   make(map[string]int64),
+		GaugeStorage:     make(map[string]float64),
+		HistogramStorage: make(map[string]*entity.HistogramValue),
+		SummaryStorage:   make(map[string]*entity.SummaryValue),
+		CounterHistory:   make(map[string]entity.SampleList),
+		GaugeHistory:     make(map[string]entity.SampleList),
+		HistogramHistory: make(map[string]entity.SampleList),
+		SummaryHistory:   make(map[string]entity.SampleList),
+		Labels:           make(map[string]entity.Labels),
 	}
 
 	got, err := NewMemory()
@@ -187,8 +276,9 @@ func Test_memoryStorage_DeleteOne(t *testing.T) {
 	})
 
 	type args struct {
-		id    string
-		mType string
+		id     string
+		mType  string
+		labels entity.Labels
 	}
 	tests := []struct {
 		name    string
@@ -237,12 +327,12 @@ func Test_memoryStorage_DeleteOne(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var exists bool
-			_, err := s.GetOne(ctx, tt.args.id, tt.args.mType)
+			_, err := s.GetOne(ctx, tt.args.id, tt.args.mType, tt.args.labels)
 			if err != nil {
 				exists = true
 			}
 
-			err = s.DeleteOne(ctx, tt.args.id, tt.args.mType)
+			err = s.DeleteOne(ctx, tt.args.id, tt.args.mType, tt.args.labels)
 			if tt.wantErr {
 				require.Error(t, err)
 				return
@@ -250,7 +340,7 @@ func Test_memoryStorage_DeleteOne(t *testing.T) {
 			require.NoError(t, err)
 
 			if exists {
-				_, err = s.GetOne(ctx, tt.args.id, tt.args.mType)
+				_, err = s.GetOne(ctx, tt.args.id, tt.args.mType, tt.args.labels)
 				require.Equal(t, err, entity.ErrMetricNotFound)
 			}
 		})
@@ -274,3 +364,77 @@ func Test_memoryStorage_DeleteAll(t *testing.T) {
 	list, _ := s.GetAll(ctx)
 	require.Empty(t, list)
 }
+
+func Test_memoryStorage_GetRange(t *testing.T) {
+	ctx := context.Background()
+
+	s, _ := NewMemory()
+
+	start := time.Now()
+	for i := 1; i <= 3; i++ {
+		_ = s.Update(ctx, entity.MetricsList{
+			{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(float64(i))},
+			{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(i))},
+		})
+	}
+	end := time.Now()
+
+	type args struct {
+		id       string
+		mType    string
+		labels   entity.Labels
+		from, to time.Time
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []float64
+		wantErr error
+	}{
+		{
+			name: "gauge history",
+			args: args{id: "gauge1", mType: entity.Gauge, from: start, to: end},
+			want: []float64{1, 2, 3},
+		},
+		{
+			name: "counter history holds accumulated values",
+			args: args{id: "counter1", mType: entity.Counter, from: start, to: end},
+			want: []float64{1, 3, 6},
+		},
+		{
+			name: "empty range",
+			args: args{id: "gauge1", mType: entity.Gauge, from: end.Add(time.Hour), to: end.Add(2 * time.Hour)},
+			want: []float64{},
+		},
+		{
+			name:    "non-existing metrics",
+			args:    args{id: "non-existing", mType: entity.Gauge, from: start, to: end},
+			wantErr: entity.ErrMetricNotFound,
+		},
+		{
+			name:    "invalid type metrics",
+			args:    args{id: "gauge1", mType: "invalid", from: start, to: end},
+			wantErr: entity.ErrInvalidMetricType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetRange(ctx, tt.args.id, tt.args.mType, tt.args.labels, tt.args.from, tt.args.to)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			values := make([]float64, 0, len(got))
+			for _, sample := range got {
+				if sample.Delta != nil {
+					values = append(values, float64(*sample.Delta))
+				} else {
+					values = append(values, *sample.Value)
+				}
+			}
+			require.Equal(t, tt.want, values)
+		})
+	}
+}
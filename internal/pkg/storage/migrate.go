@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationsLockID is key of advisory lock serializing migrations
+// of servers started at the same time.
+const migrationsLockID = 7_301_925_413
+
+var ErrInvalidMigration = errors.New("invalid migration")
+
+// Migration is a versioned schema change, files are named
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrations returns embedded migrations ordered by version.
+func Migrations() ([]Migration, error) {
+	return loadMigrations(migrationsFS, "migrations")
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		base, direction, found := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		rawVersion, name, ok := strings.Cut(base, "_")
+		version, errParse := strconv.ParseInt(rawVersion, 10, 64)
+		if !found || !ok || errParse != nil || version <= 0 {
+			return nil, fmt.Errorf("%w: unexpected file name %s", ErrInvalidMigration, entry.Name())
+		}
+
+		content, errRead := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if errRead != nil {
+			return nil, errRead
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+
+		if m.Name != name {
+			return nil, fmt.Errorf("%w: version %d has different names", ErrInvalidMigration, version)
+		}
+
+		switch direction {
+		case "up":
+			m.Up = string(content)
+		case "down":
+			m.Down = string(content)
+		default:
+			return nil, fmt.Errorf("%w: unexpected file name %s", ErrInvalidMigration, entry.Name())
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("%w: version %d must have up and down steps", ErrInvalidMigration, m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func createMigrationsTable(ctx context.Context, pool *pgxpool.Pool) error {
+	_, err := pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+    		version BIGINT PRIMARY KEY,
+    		name TEXT NOT NULL,
+    		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`)
+	return err
+}
+
+// SchemaVersion returns version of the last applied migration, 0 if none.
+func SchemaVersion(ctx context.Context, pool *pgxpool.Pool) (int64, error) {
+	if err := createMigrationsTable(ctx, pool); err != nil {
+		return 0, err
+	}
+
+	var version int64
+	err := pool.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// MigrationStatus is embedded migration and its state in database.
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	// AppliedAt is zero for pending migration.
+	AppliedAt time.Time
+}
+
+// Applied reports whether migration is applied.
+func (s MigrationStatus) Applied() bool {
+	return !s.AppliedAt.IsZero()
+}
+
+// MigrationsStatus returns state of embedded migrations ordered by version.
+func MigrationsStatus(ctx context.Context, pool *pgxpool.Pool) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = createMigrationsTable(ctx, pool); err != nil {
+		return nil, err
+	}
+
+	rows, err := pool.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]time.Time)
+	var (
+		version   int64
+		appliedAt time.Time
+	)
+	_, err = pgx.ForEachRow(rows, []any{&version, &appliedAt}, func() error {
+		applied[version] = appliedAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		list = append(list, MigrationStatus{Version: m.Version, Name: m.Name, AppliedAt: applied[m.Version]})
+	}
+
+	return list, nil
+}
+
+// MigrateUp applies pending migrations, every migration
+// is applied in its own transaction.
+func MigrateUp(ctx context.Context, pool *pgxpool.Pool) error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+
+	if err = createMigrationsTable(ctx, pool); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		err = inMigrationTx(ctx, pool, func(tx pgx.Tx) error {
+			var applied bool
+			errTx := tx.QueryRow(ctx,
+				`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, m.Version,
+			).Scan(&applied)
+			if errTx != nil || applied {
+				return errTx
+			}
+
+			if _, errTx = tx.Exec(ctx, m.Up); errTx != nil {
+				return errTx
+			}
+
+			_, errTx = tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+			return errTx
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// MigrateDown reverts the last steps applied migrations.
+func MigrateDown(ctx context.Context, pool *pgxpool.Pool, steps int) error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+
+	if err = createMigrationsTable(ctx, pool); err != nil {
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+		m := migrations[i]
+
+		var reverted bool
+		err = inMigrationTx(ctx, pool, func(tx pgx.Tx) error {
+			tag, errTx := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+			if errTx != nil || tag.RowsAffected() == 0 {
+				return errTx
+			}
+
+			reverted = true
+			_, errTx = tx.Exec(ctx, m.Down)
+			return errTx
+		})
+		if err != nil {
+			return fmt.Errorf("failed to revert migration %d_%s: %w", m.Version, m.Name, err)
+		}
+
+		if reverted {
+			steps--
+		}
+	}
+
+	return nil
+}
+
+// inMigrationTx runs fn in transaction holding migrations advisory lock.
+func inMigrationTx(ctx context.Context, pool *pgxpool.Pool, fn func(tx pgx.Tx) error) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationsLockID)
+	if err == nil {
+		err = fn(tx)
+	}
+
+	if err != nil {
+		if errRollBack := tx.Rollback(ctx); errRollBack != nil {
+			return fmt.Errorf("migration error: %w; rollback error: %w", err, errRollBack)
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
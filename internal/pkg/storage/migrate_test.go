@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		require.Equal(t, int64(i+1), m.Version, "versions must be consecutive")
+		require.NotEmpty(t, m.Up)
+		require.NotEmpty(t, m.Down)
+	}
+}
+
+func Test_loadMigrations(t *testing.T) {
+	file := func(data string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(data)}
+	}
+
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []Migration
+		wantErr bool
+	}{
+		{
+			name: "ordered by version",
+			fsys: fstest.MapFS{
+				"m/0010_b.up.sql":   file("up b"),
+				"m/0010_b.down.sql": file("down b"),
+				"m/0002_a.up.sql":   file("up a"),
+				"m/0002_a.down.sql": file("down a"),
+			},
+			want: []Migration{
+				{Version: 2, Name: "a", Up: "up a", Down: "down a"},
+				{Version: 10, Name: "b", Up: "up b", Down: "down b"},
+			},
+		},
+		{
+			name:    "missing down step",
+			fsys:    fstest.MapFS{"m/0001_a.up.sql": file("up")},
+			wantErr: true,
+		},
+		{
+			name:    "invalid version",
+			fsys:    fstest.MapFS{"m/first_a.up.sql": file("up"), "m/first_a.down.sql": file("down")},
+			wantErr: true,
+		},
+		{
+			name:    "unknown direction",
+			fsys:    fstest.MapFS{"m/0001_a.sideways.sql": file("up")},
+			wantErr: true,
+		},
+		{
+			name: "different names of version",
+			fsys: fstest.MapFS{
+				"m/0001_a.up.sql":   file("up"),
+				"m/0001_b.down.sql": file("down"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadMigrations(tt.fsys, "m")
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidMigration)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
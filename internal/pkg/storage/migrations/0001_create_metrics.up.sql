@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS counter (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    delta BIGINT
+);
+
+CREATE TABLE IF NOT EXISTS gauge (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    value DOUBLE PRECISION
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS counter_history;
+DROP TABLE IF EXISTS gauge_history;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS counter_history (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    delta BIGINT,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS counter_history_name_created_at_idx
+ON counter_history (name, created_at);
+
+CREATE TABLE IF NOT EXISTS gauge_history (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    value DOUBLE PRECISION,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS gauge_history_name_created_at_idx
+ON gauge_history (name, created_at);
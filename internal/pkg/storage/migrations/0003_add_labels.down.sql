@@ -0,0 +1,16 @@
+-- labeled series cannot be kept once name is unique again
+DELETE FROM counter WHERE labels <> '{}'::jsonb;
+DELETE FROM gauge WHERE labels <> '{}'::jsonb;
+DELETE FROM counter_history WHERE labels <> '{}'::jsonb;
+DELETE FROM gauge_history WHERE labels <> '{}'::jsonb;
+
+DROP INDEX IF EXISTS counter_name_labels_key;
+DROP INDEX IF EXISTS gauge_name_labels_key;
+
+ALTER TABLE counter DROP COLUMN IF EXISTS labels;
+ALTER TABLE gauge DROP COLUMN IF EXISTS labels;
+ALTER TABLE counter_history DROP COLUMN IF EXISTS labels;
+ALTER TABLE gauge_history DROP COLUMN IF EXISTS labels;
+
+ALTER TABLE counter ADD CONSTRAINT counter_name_key UNIQUE (name);
+ALTER TABLE gauge ADD CONSTRAINT gauge_name_key UNIQUE (name);
@@ -0,0 +1,11 @@
+-- labels are part of metrics identity, name alone is not unique anymore
+ALTER TABLE counter ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}'::jsonb;
+ALTER TABLE gauge ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}'::jsonb;
+ALTER TABLE counter_history ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}'::jsonb;
+ALTER TABLE gauge_history ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+ALTER TABLE counter DROP CONSTRAINT IF EXISTS counter_name_key;
+ALTER TABLE gauge DROP CONSTRAINT IF EXISTS gauge_name_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS counter_name_labels_key ON counter (name, labels);
+CREATE UNIQUE INDEX IF NOT EXISTS gauge_name_labels_key ON gauge (name, labels);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS histogram;
+DROP TABLE IF EXISTS histogram_history;
@@ -0,0 +1,20 @@
+-- histogram is stored as JSON document of entity.HistogramValue
+CREATE TABLE IF NOT EXISTS histogram (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    labels JSONB NOT NULL DEFAULT '{}'::jsonb,
+    data JSONB
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS histogram_name_labels_key ON histogram (name, labels);
+
+CREATE TABLE IF NOT EXISTS histogram_history (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    labels JSONB NOT NULL DEFAULT '{}'::jsonb,
+    data JSONB,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS histogram_history_name_created_at_idx
+ON histogram_history (name, created_at);
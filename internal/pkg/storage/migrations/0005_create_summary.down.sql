@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS summary;
+DROP TABLE IF EXISTS summary_history;
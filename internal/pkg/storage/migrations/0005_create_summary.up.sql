@@ -0,0 +1,20 @@
+-- summary is stored as JSON document of entity.SummaryValue
+CREATE TABLE IF NOT EXISTS summary (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    labels JSONB NOT NULL DEFAULT '{}'::jsonb,
+    data JSONB
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS summary_name_labels_key ON summary (name, labels);
+
+CREATE TABLE IF NOT EXISTS summary_history (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    labels JSONB NOT NULL DEFAULT '{}'::jsonb,
+    data JSONB,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS summary_history_name_created_at_idx
+ON summary_history (name, created_at);
@@ -0,0 +1,13 @@
+CREATE INDEX IF NOT EXISTS counter_history_name_created_at_idx
+ON counter_history (name, created_at);
+CREATE INDEX IF NOT EXISTS gauge_history_name_created_at_idx
+ON gauge_history (name, created_at);
+CREATE INDEX IF NOT EXISTS histogram_history_name_created_at_idx
+ON histogram_history (name, created_at);
+CREATE INDEX IF NOT EXISTS summary_history_name_created_at_idx
+ON summary_history (name, created_at);
+
+DROP INDEX IF EXISTS counter_history_name_labels_created_at_idx;
+DROP INDEX IF EXISTS gauge_history_name_labels_created_at_idx;
+DROP INDEX IF EXISTS histogram_history_name_labels_created_at_idx;
+DROP INDEX IF EXISTS summary_history_name_labels_created_at_idx;
@@ -0,0 +1,14 @@
+-- history is selected and trimmed by series, that is name and labels
+CREATE INDEX IF NOT EXISTS counter_history_name_labels_created_at_idx
+ON counter_history (name, labels, created_at);
+CREATE INDEX IF NOT EXISTS gauge_history_name_labels_created_at_idx
+ON gauge_history (name, labels, created_at);
+CREATE INDEX IF NOT EXISTS histogram_history_name_labels_created_at_idx
+ON histogram_history (name, labels, created_at);
+CREATE INDEX IF NOT EXISTS summary_history_name_labels_created_at_idx
+ON summary_history (name, labels, created_at);
+
+DROP INDEX IF EXISTS counter_history_name_created_at_idx;
+DROP INDEX IF EXISTS gauge_history_name_created_at_idx;
+DROP INDEX IF EXISTS histogram_history_name_created_at_idx;
+DROP INDEX IF EXISTS summary_history_name_created_at_idx;
@@ -1,83 +1,170 @@
 package storage
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/Imomali1/metrics/internal/entity"
-	"github.com/Imomali1/metrics/internal/pkg/utils"
+	"github.com/Imomali1/metrics/internal/pkg/retry"
 )
 
 type DB struct {
 	Pool *pgxpool.Pool
+
+	// inserts counts history records of series inserted since its
+	// history was trimmed last time, see historyTrimEvery.
+	mu      sync.Mutex
+	inserts map[string]int
+	// closed is set by Close, pool does not report it with sentinel error.
+	closed atomic.Bool
 }
 
-func NewDB(ctx context.Context, dsn string) (Storage, error) {
+// historyTrimEvery is number of history records of series inserted
+// between trims of its history, so history may exceed MaxHistorySize
+// until the next trim and GetRange skips samples beyond the limit.
+const historyTrimEvery = 100
+
+func NewDB(ctx context.Context, dsn string, opts ...Option) (Storage, error) {
+	var options storageOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	config, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	var pool *pgxpool.Pool
-	err = utils.DoWithRetries(func() error {
-		pool, err = pgxpool.NewWithConfig(ctx, config)
-		return err
-	})
+	policy := retry.DefaultPolicy()
+	if options.retryPolicy != nil {
+		policy = *options.retryPolicy
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = isRetryableDBError
+	}
 
+	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
 		return nil, err
 	}
 
-	if err = pool.Ping(ctx); err != nil {
+	// pool connects lazily, so availability of database is checked by ping
+	err = policy.Do(ctx, func(ctx context.Context) error {
+		return pool.Ping(ctx)
+	})
+	if err != nil {
+		pool.Close()
 		return nil, err
 	}
 
-	if err = CreateTable(ctx, pool); err != nil {
-		return nil, err
+	if !options.skipMigrations {
+		if err = MigrateUp(ctx, pool); err != nil {
+			pool.Close()
+			return nil, err
+		}
 	}
 
 	return &DB{Pool: pool}, nil
 }
 
-func CreateTable(ctx context.Context, pool *pgxpool.Pool) error {
-	var (
-		counterTable = `
-		CREATE TABLE IF NOT EXISTS counter (
-    		id SERIAL PRIMARY KEY,
-    		name TEXT NOT NULL UNIQUE,
-    		delta BIGINT
-		)`
-
-		gaugeTable = `
-		CREATE TABLE IF NOT EXISTS gauge (
-    		id SERIAL PRIMARY KEY,
-    		name TEXT NOT NULL UNIQUE,
-    		value DOUBLE PRECISION
-		)`
-	)
-
-	statements := []string{counterTable, gaugeTable}
-
-	tx, err := pool.Begin(ctx)
-	if err != nil {
-		return err
+// isRetryableDBError treats connection failures and starting up server
+// as transient, errors of authentication or missing database are not.
+func isRetryableDBError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "08") || pgErr.Code == "57P03"
 	}
 
-	for _, statement := range statements {
-		_, err = tx.Exec(ctx, statement)
-		if err != nil {
-			return err
-		}
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) {
+		return true
 	}
 
-	return tx.Commit(ctx)
+	return retry.IsRetryable(err)
 }
 
+// Upserts of metrics combined with history records, counters and sum and
+// count of summaries are incremented atomically, so concurrent updates
+// do not lose deltas.
+const (
+	counterUpsert = `
+	WITH upserted AS (
+		INSERT INTO counter (name, labels, delta)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name, labels)
+		DO UPDATE
+		SET delta = COALESCE(counter.delta, 0) + EXCLUDED.delta
+		RETURNING name, labels, delta
+	)
+	INSERT INTO counter_history (name, labels, delta, created_at)
+	SELECT name, labels, delta, $4::timestamptz FROM upserted`
+
+	gaugeUpsert = `
+	WITH upserted AS (
+		INSERT INTO gauge (name, labels, value)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name, labels)
+		DO UPDATE
+		SET value = EXCLUDED.value
+		RETURNING name, labels, value
+	)
+	INSERT INTO gauge_history (name, labels, value, created_at)
+	SELECT name, labels, value, $4::timestamptz FROM upserted`
+
+	histogramUpsert = `
+	WITH upserted AS (
+		INSERT INTO histogram (name, labels, data)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name, labels)
+		DO UPDATE
+		SET data = EXCLUDED.data
+		RETURNING name, labels, data
+	)
+	INSERT INTO histogram_history (name, labels, data, created_at)
+	SELECT name, labels, data, $4::timestamptz FROM upserted`
+
+	// summaryUpsert stores the latest quantiles, see entity.SummaryValue.Merge.
+	summaryUpsert = `
+	WITH upserted AS (
+		INSERT INTO summary (name, labels, data)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name, labels)
+		DO UPDATE
+		SET data = jsonb_set(jsonb_set(EXCLUDED.data,
+			'{sum}', to_jsonb((summary.data->>'sum')::double precision + (EXCLUDED.data->>'sum')::double precision)),
+			'{count}', to_jsonb((summary.data->>'count')::numeric + (EXCLUDED.data->>'count')::numeric))
+		RETURNING name, labels, data
+	)
+	INSERT INTO summary_history (name, labels, data, created_at)
+	SELECT name, labels, data, $4::timestamptz FROM upserted`
+
+	// historyTrim keeps the latest MaxHistorySize samples of series.
+	historyTrim = `
+	DELETE FROM %[1]s_history WHERE id IN (
+		SELECT id FROM %[1]s_history
+		WHERE name = $1 AND labels = $2
+		ORDER BY created_at DESC, id DESC
+		OFFSET $3
+	)`
+)
+
+// Update applies batch in a single transaction sending all statements
+// in one round trip with pgx.Batch.
 func (s *DB) Update(ctx context.Context, batch entity.MetricsList) error {
+	if s.closed.Load() {
+		return ErrClosed
+	}
 	if len(batch) == 0 {
 		return nil
 	}
@@ -87,64 +174,162 @@ func (s *DB) Update(ctx context.Context, batch entity.MetricsList) error {
 		return err
 	}
 
-	queryInsertLayout := `INSERT INTO %[1]s (name, %[2]s)
-						VALUES ($1, $2)
-						ON CONFLICT (name)
-						DO UPDATE
-						SET %[2]s = EXCLUDED.%[2]s;`
+	if err = s.update(ctx, tx, batch); err != nil {
+		if errRollBack := tx.Rollback(ctx); errRollBack != nil {
+			return fmt.Errorf("update error: %w; rollback error: %w", err, errRollBack)
+		}
+		return err
+	}
 
-	counterValMap := make(map[string]int64)
+	return tx.Commit(ctx)
+}
 
-	for _, one := range batch {
-		var query string
-
-		if one.MType == entity.Counter {
-			_, exists := counterValMap[one.ID]
-			if !exists {
-				var oldValue int64
-				var oldCounter entity.Metrics
-				oldCounter, err = s.GetOne(ctx, one.ID, one.MType)
-				switch {
-				case err == nil:
-					oldValue = *oldCounter.Delta
-				case errors.Is(err, entity.ErrMetricNotFound):
-					oldValue = 0
-				default:
-					if errRollBack := tx.Rollback(ctx); errRollBack != nil {
-						return fmt.Errorf("get_one error: %w; rollback error: %w", err, errRollBack)
-					}
-					return err
+func (s *DB) update(ctx context.Context, tx pgx.Tx, batch entity.MetricsList) error {
+	// rows are locked in the same order by concurrent transactions to avoid
+	// deadlocks, stable sort keeps the order of updates of the same series
+	sorted := slices.Clone(batch)
+	slices.SortStableFunc(sorted, func(a, b entity.Metrics) int {
+		return cmp.Or(cmp.Compare(a.MType, b.MType), cmp.Compare(a.Key(), b.Key()))
+	})
+
+	histograms, err := lockHistograms(ctx, tx, sorted)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	queries := &pgx.Batch{}
+
+	for _, one := range sorted {
+		labels := labelsParam(one.Labels)
+
+		switch one.MType {
+		case entity.Counter:
+			queries.Queue(counterUpsert, one.ID, labels, *one.Delta, now)
+		case entity.Gauge:
+			queries.Queue(gaugeUpsert, one.ID, labels, *one.Value, now)
+		case entity.Histogram:
+			key := one.Key()
+
+			merged := one.Histogram
+			if current := histograms[key]; current != nil {
+				merged, err = current.Merge(one.Histogram)
+				if err != nil {
+					return fmt.Errorf("%s: %w", key, err)
 				}
-				counterValMap[one.ID] = oldValue
 			}
+			histograms[key] = merged
 
-			counterValMap[one.ID] += *one.Delta
+			queries.Queue(histogramUpsert, one.ID, labels, merged, now)
+		case entity.Summary:
+			queries.Queue(summaryUpsert, one.ID, labels, one.Summary, now)
+		}
+	}
 
-			query = fmt.Sprintf(queryInsertLayout, "counter", "delta")
-			_, err = tx.Exec(ctx, query, one.ID, counterValMap[one.ID])
-		} else if one.MType == entity.Gauge {
-			query = fmt.Sprintf(queryInsertLayout, "gauge", "value")
-			_, err = tx.Exec(ctx, query, one.ID, *one.Value)
+	// history of updated series is trimmed once in historyTrimEvery
+	// inserts, updates of the same series are adjacent in sorted batch
+	inserted := 0
+	for i, one := range sorted {
+		if !entity.IsValidType(one.MType) {
+			continue
+		}
+		inserted++
+		if i+1 < len(sorted) && sorted[i+1].MType == one.MType && sorted[i+1].Key() == one.Key() {
+			continue
 		}
+		if s.trimDue(one.MType, one.Key(), inserted) {
+			queries.Queue(fmt.Sprintf(historyTrim, one.MType), one.ID, labelsParam(one.Labels), MaxHistorySize)
+		}
+		inserted = 0
+	}
 
-		if err != nil {
-			if errRollBack := tx.Rollback(ctx); errRollBack != nil {
-				return fmt.Errorf("exec error: %w; rollback error: %w", err, errRollBack)
-			}
-			return err
+	return tx.SendBatch(ctx, queries).Close()
+}
+
+// trimDue counts n history records inserted to series and reports
+// whether its history should be trimmed.
+func (s *DB) trimDue(mType, key string, n int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.inserts == nil {
+		s.inserts = make(map[string]int)
+	}
+
+	key = mType + ":" + key
+	s.inserts[key] += n
+	if s.inserts[key] < historyTrimEvery {
+		return false
+	}
+
+	delete(s.inserts, key)
+	return true
+}
+
+// lockHistograms returns stored histograms of batch locked until the end
+// of transaction. Missing rows are inserted first, so that concurrent
+// transactions wait for each other instead of overwriting the histogram.
+func lockHistograms(ctx context.Context, tx pgx.Tx, batch entity.MetricsList) (map[string]*entity.HistogramValue, error) {
+	const (
+		insertQuery = `INSERT INTO histogram (name, labels) VALUES ($1, $2) ON CONFLICT (name, labels) DO NOTHING`
+		selectQuery = `SELECT data FROM histogram WHERE name = $1 AND labels = $2 FOR UPDATE`
+	)
+
+	histograms := make(map[string]*entity.HistogramValue)
+	queries := &pgx.Batch{}
+
+	for _, one := range batch {
+		key := one.Key()
+		if one.MType != entity.Histogram {
+			continue
 		}
+		if _, ok := histograms[key]; ok {
+			continue
+		}
+		histograms[key] = nil
+
+		labels := labelsParam(one.Labels)
+		queries.Queue(insertQuery, one.ID, labels)
+		queries.Queue(selectQuery, one.ID, labels).QueryRow(func(row pgx.Row) error {
+			var current *entity.HistogramValue
+			if err := row.Scan(&current); err != nil {
+				return err
+			}
+			histograms[key] = current
+			return nil
+		})
 	}
 
-	return tx.Commit(ctx)
+	if queries.Len() == 0 {
+		return histograms, nil
+	}
+
+	return histograms, tx.SendBatch(ctx, queries).Close()
+}
+
+// labelsParam converts labels to query argument,
+// metrics without labels are stored with empty JSON object.
+func labelsParam(labels entity.Labels) map[string]string {
+	if labels == nil {
+		return map[string]string{}
+	}
+	return labels
 }
 
-func (s *DB) GetOne(ctx context.Context, id, mType string) (entity.Metrics, error) {
-	var metric = entity.Metrics{ID: id, MType: mType}
+func (s *DB) GetOne(ctx context.Context, id, mType string, labels entity.Labels) (entity.Metrics, error) {
+	if s.closed.Load() {
+		return entity.Metrics{}, ErrClosed
+	}
+	if !entity.IsValidType(mType) {
+		return entity.Metrics{}, entity.ErrInvalidMetricType
+	}
+
+	var metric = entity.Metrics{ID: id, MType: mType, Labels: labels.Clone()}
 	switch mType {
 	case entity.Counter:
-		query := `SELECT delta FROM counter WHERE name = $1 LIMIT 1`
+		query := `SELECT delta FROM counter WHERE name = $1 AND labels = $2 LIMIT 1`
 		var delta *int64
-		if err := s.Pool.QueryRow(ctx, query, id).Scan(&delta); err != nil {
+		if err := s.Pool.QueryRow(ctx, query, id, labelsParam(labels)).Scan(&delta); err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
 				return entity.Metrics{}, entity.ErrMetricNotFound
 			}
@@ -152,24 +337,49 @@ func (s *DB) GetOne(ctx context.Context, id, mType string) (entity.Metrics, erro
 		}
 		metric.Delta = delta
 	case entity.Gauge:
-		query := `SELECT value FROM gauge WHERE name = $1 LIMIT 1`
+		query := `SELECT value FROM gauge WHERE name = $1 AND labels = $2 LIMIT 1`
 		var value *float64
-		if err := s.Pool.QueryRow(ctx, query, id).Scan(&value); err != nil {
+		if err := s.Pool.QueryRow(ctx, query, id, labelsParam(labels)).Scan(&value); err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
 				return entity.Metrics{}, entity.ErrMetricNotFound
 			}
 			return entity.Metrics{}, err
 		}
 		metric.Value = value
+	case entity.Histogram:
+		query := `SELECT data FROM histogram WHERE name = $1 AND labels = $2 LIMIT 1`
+		var histogram *entity.HistogramValue
+		if err := s.Pool.QueryRow(ctx, query, id, labelsParam(labels)).Scan(&histogram); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return entity.Metrics{}, entity.ErrMetricNotFound
+			}
+			return entity.Metrics{}, err
+		}
+		metric.Histogram = histogram
+	case entity.Summary:
+		query := `SELECT data FROM summary WHERE name = $1 AND labels = $2 LIMIT 1`
+		var summary *entity.SummaryValue
+		if err := s.Pool.QueryRow(ctx, query, id, labelsParam(labels)).Scan(&summary); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return entity.Metrics{}, entity.ErrMetricNotFound
+			}
+			return entity.Metrics{}, err
+		}
+		metric.Summary = summary
 	}
 
 	return metric, nil
 }
 
 func (s *DB) GetAll(ctx context.Context) (entity.MetricsList, error) {
-	querySelectLayout := `SELECT name, %s FROM %s`
+	if s.closed.Load() {
+		return nil, ErrClosed
+	}
+
+	querySelectLayout := `SELECT name, labels, %s FROM %s`
 
-	colTables := [][]string{{"delta", entity.Counter}, {"value", entity.Gauge}}
+	colTables := [][]string{{"delta", entity.Counter}, {"value", entity.Gauge}, {"data", entity.Histogram},
+		{"data", entity.Summary}}
 
 	var list entity.MetricsList
 	for _, colTable := range colTables {
@@ -200,15 +410,25 @@ func (s *DB) fetchMetrics(
 	for rows.Next() {
 		var (
 			name, mType string
+			labels      entity.Labels
 			delta       *int64
 			value       *float64
+			histogram   *entity.HistogramValue
+			summary     *entity.SummaryValue
 		)
 
-		if tableName == entity.Counter {
-			err = rows.Scan(&name, &delta)
+		switch tableName {
+		case entity.Counter:
+			err = rows.Scan(&name, &labels, &delta)
 			mType = entity.Counter
-		} else {
-			err = rows.Scan(&name, &value)
+		case entity.Histogram:
+			err = rows.Scan(&name, &labels, &histogram)
+			mType = entity.Histogram
+		case entity.Summary:
+			err = rows.Scan(&name, &labels, &summary)
+			mType = entity.Summary
+		default:
+			err = rows.Scan(&name, &labels, &value)
 			mType = entity.Gauge
 		}
 
@@ -217,10 +437,13 @@ func (s *DB) fetchMetrics(
 		}
 
 		list = append(list, entity.Metrics{
-			ID:    name,
-			MType: mType,
-			Delta: delta,
-			Value: value,
+			ID:        name,
+			MType:     mType,
+			Delta:     delta,
+			Value:     value,
+			Histogram: histogram,
+			Summary:   summary,
+			Labels:    labels.Clone(),
 		})
 	}
 
@@ -231,29 +454,135 @@ func (s *DB) fetchMetrics(
 	return list, nil
 }
 
-func (s *DB) DeleteOne(ctx context.Context, id, mType string) error {
-	if mType != entity.Counter && mType != entity.Gauge {
-		return entity.ErrInvalidMetricType
+func (s *DB) GetRange(
+	ctx context.Context,
+	id, mType string,
+	labels entity.Labels,
+	from, to time.Time,
+) (entity.SampleList, error) {
+	if s.closed.Load() {
+		return nil, ErrClosed
+	}
+	if !entity.IsValidType(mType) {
+		return nil, entity.ErrInvalidMetricType
+	}
+
+	var colName = "value"
+	switch mType {
+	case entity.Counter:
+		colName = "delta"
+	case entity.Histogram, entity.Summary:
+		colName = "data"
+	}
+
+	// history is trimmed lazily, so only the latest samples are selected
+	query := fmt.Sprintf(`SELECT created_at, %[1]s FROM (
+							SELECT id, created_at, %[1]s FROM %[2]s_history
+							WHERE name = $1 AND labels = $2
+							ORDER BY created_at DESC, id DESC
+							LIMIT $5
+						) latest
+						WHERE created_at BETWEEN $3 AND $4
+						ORDER BY created_at, id`, colName, mType)
+
+	rows, err := s.Pool.Query(ctx, query, id, labelsParam(labels), from, to, MaxHistorySize)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	list := entity.SampleList{}
+	for rows.Next() {
+		var sample entity.Sample
+		switch mType {
+		case entity.Counter:
+			err = rows.Scan(&sample.Timestamp, &sample.Delta)
+		case entity.Histogram:
+			err = rows.Scan(&sample.Timestamp, &sample.Histogram)
+		case entity.Summary:
+			err = rows.Scan(&sample.Timestamp, &sample.Summary)
+		default:
+			err = rows.Scan(&sample.Timestamp, &sample.Value)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, sample)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(list) == 0 {
+		if _, err = s.GetOne(ctx, id, mType, labels); err != nil {
+			return nil, err
+		}
 	}
-	query := fmt.Sprintf(`DELETE FROM %s WHERE name = $1`, mType)
-	_, err := s.Pool.Exec(ctx, query, id)
-	return err
+
+	return list, nil
 }
 
-func (s *DB) DeleteAll(ctx context.Context) error {
-	_, err := s.Pool.Exec(ctx, "DELETE FROM counter")
+func (s *DB) DeleteOne(ctx context.Context, id, mType string, labels entity.Labels) error {
+	if s.closed.Load() {
+		return ErrClosed
+	}
+	if !entity.IsValidType(mType) {
+		return entity.ErrInvalidMetricType
+	}
+	query := fmt.Sprintf(`DELETE FROM %s WHERE name = $1 AND labels = $2`, mType)
+	_, err := s.Pool.Exec(ctx, query, id, labelsParam(labels))
 	if err != nil {
 		return err
 	}
 
-	_, err = s.Pool.Exec(ctx, "DELETE FROM gauge")
-	return err
+	query = fmt.Sprintf(`DELETE FROM %s_history WHERE name = $1 AND labels = $2`, mType)
+	_, err = s.Pool.Exec(ctx, query, id, labelsParam(labels))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.inserts, mType+":"+entity.SeriesKey(id, labels))
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *DB) DeleteAll(ctx context.Context) error {
+	if s.closed.Load() {
+		return ErrClosed
+	}
+
+	tables := []string{
+		"counter", "gauge", "histogram", "summary",
+		"counter_history", "gauge_history", "histogram_history", "summary_history",
+	}
+	for _, table := range tables {
+		if _, err := s.Pool.Exec(ctx, "DELETE FROM "+table); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	s.inserts = nil
+	s.mu.Unlock()
+
+	return nil
 }
 
 func (s *DB) Ping(ctx context.Context) error {
+	if s.closed.Load() {
+		return ErrClosed
+	}
+
 	return s.Pool.Ping(ctx)
 }
 
 func (s *DB) Close() {
+	s.closed.Store(true)
 	s.Pool.Close()
 }
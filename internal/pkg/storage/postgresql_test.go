@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -15,16 +18,65 @@ import (
 	"github.com/testcontainers/testcontainers-go/wait"
 
 	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/retry"
 	"github.com/Imomali1/metrics/internal/pkg/utils"
 )
 
 var (
 	DSN           string
 	TestContainer testcontainers.Container
+	// errDocker tells why tests needing database are skipped.
+	errDocker error
 )
 
-func init() {
-	startTestContainer()
+// requireDockerEnv makes tests needing database fail instead of being
+// skipped when Docker is not available, it is set in CI.
+const requireDockerEnv = "REQUIRE_DOCKER"
+
+// TestMain starts PostgreSQL container when Docker is available,
+// otherwise tests needing database are skipped.
+func TestMain(m *testing.M) {
+	errDocker = checkDocker()
+	if errDocker == nil {
+		startTestContainer()
+	} else if os.Getenv(requireDockerEnv) != "" {
+		log.Fatalf("docker is required by %s: %v", requireDockerEnv, errDocker)
+	} else {
+		log.Printf("docker is not available, postgresql tests are skipped: %v", errDocker)
+	}
+
+	code := m.Run()
+
+	if TestContainer != nil {
+		stopTestContainer()
+	}
+
+	os.Exit(code)
+}
+
+func checkDocker() (err error) {
+	// provider panics when docker host cannot be found
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	provider, err := testcontainers.NewDockerProvider()
+	if err != nil {
+		return err
+	}
+	defer provider.Close()
+
+	return provider.Health(context.Background())
+}
+
+func skipWithoutDocker(t *testing.T) {
+	t.Helper()
+
+	if TestContainer == nil {
+		t.Skipf("docker is not available, set %s to fail instead: %v", requireDockerEnv, errDocker)
+	}
 }
 
 func startTestContainer() {
@@ -92,17 +144,16 @@ func createTestPool() (*pgxpool.Pool, error) {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
-	var pool *pgxpool.Pool
-	err = utils.DoWithRetries(func() error {
-		pool, err = pgxpool.NewWithConfig(ctx, config)
-		return err
-	})
-
+	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Pool: %w", err)
 	}
 
-	if err = pool.Ping(ctx); err != nil {
+	err = retry.DefaultPolicy().Do(ctx, func(ctx context.Context) error {
+		return pool.Ping(ctx)
+	})
+	if err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("failed to ping Pool: %w", err)
 	}
 
@@ -110,6 +161,8 @@ func createTestPool() (*pgxpool.Pool, error) {
 }
 
 func Test_newDBStorage(t *testing.T) {
+	skipWithoutDocker(t)
+
 	tests := []struct {
 		name    string
 		dsn     string
@@ -149,29 +202,59 @@ func Test_newDBStorage(t *testing.T) {
 	}
 }
 
-func Test_createTable(t *testing.T) {
+func Test_migrate(t *testing.T) {
+	skipWithoutDocker(t)
+
 	pool, err := createTestPool()
 	require.NoError(t, err)
 	require.NotNil(t, pool)
 
-	tests := []struct {
-		name    string
-		wantErr bool
-	}{
-		{
-			name:    "valid",
-			wantErr: false,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err = storage.CreateTable(context.Background(), pool)
-			require.NoError(t, err)
-		})
+	ctx := context.Background()
+
+	migrations, err := storage.Migrations()
+	require.NoError(t, err)
+	latest := migrations[len(migrations)-1].Version
+
+	// applying twice is no-op
+	require.NoError(t, storage.MigrateUp(ctx, pool))
+	require.NoError(t, storage.MigrateUp(ctx, pool))
+
+	version, err := storage.SchemaVersion(ctx, pool)
+	require.NoError(t, err)
+	require.Equal(t, latest, version)
+
+	require.NoError(t, storage.MigrateDown(ctx, pool, 1))
+	version, err = storage.SchemaVersion(ctx, pool)
+	require.NoError(t, err)
+	require.Equal(t, migrations[len(migrations)-2].Version, version)
+
+	status, err := storage.MigrationsStatus(ctx, pool)
+	require.NoError(t, err)
+	require.Len(t, status, len(migrations))
+	for i, m := range status {
+		require.Equal(t, migrations[i].Version, m.Version)
+		require.Equal(t, i < len(migrations)-1, m.Applied(), "only the last migration is pending")
 	}
+
+	require.NoError(t, storage.MigrateDown(ctx, pool, len(migrations)))
+	version, err = storage.SchemaVersion(ctx, pool)
+	require.NoError(t, err)
+	require.Zero(t, version)
+
+	var exists bool
+	err = pool.QueryRow(ctx, `SELECT to_regclass('counter') IS NOT NULL`).Scan(&exists)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, storage.MigrateUp(ctx, pool))
+	version, err = storage.SchemaVersion(ctx, pool)
+	require.NoError(t, err)
+	require.Equal(t, latest, version)
 }
 
 func Test_dbStorage_Update(t *testing.T) {
+	skipWithoutDocker(t)
+
 	ctx := context.Background()
 	db, err := storage.NewDB(ctx, DSN)
 	require.NoError(t, err)
@@ -252,6 +335,36 @@ func Test_dbStorage_Update(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "labeled metrics are separate series",
+			batch: entity.MetricsList{
+				{
+					ID:    "gauge1",
+					MType: entity.Gauge,
+					Value: utils.Ptr(123.0),
+				},
+				{
+					ID:     "gauge1",
+					MType:  entity.Gauge,
+					Value:  utils.Ptr(321.0),
+					Labels: entity.Labels{"host": "a"},
+				},
+			},
+			wanted: entity.MetricsList{
+				{
+					ID:    "gauge1",
+					MType: entity.Gauge,
+					Value: utils.Ptr(123.0),
+				},
+				{
+					ID:     "gauge1",
+					MType:  entity.Gauge,
+					Value:  utils.Ptr(321.0),
+					Labels: entity.Labels{"host": "a"},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "one invalid metrics",
 			batch: entity.MetricsList{
@@ -295,6 +408,57 @@ func Test_dbStorage_Update(t *testing.T) {
 			wanted:  entity.MetricsList{},
 			wantErr: false,
 		},
+		{
+			name: "histograms are merged",
+			batch: entity.MetricsList{
+				{
+					ID:    "histogram1",
+					MType: entity.Histogram,
+					Histogram: &entity.HistogramValue{
+						Buckets: []entity.Bucket{{UpperBound: 0.1, Count: 1}, {UpperBound: 1, Count: 2}},
+						Sum:     0.5,
+						Count:   2,
+					},
+				},
+				{
+					ID:    "histogram1",
+					MType: entity.Histogram,
+					Histogram: &entity.HistogramValue{
+						Buckets: []entity.Bucket{{UpperBound: 0.1, Count: 0}, {UpperBound: 1, Count: 1}},
+						Sum:     2.5,
+						Count:   2,
+					},
+				},
+			},
+			wanted: entity.MetricsList{
+				{
+					ID:    "histogram1",
+					MType: entity.Histogram,
+					Histogram: &entity.HistogramValue{
+						Buckets: []entity.Bucket{{UpperBound: 0.1, Count: 1}, {UpperBound: 1, Count: 3}},
+						Sum:     3,
+						Count:   4,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "mismatching histogram buckets",
+			batch: entity.MetricsList{
+				{
+					ID:        "histogram1",
+					MType:     entity.Histogram,
+					Histogram: &entity.HistogramValue{Buckets: []entity.Bucket{{UpperBound: 0.1, Count: 1}}, Sum: 0.05, Count: 1},
+				},
+				{
+					ID:        "histogram1",
+					MType:     entity.Histogram,
+					Histogram: &entity.HistogramValue{Buckets: []entity.Bucket{{UpperBound: 1, Count: 1}}, Sum: 0.5, Count: 1},
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -317,7 +481,68 @@ func Test_dbStorage_Update(t *testing.T) {
 	}
 }
 
+func Test_dbStorage_UpdateConcurrent(t *testing.T) {
+	skipWithoutDocker(t)
+
+	ctx := context.Background()
+
+	db, err := storage.NewDB(ctx, DSN)
+	require.NoError(t, err)
+
+	err = db.DeleteAll(ctx)
+	require.NoError(t, err)
+
+	const (
+		workers  = 10
+		requests = 20
+	)
+
+	histogram := entity.NewHistogram([]float64{1})
+	histogram.Observe(0.5)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < requests; j++ {
+				batch := entity.MetricsList{
+					{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(1))},
+					{ID: "counter2", MType: entity.Counter, Delta: utils.Ptr(int64(2))},
+					{ID: "histogram1", MType: entity.Histogram, Histogram: histogram},
+				}
+				// opposite order of series must not deadlock
+				if i%2 == 1 {
+					slices.Reverse(batch)
+				}
+
+				if errUpdate := db.Update(ctx, batch); errUpdate != nil {
+					t.Error(errUpdate)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	counter1, err := db.GetOne(ctx, "counter1", entity.Counter, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(workers*requests), *counter1.Delta)
+
+	counter2, err := db.GetOne(ctx, "counter2", entity.Counter, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(2*workers*requests), *counter2.Delta)
+
+	histogram1, err := db.GetOne(ctx, "histogram1", entity.Histogram, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(workers*requests), histogram1.Histogram.Count)
+	require.Equal(t, uint64(workers*requests), histogram1.Histogram.Buckets[0].Count)
+}
+
 func Test_dbStorage_GetAll(t *testing.T) {
+	skipWithoutDocker(t)
+
 	ctx := context.Background()
 
 	db, err := storage.NewDB(ctx, DSN)
@@ -380,6 +605,8 @@ func Test_dbStorage_GetAll(t *testing.T) {
 }
 
 func Test_dbStorage_GetOne(t *testing.T) {
+	skipWithoutDocker(t)
+
 	ctx := context.Background()
 
 	db, err := storage.NewDB(ctx, DSN)
@@ -400,8 +627,9 @@ func Test_dbStorage_GetOne(t *testing.T) {
 	})
 
 	type args struct {
-		id    string
-		mType string
+		id     string
+		mType  string
+		labels entity.Labels
 	}
 	tests := []struct {
 		name    string
@@ -467,16 +695,12 @@ func Test_dbStorage_GetOne(t *testing.T) {
 				id:    "non-existing-type",
 				mType: "non-existing",
 			},
-			want: entity.Metrics{
-				ID:    "non-existing-type",
-				MType: "non-existing",
-			},
-			wantErr: false,
+			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := db.GetOne(ctx, tt.args.id, tt.args.mType)
+			got, err := db.GetOne(ctx, tt.args.id, tt.args.mType, tt.args.labels)
 			if tt.wantErr {
 				require.Error(t, err)
 				return
@@ -489,7 +713,81 @@ func Test_dbStorage_GetOne(t *testing.T) {
 	}
 }
 
+func Test_dbStorage_GetRange(t *testing.T) {
+	skipWithoutDocker(t)
+
+	ctx := context.Background()
+
+	db, err := storage.NewDB(ctx, DSN)
+	require.NoError(t, err)
+	require.NotNil(t, db)
+
+	_ = db.DeleteAll(ctx)
+
+	start := time.Now()
+	for i := 1; i <= 3; i++ {
+		_ = db.Update(ctx, entity.MetricsList{
+			{ID: "gauge1", MType: entity.Gauge, Value: utils.Ptr(float64(i))},
+			{ID: "counter1", MType: entity.Counter, Delta: utils.Ptr(int64(i))},
+		})
+	}
+	end := time.Now()
+
+	type args struct {
+		id       string
+		mType    string
+		labels   entity.Labels
+		from, to time.Time
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name:    "gauge history",
+			args:    args{id: "gauge1", mType: entity.Gauge, from: start, to: end},
+			wantLen: 3,
+		},
+		{
+			name:    "counter history",
+			args:    args{id: "counter1", mType: entity.Counter, from: start, to: end},
+			wantLen: 3,
+		},
+		{
+			name:    "empty range",
+			args:    args{id: "gauge1", mType: entity.Gauge, from: end.Add(time.Hour), to: end.Add(2 * time.Hour)},
+			wantLen: 0,
+		},
+		{
+			name:    "non-existing metrics",
+			args:    args{id: "non-existing", mType: entity.Gauge, from: start, to: end},
+			wantErr: true,
+		},
+		{
+			name:    "invalid type metrics",
+			args:    args{id: "gauge1", mType: "invalid", from: start, to: end},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.GetRange(ctx, tt.args.id, tt.args.mType, tt.args.labels, tt.args.from, tt.args.to)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, got, tt.wantLen)
+		})
+	}
+}
+
 func Test_dbStorage_DeleteOne(t *testing.T) {
+	skipWithoutDocker(t)
+
 	ctx := context.Background()
 
 	db, err := storage.NewDB(ctx, DSN)
@@ -510,8 +808,9 @@ func Test_dbStorage_DeleteOne(t *testing.T) {
 	}
 
 	type args struct {
-		id    string
-		mType string
+		id     string
+		mType  string
+		labels entity.Labels
 	}
 	tests := []struct {
 		name       string
@@ -559,7 +858,7 @@ func Test_dbStorage_DeleteOne(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.updateFunc()
-			err = db.DeleteOne(ctx, tt.args.id, tt.args.mType)
+			err = db.DeleteOne(ctx, tt.args.id, tt.args.mType, tt.args.labels)
 			if tt.wantErr {
 				t.Log(err)
 				require.Error(t, err)
@@ -571,6 +870,8 @@ func Test_dbStorage_DeleteOne(t *testing.T) {
 }
 
 func Test_dbStorage_Ping(t *testing.T) {
+	skipWithoutDocker(t)
+
 	pool, err := createTestPool()
 	require.NoError(t, err)
 	require.NotNil(t, pool)
@@ -584,6 +885,8 @@ func Test_dbStorage_Ping(t *testing.T) {
 }
 
 func Test_dbStorage_Close(t *testing.T) {
+	skipWithoutDocker(t)
+
 	pool, err := createTestPool()
 	require.NoError(t, err)
 	require.NotNil(t, pool)
@@ -2,11 +2,48 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"strings"
+
+	"github.com/Imomali1/metrics/internal/pkg/retry"
 )
 
-func New(ctx context.Context, dsn string) (Storage, error) {
+// ErrClosed is returned by storage used after Close.
+var ErrClosed = errors.New("storage is closed")
+
+type storageOptions struct {
+	skipMigrations bool
+	retryPolicy    *retry.Policy
+}
+
+// Option configures storage created by New.
+type Option func(*storageOptions)
+
+// WithoutMigrations disables applying pending migrations
+// when database storage is created.
+func WithoutMigrations() Option {
+	return func(o *storageOptions) {
+		o.skipMigrations = true
+	}
+}
+
+// WithRetryPolicy sets policy of connecting to database,
+// retry.DefaultPolicy is used otherwise.
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(o *storageOptions) {
+		o.retryPolicy = &p
+	}
+}
+
+// New creates storage for dsn: embedded database for DSN with BoltScheme,
+// PostgreSQL for other non-empty DSN and memory storage otherwise.
+func New(ctx context.Context, dsn string, opts ...Option) (Storage, error) {
+	if path, ok := strings.CutPrefix(dsn, BoltScheme); ok {
+		return NewBolt(path)
+	}
+
 	if dsn != "" {
-		return NewDB(ctx, dsn)
+		return NewDB(ctx, dsn, opts...)
 	}
 
 	return NewMemory()
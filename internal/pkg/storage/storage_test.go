@@ -2,6 +2,8 @@ package storage
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestNewStorage(t *testing.T) {
@@ -19,3 +21,15 @@ func TestWithDB(t *testing.T) {
 func TestWithSyncWrite(t *testing.T) {
 
 }
+
+func TestDB_trimDue(t *testing.T) {
+	s := &DB{}
+
+	assert.False(t, s.trimDue("gauge", "gauge1", historyTrimEvery-1))
+	assert.False(t, s.trimDue("counter", "gauge1", 1), "series of other type is counted apart")
+	assert.True(t, s.trimDue("gauge", "gauge1", 1))
+
+	// counting starts over after trim
+	assert.False(t, s.trimDue("gauge", "gauge1", 1))
+	assert.True(t, s.trimDue("gauge", "gauge1", historyTrimEvery))
+}
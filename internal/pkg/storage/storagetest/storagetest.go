@@ -0,0 +1,411 @@
+// Package storagetest provides behaviour tests every storage.Storage
+// implementation is expected to pass.
+package storagetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/storage"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+)
+
+// Factory returns empty storage for a single test, the test closes it.
+type Factory func(t *testing.T) storage.Storage
+
+// Run runs the suite against storages created by newStorage.
+func Run(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s storage.Storage)
+	}{
+		{name: "counter accumulates", test: testCounterAccumulates},
+		{name: "gauge overwrites", test: testGaugeOverwrites},
+		{name: "histogram merges", test: testHistogramMerges},
+		{name: "summary merges", test: testSummaryMerges},
+		{name: "labels separate series", test: testLabelsSeparateSeries},
+		{name: "invalid type skipped on update", test: testUpdateSkipsInvalidType},
+		{name: "not found", test: testNotFound},
+		{name: "invalid type", test: testInvalidType},
+		{name: "get all", test: testGetAll},
+		{name: "get range", test: testGetRange},
+		{name: "history limit", test: testHistoryLimit},
+		{name: "delete one", test: testDeleteOne},
+		{name: "delete all", test: testDeleteAll},
+		{name: "ping", test: testPing},
+		{name: "concurrent updates", test: testConcurrentUpdates},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStorage(t)
+			defer s.Close()
+
+			tt.test(t, s)
+		})
+	}
+
+	t.Run("close", func(t *testing.T) {
+		testClose(t, newStorage(t))
+	})
+}
+
+func counter(id string, delta int64) entity.Metrics {
+	return entity.Metrics{ID: id, MType: entity.Counter, Delta: utils.Ptr(delta)}
+}
+
+func gauge(id string, value float64) entity.Metrics {
+	return entity.Metrics{ID: id, MType: entity.Gauge, Value: utils.Ptr(value)}
+}
+
+func histogram(id string, sum float64, count uint64, buckets ...entity.Bucket) entity.Metrics {
+	return entity.Metrics{
+		ID:        id,
+		MType:     entity.Histogram,
+		Histogram: &entity.HistogramValue{Buckets: buckets, Sum: sum, Count: count},
+	}
+}
+
+func summary(id string, sum float64, count uint64, quantiles ...entity.Quantile) entity.Metrics {
+	return entity.Metrics{
+		ID:      id,
+		MType:   entity.Summary,
+		Summary: &entity.SummaryValue{Quantiles: quantiles, Sum: sum, Count: count},
+	}
+}
+
+func withLabels(m entity.Metrics, labels entity.Labels) entity.Metrics {
+	m.Labels = labels
+	return m
+}
+
+func testCounterAccumulates(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Update(ctx, entity.MetricsList{counter("counter1", 1)}))
+	require.NoError(t, s.Update(ctx, entity.MetricsList{counter("counter1", 2), counter("counter1", 3)}))
+
+	got, err := s.GetOne(ctx, "counter1", entity.Counter, nil)
+	require.NoError(t, err)
+	assert.Equal(t, counter("counter1", 6), got)
+}
+
+func testGaugeOverwrites(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Update(ctx, entity.MetricsList{gauge("gauge1", 1.5)}))
+	require.NoError(t, s.Update(ctx, entity.MetricsList{gauge("gauge1", 2.5), gauge("gauge1", -3)}))
+
+	got, err := s.GetOne(ctx, "gauge1", entity.Gauge, nil)
+	require.NoError(t, err)
+	assert.Equal(t, gauge("gauge1", -3), got)
+}
+
+func testHistogramMerges(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Update(ctx, entity.MetricsList{
+		histogram("latency", 0.3, 2, entity.Bucket{UpperBound: 0.1, Count: 1}, entity.Bucket{UpperBound: 1, Count: 2}),
+		histogram("latency", 5, 1, entity.Bucket{UpperBound: 0.1}, entity.Bucket{UpperBound: 1}),
+	}))
+
+	// batch with mismatching buckets is rejected as a whole
+	err := s.Update(ctx, entity.MetricsList{
+		counter("counter1", 1),
+		histogram("latency", 1, 1, entity.Bucket{UpperBound: 2, Count: 1}),
+	})
+	require.ErrorIs(t, err, entity.ErrHistogramBucketsMismatch)
+
+	_, err = s.GetOne(ctx, "counter1", entity.Counter, nil)
+	require.ErrorIs(t, err, entity.ErrMetricNotFound)
+
+	got, err := s.GetOne(ctx, "latency", entity.Histogram, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.Bucket{{UpperBound: 0.1, Count: 1}, {UpperBound: 1, Count: 2}}, got.Histogram.Buckets)
+	assert.Equal(t, uint64(3), got.Histogram.Count)
+	assert.InDelta(t, 5.3, got.Histogram.Sum, 1e-9)
+}
+
+func testSummaryMerges(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	start := time.Now()
+	require.NoError(t, s.Update(ctx, entity.MetricsList{
+		summary("rpc", 1.5, 3, entity.Quantile{Quantile: 0.5, Value: 0.4}, entity.Quantile{Quantile: 0.99, Value: 0.9}),
+	}))
+	require.NoError(t, s.Update(ctx, entity.MetricsList{
+		summary("rpc", 2.5, 2, entity.Quantile{Quantile: 0.5, Value: 1.2}),
+	}))
+
+	// sum and count accumulate, quantiles are replaced by the latest ones
+	got, err := s.GetOne(ctx, "rpc", entity.Summary, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.Quantile{{Quantile: 0.5, Value: 1.2}}, got.Summary.Quantiles)
+	assert.Equal(t, uint64(5), got.Summary.Count)
+	assert.InDelta(t, 4.0, got.Summary.Sum, 1e-9)
+
+	samples, err := s.GetRange(ctx, "rpc", entity.Summary, nil, start, time.Now())
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, uint64(3), samples[0].Summary.Count)
+	assert.Equal(t, uint64(5), samples[1].Summary.Count)
+}
+
+func testLabelsSeparateSeries(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Update(ctx, entity.MetricsList{
+		counter("counter1", 1),
+		withLabels(counter("counter1", 10), entity.Labels{"host": "a"}),
+		withLabels(counter("counter1", 100), entity.Labels{"host": "b"}),
+	}))
+
+	got, err := s.GetOne(ctx, "counter1", entity.Counter, entity.Labels{"host": "a"})
+	require.NoError(t, err)
+	assert.Equal(t, withLabels(counter("counter1", 10), entity.Labels{"host": "a"}), got)
+
+	got, err = s.GetOne(ctx, "counter1", entity.Counter, nil)
+	require.NoError(t, err)
+	assert.Equal(t, counter("counter1", 1), got)
+
+	_, err = s.GetOne(ctx, "counter1", entity.Counter, entity.Labels{"host": "c"})
+	require.ErrorIs(t, err, entity.ErrMetricNotFound)
+}
+
+func testUpdateSkipsInvalidType(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Update(ctx, entity.MetricsList{
+		{ID: "invalid", MType: "invalid", Value: utils.Ptr(1.0), Delta: utils.Ptr(int64(1))},
+		gauge("gauge1", 1),
+	}))
+
+	list, err := s.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entity.MetricsList{gauge("gauge1", 1)}, list)
+}
+
+func testNotFound(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Update(ctx, entity.MetricsList{gauge("metric1", 1)}))
+
+	for _, mType := range []string{entity.Counter, entity.Gauge, entity.Histogram, entity.Summary} {
+		_, err := s.GetOne(ctx, "non-existing", mType, nil)
+		assert.ErrorIs(t, err, entity.ErrMetricNotFound, mType)
+
+		_, err = s.GetRange(ctx, "non-existing", mType, nil, time.Time{}, time.Now())
+		assert.ErrorIs(t, err, entity.ErrMetricNotFound, mType)
+	}
+
+	// the same name with other type is a different metric
+	_, err := s.GetOne(ctx, "metric1", entity.Counter, nil)
+	assert.ErrorIs(t, err, entity.ErrMetricNotFound)
+}
+
+func testInvalidType(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Update(ctx, entity.MetricsList{gauge("gauge1", 1)}))
+
+	_, err := s.GetOne(ctx, "gauge1", "invalid", nil)
+	assert.ErrorIs(t, err, entity.ErrInvalidMetricType)
+
+	_, err = s.GetRange(ctx, "gauge1", "invalid", nil, time.Time{}, time.Now())
+	assert.ErrorIs(t, err, entity.ErrInvalidMetricType)
+
+	err = s.DeleteOne(ctx, "gauge1", "invalid", nil)
+	assert.ErrorIs(t, err, entity.ErrInvalidMetricType)
+}
+
+func testGetAll(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	list, err := s.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	metrics := entity.MetricsList{
+		gauge("gauge1", 123),
+		counter("counter1", 123),
+		withLabels(gauge("gauge1", 321), entity.Labels{"host": "a"}),
+		withLabels(counter("counter1", 321), entity.Labels{"host": "a"}),
+		histogram("latency", 0.5, 1, entity.Bucket{UpperBound: 1, Count: 1}),
+		summary("rpc", 0.5, 1, entity.Quantile{Quantile: 0.5, Value: 0.5}),
+	}
+	require.NoError(t, s.Update(ctx, metrics))
+
+	list, err = s.GetAll(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, metrics, list)
+}
+
+func testGetRange(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, s.Update(ctx, entity.MetricsList{
+			gauge("gauge1", float64(i)),
+			counter("counter1", int64(i)),
+		}))
+	}
+	end := time.Now()
+
+	samples, err := s.GetRange(ctx, "gauge1", entity.Gauge, nil, start, end)
+	require.NoError(t, err)
+	require.Len(t, samples, 3)
+	for i, sample := range samples {
+		assert.Equal(t, float64(i+1), *sample.Value)
+	}
+
+	// counter history holds accumulated values
+	samples, err = s.GetRange(ctx, "counter1", entity.Counter, nil, start, end)
+	require.NoError(t, err)
+	require.Len(t, samples, 3)
+	for i, want := range []int64{1, 3, 6} {
+		assert.Equal(t, want, *samples[i].Delta)
+	}
+
+	samples, err = s.GetRange(ctx, "gauge1", entity.Gauge, nil, end.Add(time.Hour), end.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, samples)
+}
+
+func testHistoryLimit(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	batch := make(entity.MetricsList, 0, storage.MaxHistorySize)
+	for i := range storage.MaxHistorySize {
+		batch = append(batch, gauge("gauge1", float64(i)))
+	}
+	require.NoError(t, s.Update(ctx, batch))
+	require.NoError(t, s.Update(ctx, entity.MetricsList{
+		gauge("gauge1", storage.MaxHistorySize),
+		gauge("gauge1", storage.MaxHistorySize+1),
+	}))
+
+	// the oldest samples are discarded
+	samples, err := s.GetRange(ctx, "gauge1", entity.Gauge, nil, time.Time{}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, samples, storage.MaxHistorySize)
+	assert.Equal(t, 2.0, *samples[0].Value)
+	assert.Equal(t, float64(storage.MaxHistorySize+1), *samples[len(samples)-1].Value)
+}
+
+func testDeleteOne(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Update(ctx, entity.MetricsList{
+		gauge("metric1", 1),
+		counter("metric1", 1),
+		withLabels(gauge("metric1", 2), entity.Labels{"host": "a"}),
+	}))
+
+	require.NoError(t, s.DeleteOne(ctx, "metric1", entity.Gauge, nil))
+
+	_, err := s.GetOne(ctx, "metric1", entity.Gauge, nil)
+	assert.ErrorIs(t, err, entity.ErrMetricNotFound)
+
+	_, err = s.GetRange(ctx, "metric1", entity.Gauge, nil, time.Time{}, time.Now())
+	assert.ErrorIs(t, err, entity.ErrMetricNotFound)
+
+	// other types and labels of the same name are kept
+	_, err = s.GetOne(ctx, "metric1", entity.Counter, nil)
+	assert.NoError(t, err)
+	_, err = s.GetOne(ctx, "metric1", entity.Gauge, entity.Labels{"host": "a"})
+	assert.NoError(t, err)
+
+	// deleting missing metrics is not an error
+	assert.NoError(t, s.DeleteOne(ctx, "metric1", entity.Gauge, nil))
+	assert.NoError(t, s.DeleteOne(ctx, "non-existing", entity.Histogram, nil))
+}
+
+func testDeleteAll(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Update(ctx, entity.MetricsList{
+		gauge("gauge1", 1),
+		counter("counter1", 1),
+		histogram("latency", 0.5, 1, entity.Bucket{UpperBound: 1, Count: 1}),
+		summary("rpc", 0.5, 1, entity.Quantile{Quantile: 0.5, Value: 0.5}),
+	}))
+
+	require.NoError(t, s.DeleteAll(ctx))
+
+	list, err := s.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	_, err = s.GetRange(ctx, "counter1", entity.Counter, nil, time.Time{}, time.Now())
+	assert.ErrorIs(t, err, entity.ErrMetricNotFound)
+
+	// storage is usable after deleting everything
+	require.NoError(t, s.Update(ctx, entity.MetricsList{counter("counter1", 2)}))
+	got, err := s.GetOne(ctx, "counter1", entity.Counter, nil)
+	require.NoError(t, err)
+	assert.Equal(t, counter("counter1", 2), got)
+}
+
+func testPing(t *testing.T, s storage.Storage) {
+	assert.NoError(t, s.Ping(context.Background()))
+}
+
+func testConcurrentUpdates(t *testing.T, s storage.Storage) {
+	const (
+		workers  = 8
+		requests = 25
+	)
+
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < requests; i++ {
+				assert.NoError(t, s.Update(ctx, entity.MetricsList{
+					counter("counter1", 1),
+					gauge("gauge1", float64(w)),
+				}))
+
+				_, err := s.GetAll(ctx)
+				assert.NoError(t, err)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	got, err := s.GetOne(ctx, "counter1", entity.Counter, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(workers*requests), *got.Delta)
+
+	got, err = s.GetOne(ctx, "gauge1", entity.Gauge, nil)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, *got.Value, 0.0)
+	assert.Less(t, *got.Value, float64(workers))
+}
+
+func testClose(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Update(ctx, entity.MetricsList{counter("counter1", 1)}))
+
+	s.Close()
+
+	assert.ErrorIs(t, s.Ping(ctx), storage.ErrClosed)
+	assert.ErrorIs(t, s.Update(ctx, entity.MetricsList{counter("counter1", 1)}), storage.ErrClosed)
+
+	_, err := s.GetAll(ctx)
+	assert.ErrorIs(t, err, storage.ErrClosed)
+
+	// closing twice is harmless
+	assert.NotPanics(t, s.Close)
+}
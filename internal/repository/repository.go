@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/Imomali1/metrics/internal/entity"
 	"github.com/Imomali1/metrics/internal/pkg/file"
@@ -36,10 +37,10 @@ func (r *MetricsRepo) UpdateMetrics(ctx context.Context, batch entity.MetricsLis
 	return nil
 }
 
-// GetMetrics fetches metrics by name and type.
+// GetMetrics fetches metrics by name, type and labels.
 func (r *MetricsRepo) GetMetrics(ctx context.Context, metric entity.Metrics) (entity.Metrics, error) {
-	id, mType := metric.ID, metric.MType
-	return r.store.GetOne(ctx, id, mType)
+	id, mType, labels := metric.ID, metric.MType, metric.Labels
+	return r.store.GetOne(ctx, id, mType, labels)
 }
 
 // ListMetrics fetches all metrics stored in storage.
@@ -47,16 +48,27 @@ func (r *MetricsRepo) ListMetrics(ctx context.Context) (entity.MetricsList, erro
 	return r.store.GetAll(ctx)
 }
 
+// GetHistory fetches samples of metrics received within [from, to].
+func (r *MetricsRepo) GetHistory(
+	ctx context.Context,
+	metric entity.Metrics,
+	from, to time.Time,
+) (entity.SampleList, error) {
+	return r.store.GetRange(ctx, metric.ID, metric.MType, metric.Labels, from, to)
+}
+
 // Ping checks whether database is alive or not.
 func (r *MetricsRepo) Ping(ctx context.Context) error {
 	return r.store.Ping(ctx)
 }
 
 // SyncWrite writes synchronously all metrics from storage.
-func (r *MetricsRepo) SyncWrite(list entity.MetricsList) error {
-	if r.syncFileWriter != nil {
-		return r.syncFileWriter.Write(list)
+func (r *MetricsRepo) SyncWrite(ctx context.Context) error {
+	if r.syncFileWriter == nil {
+		return nil
 	}
 
-	return nil
+	return r.syncFileWriter.Write(func() (entity.MetricsList, error) {
+		return r.store.GetAll(ctx)
+	})
 }
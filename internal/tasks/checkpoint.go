@@ -0,0 +1,32 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+)
+
+type Checkpointer interface {
+	Checkpoint(ctx context.Context) error
+}
+
+// RunCheckpoints makes checkpoint every interval until ctx is done, failed
+// checkpoint is logged and retried on the next tick, so that the log is
+// truncated once the cause, e.g. full disk, is gone. The final checkpoint
+// is made when ctx is done, its error is returned.
+func RunCheckpoints(ctx context.Context, cp Checkpointer, interval time.Duration, log logger.Logger) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := cp.Checkpoint(context.Background()); err != nil {
+				log.Error().Err(err).Msg("failed to make wal checkpoint, retrying on the next tick")
+			}
+		case <-ctx.Done():
+			return cp.Checkpoint(context.Background())
+		}
+	}
+}
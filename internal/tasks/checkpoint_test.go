@@ -0,0 +1,63 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+)
+
+type checkpointerFunc func(ctx context.Context) error
+
+func (f checkpointerFunc) Checkpoint(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestRunCheckpoints(t *testing.T) {
+	log := logger.NewLogger(os.Stdout, "info", "test")
+
+	var calls atomic.Int32
+	cp := checkpointerFunc(func(context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, RunCheckpoints(ctx, cp, 10*time.Millisecond, log))
+	require.Positive(t, calls.Load())
+
+	// failed checkpoints are retried until the disk is freed
+	var failures atomic.Int32
+	recovering := checkpointerFunc(func(context.Context) error {
+		if failures.Add(1) <= 3 {
+			return errors.New("disk full")
+		}
+		return nil
+	})
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, RunCheckpoints(ctx, recovering, time.Millisecond, log))
+	require.Greater(t, failures.Load(), int32(3))
+
+	// the final checkpoint is made on shutdown
+	calls.Store(0)
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, RunCheckpoints(ctx, cp, time.Hour, log))
+	require.Equal(t, int32(1), calls.Load())
+
+	failing := checkpointerFunc(func(context.Context) error {
+		return errors.New("disk full")
+	})
+	require.Error(t, RunCheckpoints(ctx, failing, time.Hour, log))
+}
@@ -1,59 +1,28 @@
 package tasks
 
 import (
-	"bufio"
 	"context"
-	"os"
 	"time"
 
-	"github.com/Imomali1/metrics/internal/entity"
-
-	"github.com/mailru/easyjson"
-
+	"github.com/Imomali1/metrics/internal/pkg/file"
 	"github.com/Imomali1/metrics/internal/pkg/storage"
 )
 
-type FileWriter struct {
-	file   *os.File
-	writer *bufio.Writer
-}
-
-func NewFileWriter(filename string) (*FileWriter, error) {
-	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
-	if err != nil {
-		return nil, err
-	}
-
-	return &FileWriter{
-		file:   file,
-		writer: bufio.NewWriter(file),
-	}, nil
-}
-
+// WriteMetricsToFile replaces snapshot in filename with all stored
+// metrics every interval until ctx is done.
 func WriteMetricsToFile(
 	ctx context.Context,
 	store storage.Storage,
 	filename string,
 	interval time.Duration,
 ) error {
-	fw, err := NewFileWriter(filename)
-	if err != nil {
-		return err
-	}
-
 	storeTicker := time.NewTicker(interval)
+	defer storeTicker.Stop()
 
 	for {
 		select {
 		case <-storeTicker.C:
-			var metrics entity.MetricsList
-			metrics, err = store.GetAll(context.Background())
-			if err != nil {
-				return err
-			}
-
-			err = fw.WriteAllMetrics(metrics)
-			if err != nil {
+			if err := writeSnapshot(store, filename); err != nil {
 				return err
 			}
 		case <-ctx.Done():
@@ -62,22 +31,11 @@ func WriteMetricsToFile(
 	}
 }
 
-func (fw *FileWriter) WriteAllMetrics(metrics entity.MetricsList) error {
-	for _, metric := range metrics {
-		data, err := easyjson.Marshal(metric)
-		if err != nil {
-			return err
-		}
-
-		_, err = fw.writer.Write(data)
-		if err != nil {
-			return err
-		}
-
-		if err = fw.writer.WriteByte('\n'); err != nil {
-			return err
-		}
+func writeSnapshot(store storage.Storage, filename string) error {
+	metrics, err := store.GetAll(context.Background())
+	if err != nil {
+		return err
 	}
 
-	return fw.writer.Flush()
+	return file.WriteSnapshot(filename, metrics)
 }
@@ -1,54 +1,19 @@
 package tasks
 
 import (
-	"bufio"
-	"bytes"
 	"context"
+	"path/filepath"
 	"testing"
+	"time"
 
-	"github.com/mailru/easyjson"
 	"github.com/stretchr/testify/require"
 
 	"github.com/Imomali1/metrics/internal/entity"
+	"github.com/Imomali1/metrics/internal/pkg/file"
 	"github.com/Imomali1/metrics/internal/pkg/storage"
 	"github.com/Imomali1/metrics/internal/pkg/utils"
 )
 
-func TestNewFileWriter(t *testing.T) {
-	tests := []struct {
-		name     string
-		filename string
-		valid    bool
-	}{
-		{
-			name:     "test-1",
-			filename: "/tmp/test-1.json",
-			valid:    true,
-		},
-		{
-			name:     "test-2",
-			filename: "/invalid/path/test-1.json",
-			valid:    false,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Check valid case
-			fw, err := NewFileWriter(tt.filename)
-			if !tt.valid {
-				require.Error(t, err)
-				require.Nil(t, fw)
-				return
-			}
-
-			require.NoError(t, err)
-			require.NotNil(t, fw)
-			require.NotNil(t, fw.file)
-			require.NotNil(t, fw.file)
-		})
-	}
-}
-
 func TestWriteMetricsToFile(t *testing.T) {
 	metrics := entity.MetricsList{
 		{ID: "Gauge1", MType: entity.Gauge, Value: utils.Ptr(123.0)},
@@ -72,33 +37,26 @@ func TestWriteMetricsToFile(t *testing.T) {
 		},
 	}
 
-	// Initialize mock FileWriter
-	var b bytes.Buffer
-	fw := &FileWriter{
-		writer: bufio.NewWriter(&b),
-	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			storedMetrics, err := tt.store.GetAll(context.Background())
-			if tt.wantErr {
-				require.Error(t, err)
-				return
-			}
+			filename := filepath.Join(t.TempDir(), "metrics.json")
 
-			err = fw.WriteAllMetrics(storedMetrics)
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			err := WriteMetricsToFile(ctx, tt.store, filename, 10*time.Millisecond)
 			if tt.wantErr {
 				require.Error(t, err)
 				return
 			}
 			require.NoError(t, err)
 
-			expectedData := ``
-			for _, metric := range metrics {
-				data, _ := easyjson.Marshal(metric)
-				expectedData += string(data) + "\n"
-			}
-			require.Equal(t, expectedData, b.String())
+			// Every tick replaces the snapshot, so the file holds exactly one copy.
+			header, got, err := file.ReadSnapshot(filename)
+			require.NoError(t, err)
+			require.Equal(t, file.SnapshotVersion, header.Version)
+			require.Equal(t, len(metrics), header.Count)
+			require.Equal(t, metrics, got)
 		})
 	}
 }
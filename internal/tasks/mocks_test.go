@@ -3,6 +3,7 @@ package tasks
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 
@@ -45,8 +46,12 @@ func (ms *MockStorage) Update(ctx context.Context, batch entity.MetricsList) err
 	return args.Error(0)
 }
 
-func (ms *MockStorage) GetOne(ctx context.Context, id string, mType string) (entity.Metrics, error) {
-	args := ms.Called(ctx, id, mType)
+func (ms *MockStorage) GetOne(
+	ctx context.Context,
+	id, mType string,
+	labels entity.Labels,
+) (entity.Metrics, error) {
+	args := ms.Called(ctx, id, mType, labels)
 	return args.Get(0).(entity.Metrics), args.Error(1)
 }
 
@@ -55,8 +60,18 @@ func (ms *MockStorage) GetAll(ctx context.Context) (entity.MetricsList, error) {
 	return args.Get(0).(entity.MetricsList), args.Error(1)
 }
 
-func (ms *MockStorage) DeleteOne(ctx context.Context, id, mType string) error {
-	args := ms.Called(ctx, id, mType)
+func (ms *MockStorage) GetRange(
+	ctx context.Context,
+	id, mType string,
+	labels entity.Labels,
+	from, to time.Time,
+) (entity.SampleList, error) {
+	args := ms.Called(ctx, id, mType, labels, from, to)
+	return args.Get(0).(entity.SampleList), args.Error(1)
+}
+
+func (ms *MockStorage) DeleteOne(ctx context.Context, id, mType string, labels entity.Labels) error {
+	args := ms.Called(ctx, id, mType, labels)
 	return args.Error(0)
 }
 
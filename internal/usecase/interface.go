@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/Imomali1/metrics/internal/entity"
 )
@@ -10,5 +11,6 @@ type UseCase interface {
 	UpdateMetrics(context.Context, entity.MetricsList) error
 	GetMetrics(context.Context, entity.Metrics) (entity.Metrics, error)
 	ListMetrics(context.Context) (entity.MetricsList, error)
+	GetHistory(ctx context.Context, metric entity.Metrics, from, to time.Time) (entity.SampleList, error)
 	Ping(ctx context.Context) error
 }
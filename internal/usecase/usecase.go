@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/Imomali1/metrics/internal/entity"
 	"github.com/Imomali1/metrics/internal/repository"
@@ -25,7 +26,7 @@ func (uc *MetricUseCase) UpdateMetrics(
 		return err
 	}
 
-	if err := uc.repo.SyncWrite(batch); err != nil {
+	if err := uc.repo.SyncWrite(ctx); err != nil {
 		return err
 	}
 
@@ -45,6 +46,14 @@ func (uc *MetricUseCase) ListMetrics(
 	return uc.repo.ListMetrics(ctx)
 }
 
+func (uc *MetricUseCase) GetHistory(
+	ctx context.Context,
+	metric entity.Metrics,
+	from, to time.Time,
+) (entity.SampleList, error) {
+	return uc.repo.GetHistory(ctx, metric, from, to)
+}
+
 func (uc *MetricUseCase) Ping(
 	ctx context.Context,
 ) error {
@@ -0,0 +1,268 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/Imomali1/metrics/internal/api"
+	"github.com/Imomali1/metrics/internal/pkg/interceptors"
+	"github.com/Imomali1/metrics/internal/pkg/logger"
+	"github.com/Imomali1/metrics/internal/pkg/pb"
+	"github.com/Imomali1/metrics/internal/pkg/storage"
+	"github.com/Imomali1/metrics/internal/pkg/utils"
+	"github.com/Imomali1/metrics/internal/repository"
+	"github.com/Imomali1/metrics/internal/usecase"
+)
+
+const grpcHashKey = "testKey"
+
+func setupGRPCServer(t *testing.T, privateKey *rsa.PrivateKey, trustedSubnet *net.IPNet) *bufconn.Listener {
+	store, _ := storage.New(context.Background(), "")
+	repo := repository.New(store, nil)
+	uc := usecase.New(repo)
+	server := api.NewGRPCServer(api.Options{
+		Logger:     logger.NewLogger(os.Stdout, "info", "test"),
+		UseCase:    uc,
+		Cfg:        api.Config{HashKey: grpcHashKey, TrustedSubnet: trustedSubnet},
+		PrivateKey: privateKey,
+	})
+
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	return listener
+}
+
+func dialGRPC(t *testing.T, listener *bufconn.Listener, opts ...grpc.DialOption) pb.MetricsClient {
+	opts = append(opts,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+	)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return pb.NewMetricsClient(conn)
+}
+
+func TestGRPCServer(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	listener := setupGRPCServer(t, privateKey, nil)
+	client := dialGRPC(t, listener,
+		grpc.WithChainUnaryInterceptor(
+			interceptors.EncryptRequest(&privateKey.PublicKey),
+			interceptors.SignRequest(grpcHashKey),
+		),
+		grpc.WithChainStreamInterceptor(
+			interceptors.EncryptRequestStream(&privateKey.PublicKey),
+			interceptors.SignRequestStream(grpcHashKey),
+		),
+	)
+
+	ctx := context.Background()
+
+	_, err = client.UpdateMetrics(ctx, &pb.UpdateMetricsRequest{
+		Metrics: []*pb.Metric{
+			{Id: "counter1", Type: "counter", Delta: utils.Ptr[int64](10)},
+			{Id: "gauge1", Type: "gauge", Value: utils.Ptr(1.5), Labels: map[string]string{"host": "a"}},
+			{Id: "latency", Type: "histogram", Histogram: &pb.Histogram{
+				Buckets: []*pb.Bucket{{UpperBound: 0.1, Count: 1}, {UpperBound: 1, Count: 2}},
+				Sum:     0.5,
+				Count:   2,
+			}},
+		},
+	})
+	require.NoError(t, err)
+
+	stream, err := client.UpdateMetricsStream(ctx)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		err = stream.Send(&pb.UpdateMetricsRequest{
+			Metrics: []*pb.Metric{
+				{Id: "counter1", Type: "counter", Delta: utils.Ptr[int64](5)},
+				{Id: "latency", Type: "histogram", Histogram: &pb.Histogram{
+					Buckets: []*pb.Bucket{{UpperBound: 0.1}, {UpperBound: 1, Count: 1}},
+					Sum:     0.5,
+					Count:   1,
+				}},
+			},
+		})
+		require.NoError(t, err)
+	}
+	resp, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	require.Len(t, resp.GetMetrics(), 6)
+
+	tests := []struct {
+		name       string
+		req        *pb.GetMetricRequest
+		wantedCode codes.Code
+		wantedResp *pb.Metric
+	}{
+		{
+			name:       "accumulated counter",
+			req:        &pb.GetMetricRequest{Id: "counter1", Type: "counter"},
+			wantedCode: codes.OK,
+			wantedResp: &pb.Metric{Id: "counter1", Type: "counter", Delta: utils.Ptr[int64](25)},
+		},
+		{
+			name:       "labeled gauge",
+			req:        &pb.GetMetricRequest{Id: "gauge1", Type: "gauge", Labels: map[string]string{"host": "a"}},
+			wantedCode: codes.OK,
+			wantedResp: &pb.Metric{Id: "gauge1", Type: "gauge", Value: utils.Ptr(1.5), Labels: map[string]string{"host": "a"}},
+		},
+		{
+			name:       "merged histogram",
+			req:        &pb.GetMetricRequest{Id: "latency", Type: "histogram"},
+			wantedCode: codes.OK,
+			wantedResp: &pb.Metric{Id: "latency", Type: "histogram", Histogram: &pb.Histogram{
+				Buckets: []*pb.Bucket{{UpperBound: 0.1, Count: 1}, {UpperBound: 1, Count: 5}},
+				Sum:     2,
+				Count:   5,
+			}},
+		},
+		{
+			name:       "gauge without labels not found",
+			req:        &pb.GetMetricRequest{Id: "gauge1", Type: "gauge"},
+			wantedCode: codes.NotFound,
+		},
+		{
+			name:       "invalid metric type",
+			req:        &pb.GetMetricRequest{Id: "gauge1", Type: "invalid"},
+			wantedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.GetMetric(ctx, tt.req)
+			require.Equal(t, tt.wantedCode, status.Code(err))
+			if tt.wantedResp != nil {
+				require.Equal(t, pb.ToEntity(tt.wantedResp), pb.ToEntity(got.GetMetric()))
+			}
+		})
+	}
+
+	list, err := client.ListMetrics(ctx, &pb.ListMetricsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetMetrics(), 3)
+}
+
+func TestGRPCServer_Interceptors(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	listener := setupGRPCServer(t, privateKey, nil)
+
+	metrics := []*pb.Metric{{Id: "counter1", Type: "counter", Delta: utils.Ptr[int64](1)}}
+
+	tests := []struct {
+		name       string
+		opts       []grpc.DialOption
+		wantedCode codes.Code
+	}{
+		{
+			name: "encrypted and signed",
+			opts: []grpc.DialOption{grpc.WithChainUnaryInterceptor(
+				interceptors.EncryptRequest(&privateKey.PublicKey),
+				interceptors.SignRequest(grpcHashKey),
+			)},
+			wantedCode: codes.OK,
+		},
+		{
+			name: "encrypted without hash",
+			opts: []grpc.DialOption{grpc.WithChainUnaryInterceptor(
+				interceptors.EncryptRequest(&privateKey.PublicKey),
+			)},
+			wantedCode: codes.OK,
+		},
+		{
+			name: "signed with another key",
+			opts: []grpc.DialOption{grpc.WithChainUnaryInterceptor(
+				interceptors.EncryptRequest(&privateKey.PublicKey),
+				interceptors.SignRequest("anotherKey"),
+			)},
+			wantedCode: codes.InvalidArgument,
+		},
+		{
+			name:       "not encrypted",
+			wantedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := dialGRPC(t, listener, tt.opts...)
+			_, err := client.UpdateMetrics(context.Background(), &pb.UpdateMetricsRequest{Metrics: metrics})
+			require.Equal(t, tt.wantedCode, status.Code(err))
+		})
+	}
+}
+
+func TestGRPCServer_TrustedSubnet(t *testing.T) {
+	_, subnet, err := net.ParseCIDR("192.168.1.0/24")
+	require.NoError(t, err)
+
+	listener := setupGRPCServer(t, nil, subnet)
+
+	metrics := []*pb.Metric{{Id: "counter1", Type: "counter", Delta: utils.Ptr[int64](1)}}
+
+	tests := []struct {
+		name             string
+		realIP           string
+		wantedUpdateCode codes.Code
+	}{
+		{
+			name:             "ip inside subnet",
+			realIP:           "192.168.1.10",
+			wantedUpdateCode: codes.OK,
+		},
+		{
+			name:             "ip outside subnet",
+			realIP:           "10.0.0.1",
+			wantedUpdateCode: codes.PermissionDenied,
+		},
+		{
+			name:             "without ip",
+			realIP:           "",
+			wantedUpdateCode: codes.PermissionDenied,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := dialGRPC(t, listener,
+				grpc.WithChainUnaryInterceptor(interceptors.RealIP(tt.realIP)),
+				grpc.WithChainStreamInterceptor(interceptors.RealIPStream(tt.realIP)),
+			)
+
+			_, err := client.UpdateMetrics(context.Background(), &pb.UpdateMetricsRequest{Metrics: metrics})
+			require.Equal(t, tt.wantedUpdateCode, status.Code(err))
+
+			stream, err := client.UpdateMetricsStream(context.Background())
+			require.NoError(t, err)
+			_, err = stream.CloseAndRecv()
+			require.Equal(t, tt.wantedUpdateCode, status.Code(err))
+
+			// queries are not limited by trusted subnet
+			_, err = client.ListMetrics(context.Background(), &pb.ListMetricsRequest{})
+			require.NoError(t, err)
+		})
+	}
+}
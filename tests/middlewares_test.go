@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -36,15 +37,54 @@ func TestRSADecrypt(t *testing.T) {
 	})
 
 	rawBody := []byte(`{"message": "test"}`)
-	body, _ := cipher.EncryptRSA(&privateKey.PublicKey, rawBody)
-	req := httptest.NewRequest(http.MethodPost, "/test", bytes.NewBuffer(body))
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
 
-	newReqBody, _ := io.ReadAll(req.Body)
+	legacyBody, _ := cipher.EncryptRSA(&privateKey.PublicKey, rawBody)
+	envelopeBody, _ := cipher.EncryptHybrid(&privateKey.PublicKey, rawBody)
 
-	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Equal(t, rawBody, newReqBody)
+	tests := []struct {
+		name         string
+		body         []byte
+		expectedCode int
+		expectedBody []byte
+	}{
+		{
+			name:         "legacy rsa body",
+			body:         legacyBody,
+			expectedCode: http.StatusOK,
+			expectedBody: rawBody,
+		},
+		{
+			name:         "hybrid envelope body",
+			body:         envelopeBody,
+			expectedCode: http.StatusOK,
+			expectedBody: rawBody,
+		},
+		{
+			name:         "empty body",
+			body:         []byte{},
+			expectedCode: http.StatusOK,
+			expectedBody: []byte{},
+		},
+		{
+			name:         "not encrypted body",
+			body:         rawBody,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/test", bytes.NewBuffer(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != nil {
+				newReqBody, _ := io.ReadAll(req.Body)
+				assert.Equal(t, tt.expectedBody, newReqBody)
+			}
+		})
+	}
 }
 
 func TestReqRespLogger(t *testing.T) {
@@ -114,3 +154,71 @@ func TestValidateHash(t *testing.T) {
 		})
 	}
 }
+
+func TestTrustedSubnet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	l := logger.NewLogger(os.Stdout, "info", "test")
+
+	_, subnet, err := net.ParseCIDR("192.168.1.0/24")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		subnet       *net.IPNet
+		realIP       string
+		expectedCode int
+	}{
+		{
+			name:         "without trusted subnet",
+			subnet:       nil,
+			realIP:       "",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "ip inside subnet",
+			subnet:       subnet,
+			realIP:       "192.168.1.10",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "ip outside subnet",
+			subnet:       subnet,
+			realIP:       "10.0.0.1",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "without X-Real-IP",
+			subnet:       subnet,
+			realIP:       "",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "invalid X-Real-IP",
+			subnet:       subnet,
+			realIP:       "invalid",
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			router.Use(middlewares.TrustedSubnet(l, tt.subnet))
+
+			router.POST("/test", func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/test", nil)
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"net/http"
@@ -10,10 +11,13 @@ import (
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/snappy"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/Imomali1/metrics/internal/api"
 	"github.com/Imomali1/metrics/internal/pkg/logger"
+	"github.com/Imomali1/metrics/internal/pkg/prompb"
 	"github.com/Imomali1/metrics/internal/pkg/storage"
 	"github.com/Imomali1/metrics/internal/repository"
 	"github.com/Imomali1/metrics/internal/usecase"
@@ -32,6 +36,14 @@ func setupRouter() *gin.Engine {
 	return handler
 }
 
+func remoteWriteBody(series ...*prompb.TimeSeries) io.Reader {
+	data, err := proto.Marshal(&prompb.WriteRequest{Timeseries: series})
+	if err != nil {
+		panic(err)
+	}
+	return bytes.NewReader(snappy.Encode(nil, data))
+}
+
 func TestServer(t *testing.T) {
 	handler := setupRouter()
 
@@ -49,7 +61,7 @@ func TestServer(t *testing.T) {
 			name:       "PingDB: ping memory storage",
 			method:     http.MethodGet,
 			url:        "/ping",
-			wantedCode: http.StatusInternalServerError,
+			wantedCode: http.StatusOK,
 		},
 		/*================= UpdateMetricValue =================*/
 		{
@@ -100,6 +112,18 @@ func TestServer(t *testing.T) {
 			url:        "/update/counter/counter1/123",
 			wantedCode: http.StatusOK,
 		},
+		{
+			name:       "UpdateMetricValue: invalid label name",
+			method:     http.MethodPost,
+			url:        "/update/gauge/gauge1/1?invalid-label=a",
+			wantedCode: http.StatusBadRequest,
+		},
+		{
+			name:       "UpdateMetricValue: valid labeled gauge value",
+			method:     http.MethodPost,
+			url:        "/update/gauge/gauge1/567.8?host=a",
+			wantedCode: http.StatusOK,
+		},
 		/*================= GetMetricValueByName =================*/
 		{
 			name:       "GetMetricValueByName: invalid metric type",
@@ -129,6 +153,57 @@ func TestServer(t *testing.T) {
 			wantedCode: http.StatusOK,
 			wantedBody: "123.4",
 		},
+		{
+			name:       "GetMetricValueByName: existing labeled gauge",
+			url:        "/value/gauge/gauge1?host=a",
+			wantedCode: http.StatusOK,
+			wantedBody: "567.8",
+		},
+		{
+			name:       "GetMetricValueByName: non-existing labels",
+			url:        "/value/gauge/gauge1?host=b",
+			wantedCode: http.StatusNotFound,
+		},
+		/*================= GetMetricHistory =================*/
+		{
+			name:       "GetMetricHistory: invalid metric type",
+			method:     http.MethodGet,
+			url:        "/history/invalid-type/name",
+			wantedCode: http.StatusBadRequest,
+		},
+		{
+			name:       "GetMetricHistory: invalid time range",
+			method:     http.MethodGet,
+			url:        "/history/gauge/gauge1?from=invalid-time",
+			wantedCode: http.StatusBadRequest,
+		},
+		{
+			name:       "GetMetricHistory: non-existing gauge",
+			method:     http.MethodGet,
+			url:        "/history/gauge/name",
+			wantedCode: http.StatusNotFound,
+		},
+		{
+			name:       "GetMetricHistory: existing counter",
+			method:     http.MethodGet,
+			url:        "/history/counter/counter1",
+			wantedCode: http.StatusOK,
+		},
+		{
+			name:       "GetMetricHistory: empty time range",
+			method:     http.MethodGet,
+			url:        "/history/gauge/gauge1?to=2000-01-01T00:00:00Z",
+			wantedCode: http.StatusOK,
+			wantedBody: "[]",
+		},
+		/*================= PrometheusMetrics =================*/
+		{
+			name:       "PrometheusMetrics: text exposition",
+			method:     http.MethodGet,
+			url:        "/metrics",
+			wantedCode: http.StatusOK,
+			wantedBody: "# TYPE counter1 counter\ncounter1 123\n# TYPE gauge1 gauge\ngauge1 123.4\ngauge1{host=\"a\"} 567.8\n",
+		},
 		/*================= UpdateMetricValueJSON =================*/
 		{
 			name:               "UpdateMetricValueJSON: invalid request content type",
@@ -153,6 +228,14 @@ func TestServer(t *testing.T) {
 			requestBody:        strings.NewReader(`{"type": "invalid-type"}`),
 			wantedCode:         http.StatusBadRequest,
 		},
+		{
+			name:               "UpdateMetricValueJSON: braces in metric name",
+			method:             http.MethodPost,
+			url:                "/update/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id": "gauge1{host=\"a\"}","type":"gauge","value": 1}`),
+			wantedCode:         http.StatusBadRequest,
+		},
 		{
 			name:               "UpdateMetricValueJSON: valid counter update",
 			method:             http.MethodPost,
@@ -169,6 +252,22 @@ func TestServer(t *testing.T) {
 			requestBody:        strings.NewReader(`{"id": "gauge2","type":"gauge","value": 321.5}`),
 			wantedCode:         http.StatusOK,
 		},
+		{
+			name:               "UpdateMetricValueJSON: invalid labels",
+			method:             http.MethodPost,
+			url:                "/update/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id": "gauge2","type":"gauge","value": 1,"labels":{"1host":"a"}}`),
+			wantedCode:         http.StatusBadRequest,
+		},
+		{
+			name:               "UpdateMetricValueJSON: valid labeled gauge update",
+			method:             http.MethodPost,
+			url:                "/update/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id": "gauge2","type":"gauge","value": 765.4,"labels":{"host":"a"}}`),
+			wantedCode:         http.StatusOK,
+		},
 		/*================= GetMetricValueByNameJSON =================*/
 		{
 			name:               "GetMetricValueByNameJSON: invalid request content type",
@@ -227,6 +326,253 @@ func TestServer(t *testing.T) {
 			wantedCode:         http.StatusOK,
 			wantedBody:         `{"id": "gauge2","type":"gauge","value": 321.5}`,
 		},
+		{
+			name:               "GetMetricValueByNameJSON: get valid labeled gauge",
+			method:             http.MethodPost,
+			url:                "/value/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id": "gauge2","type":"gauge","labels":{"host":"a"}}`),
+			wantedCode:         http.StatusOK,
+			wantedBody:         `{"id": "gauge2","type":"gauge","value": 765.4,"labels":{"host":"a"}}`,
+		},
+		/*================= Histogram =================*/
+		{
+			name:       "Histogram: observe unknown histogram",
+			method:     http.MethodPost,
+			url:        "/update/histogram/latency/0.5",
+			wantedCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Histogram: invalid buckets",
+			method:             http.MethodPost,
+			url:                "/update/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id":"latency","type":"histogram","histogram":{"buckets":[{"le":1,"count":1},{"le":0.1,"count":1}],"sum":0.5,"count":1}}`),
+			wantedCode:         http.StatusBadRequest,
+		},
+		{
+			name:               "Histogram: missing value",
+			method:             http.MethodPost,
+			url:                "/update/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id":"latency","type":"histogram"}`),
+			wantedCode:         http.StatusBadRequest,
+		},
+		{
+			name:               "Histogram: le label is reserved",
+			method:             http.MethodPost,
+			url:                "/update/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id":"latency","type":"histogram","labels":{"le":"1"},"histogram":{"buckets":[{"le":1,"count":1}],"sum":0.5,"count":1}}`),
+			wantedCode:         http.StatusBadRequest,
+		},
+		{
+			name:               "Histogram: valid batch is merged",
+			method:             http.MethodPost,
+			url:                "/updates/",
+			requestContentType: "application/json",
+			requestBody: strings.NewReader(`[
+				{"id":"latency","type":"histogram","histogram":{"buckets":[{"le":0.1,"count":1},{"le":1,"count":2}],"sum":0.5,"count":2}},
+				{"id":"latency","type":"histogram","histogram":{"buckets":[{"le":0.1,"count":0},{"le":1,"count":0}],"sum":3,"count":1}}
+			]`),
+			wantedCode: http.StatusOK,
+		},
+		{
+			name:       "Histogram: observe value into stored buckets",
+			method:     http.MethodPost,
+			url:        "/update/histogram/latency/0.05",
+			wantedCode: http.StatusOK,
+		},
+		{
+			name:               "Histogram: mismatching buckets",
+			method:             http.MethodPost,
+			url:                "/update/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id":"latency","type":"histogram","histogram":{"buckets":[{"le":2,"count":1}],"sum":1,"count":1}}`),
+			wantedCode:         http.StatusBadRequest,
+		},
+		{
+			name:       "Histogram: get value",
+			method:     http.MethodGet,
+			url:        "/value/histogram/latency",
+			wantedCode: http.StatusOK,
+			wantedBody: `{"buckets":[{"le":0.1,"count":2},{"le":1,"count":3}],"sum":3.55,"count":4}`,
+		},
+		{
+			name:               "Histogram: get value JSON",
+			method:             http.MethodPost,
+			url:                "/value/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id":"latency","type":"histogram"}`),
+			wantedCode:         http.StatusOK,
+			wantedBody:         `{"id":"latency","type":"histogram","histogram":{"buckets":[{"le":0.1,"count":2},{"le":1,"count":3}],"sum":3.55,"count":4}}`,
+		},
+		{
+			name:       "Histogram: list metrics",
+			method:     http.MethodGet,
+			url:        "/",
+			wantedCode: http.StatusOK,
+		},
+		{
+			name:       "Histogram: history",
+			method:     http.MethodGet,
+			url:        "/history/histogram/latency",
+			wantedCode: http.StatusOK,
+		},
+		/*================= Summary =================*/
+		{
+			name:       "Summary: update in uri",
+			method:     http.MethodPost,
+			url:        "/update/summary/rpc/0.5",
+			wantedCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Summary: invalid quantiles",
+			method:             http.MethodPost,
+			url:                "/update/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id":"rpc","type":"summary","summary":{"quantiles":[{"quantile":0.9,"value":1},{"quantile":0.5,"value":0.5}],"sum":1,"count":2}}`),
+			wantedCode:         http.StatusBadRequest,
+		},
+		{
+			name:               "Summary: missing value",
+			method:             http.MethodPost,
+			url:                "/update/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id":"rpc","type":"summary"}`),
+			wantedCode:         http.StatusBadRequest,
+		},
+		{
+			name:               "Summary: quantile label is reserved",
+			method:             http.MethodPost,
+			url:                "/update/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id":"rpc","type":"summary","labels":{"quantile":"0.5"},"summary":{"quantiles":[{"quantile":0.5,"value":0.5}],"sum":1,"count":2}}`),
+			wantedCode:         http.StatusBadRequest,
+		},
+		{
+			name:               "Summary: valid batch is merged",
+			method:             http.MethodPost,
+			url:                "/updates/",
+			requestContentType: "application/json",
+			requestBody: strings.NewReader(`[
+				{"id":"rpc","type":"summary","summary":{"quantiles":[{"quantile":0.5,"value":0.2},{"quantile":0.99,"value":0.9}],"sum":1.5,"count":3}},
+				{"id":"rpc","type":"summary","summary":{"quantiles":[{"quantile":0.5,"value":0.4},{"quantile":0.99,"value":1.1}],"sum":2,"count":2}}
+			]`),
+			wantedCode: http.StatusOK,
+		},
+		{
+			name:       "Summary: get value",
+			method:     http.MethodGet,
+			url:        "/value/summary/rpc",
+			wantedCode: http.StatusOK,
+			wantedBody: `{"quantiles":[{"quantile":0.5,"value":0.4},{"quantile":0.99,"value":1.1}],"sum":3.5,"count":5}`,
+		},
+		{
+			name:               "Summary: get value JSON",
+			method:             http.MethodPost,
+			url:                "/value/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id":"rpc","type":"summary"}`),
+			wantedCode:         http.StatusOK,
+			wantedBody:         `{"id":"rpc","type":"summary","summary":{"quantiles":[{"quantile":0.5,"value":0.4},{"quantile":0.99,"value":1.1}],"sum":3.5,"count":5}}`,
+		},
+		{
+			name:       "Summary: list metrics",
+			method:     http.MethodGet,
+			url:        "/",
+			wantedCode: http.StatusOK,
+		},
+		{
+			name:       "Summary: history",
+			method:     http.MethodGet,
+			url:        "/history/summary/rpc",
+			wantedCode: http.StatusOK,
+		},
+		/*================= InfluxWrite =================*/
+		{
+			name:        "InfluxWrite: invalid line",
+			method:      http.MethodPost,
+			url:         "/api/v2/write",
+			requestBody: strings.NewReader("cpu,host=a"),
+			wantedCode:  http.StatusBadRequest,
+		},
+		{
+			name:        "InfluxWrite: invalid precision",
+			method:      http.MethodPost,
+			url:         "/api/v2/write?precision=h",
+			requestBody: strings.NewReader("cpu usage=1.5"),
+			wantedCode:  http.StatusBadRequest,
+		},
+		{
+			name:        "InfluxWrite: invalid tag name",
+			method:      http.MethodPost,
+			url:         "/api/v2/write",
+			requestBody: strings.NewReader("cpu,host-name=a usage=1.5"),
+			wantedCode:  http.StatusBadRequest,
+		},
+		{
+			name:        "InfluxWrite: valid points",
+			method:      http.MethodPost,
+			url:         "/api/v2/write?org=o&bucket=b&precision=s",
+			requestBody: strings.NewReader("cpu,host=a usage=1.5,count=2i 1700000000\ncpu,host=a count=3i,note=\"busy\"\n"),
+			wantedCode:  http.StatusNoContent,
+		},
+		{
+			name:               "InfluxWrite: get float field as gauge",
+			method:             http.MethodPost,
+			url:                "/value/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id": "cpu_usage","type":"gauge","labels":{"host":"a"}}`),
+			wantedCode:         http.StatusOK,
+			wantedBody:         `{"id": "cpu_usage","type":"gauge","value": 1.5,"labels":{"host":"a"}}`,
+		},
+		{
+			name:               "InfluxWrite: get integer field as counter",
+			method:             http.MethodPost,
+			url:                "/value/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id": "cpu_count","type":"counter","labels":{"host":"a"}}`),
+			wantedCode:         http.StatusOK,
+			wantedBody:         `{"id": "cpu_count","type":"counter","delta": 5,"labels":{"host":"a"}}`,
+		},
+		/*================= RemoteWrite =================*/
+		{
+			name:        "RemoteWrite: too large body",
+			method:      http.MethodPost,
+			url:         "/api/v1/write",
+			requestBody: strings.NewReader(strings.Repeat("a", 10<<20+1)),
+			wantedCode:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "RemoteWrite: not snappy body",
+			method:      http.MethodPost,
+			url:         "/api/v1/write",
+			requestBody: strings.NewReader("up 1"),
+			wantedCode:  http.StatusBadRequest,
+		},
+		{
+			name:   "RemoteWrite: valid write request",
+			method: http.MethodPost,
+			url:    "/api/v1/write",
+			requestBody: remoteWriteBody(&prompb.TimeSeries{
+				Labels: []*prompb.Label{{Name: prompb.NameLabel, Value: "up"}, {Name: "job", Value: "node"}},
+				Samples: []*prompb.Sample{
+					{Value: 1, Timestamp: 2000},
+					{Value: 0, Timestamp: 1000},
+				},
+			}),
+			wantedCode: http.StatusNoContent,
+		},
+		{
+			name:               "RemoteWrite: get latest sample as gauge",
+			method:             http.MethodPost,
+			url:                "/value/",
+			requestContentType: "application/json",
+			requestBody:        strings.NewReader(`{"id": "up","type":"gauge","labels":{"job":"node"}}`),
+			wantedCode:         http.StatusOK,
+			wantedBody:         `{"id": "up","type":"gauge","value": 1,"labels":{"job":"node"}}`,
+		},
 	}
 
 	for _, test := range tests {